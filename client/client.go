@@ -0,0 +1,208 @@
+// Package client controls the faults of a fusestream server, e.g. from Go integration tests.
+//
+//	c, err := client.New("unix:///run/fusestream/fs.sock")
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//
+//	fault, err := c.Fuse().Path("wal/.*").Op(client.Write).Delay(200 * time.Millisecond).Probability(0.1).Inject(ctx)
+//
+// Tests use InjectScoped instead, the fault is removed when the test ends. The package only depends on the generated
+// pb package and gRPC, so it doesn't need cgo and the FUSE headers.
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/zperf/fusestream/pb"
+	"github.com/zperf/fusestream/rpcauth"
+)
+
+// Client controls the faults of one target of the server. Clients returned by Target share the connection
+type Client struct {
+	conn   *grpc.ClientConn
+	rpc    pb.FuseStreamClient
+	target string
+}
+
+type options struct {
+	tls        bool
+	caFile     string
+	certFile   string
+	keyFile    string
+	serverName string
+	token      string
+	target     string
+	dialOpts   []grpc.DialOption
+}
+
+type Option func(*options)
+
+// WithTLS connects with TLS. The server is verified by caFile, or by the system CAs if it's empty, and against
+// serverName instead of the address host if set. certFile and keyFile are the client certificate for mTLS, both may be
+// empty
+func WithTLS(caFile, certFile, keyFile, serverName string) Option {
+	return func(o *options) {
+		o.tls = true
+		o.caFile = caFile
+		o.certFile = certFile
+		o.keyFile = keyFile
+		o.serverName = serverName
+	}
+}
+
+// WithToken sends the bearer token with every RPC, New fails unless the connection is TLS or a unix socket
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithTarget selects the target of fusestream daemon, see Client.Target
+func WithTarget(name string) Option {
+	return func(o *options) {
+		o.target = name
+	}
+}
+
+// WithDialOptions appends gRPC dial options, e.g. interceptors
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+// New connects to the server at a host:port or a unix:///path/to.sock address. The connection is established lazily
+// by the first RPC
+func New(address string, opts ...Option) (*Client, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	creds := insecure.NewCredentials()
+	if o.tls {
+		config, err := rpcauth.ClientTLSConfig(o.caFile, o.certFile, o.keyFile, o.serverName)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(config)
+	}
+
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if o.token != "" {
+		// like the server, the token is only sent over TLS or a unix socket, never in clear over TCP
+		secure := !strings.HasPrefix(address, "unix:")
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(rpcauth.TokenCredentials{Token: o.token, Secure: secure}))
+	}
+	conn, err := grpc.NewClient(address, append(dialOpts, o.dialOpts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromConn(conn).Target(o.target), nil
+}
+
+// NewFromConn uses an existing connection, Close closes it
+func NewFromConn(conn *grpc.ClientConn) *Client {
+	return &Client{conn: conn, rpc: pb.NewFuseStreamClient(conn)}
+}
+
+// Target returns a client of the named target of fusestream daemon, sharing the connection. The empty name is the
+// only target of the server
+func (c *Client) Target(name string) *Client {
+	t := *c
+	t.target = name
+	return &t
+}
+
+// Conn returns the connection, e.g. for pb.NewDaemonClient
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conn
+}
+
+// FuseStream returns the generated client of the connection
+func (c *Client) FuseStream() pb.FuseStreamClient {
+	return c.rpc
+}
+
+// Close closes the connection, shared by the clients of all targets
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Fuse starts building a FUSE fault of the target
+func (c *Client) Fuse() *FuseFaultBuilder {
+	return &FuseFaultBuilder{client: c, fault: &pb.FuseFault{}, probability: 1}
+}
+
+// Nbd starts building an NBD fault of the target
+func (c *Client) Nbd() *NbdFaultBuilder {
+	return &NbdFaultBuilder{client: c, fault: &pb.NbdFault{}, probability: 1}
+}
+
+func (c *Client) ListFaults(ctx context.Context) (*pb.ListFaultsResponse, error) {
+	return c.rpc.ListFaults(ctx, &pb.TargetRequest{Target: c.target})
+}
+
+// DeleteFaults removes the faults matching the request and returns their ids, the target of the request is ignored
+func (c *Client) DeleteFaults(ctx context.Context, req *pb.DeleteFaultRequest) ([]int32, error) {
+	req.Target = c.target
+	rsp, err := c.rpc.DeleteFault(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetDeletedIds(), nil
+}
+
+// RemoveFaults removes the faults by id, nothing is removed if any of them doesn't exist
+func (c *Client) RemoveFaults(ctx context.Context, ids ...int32) error {
+	_, err := c.DeleteFaults(ctx, &pb.DeleteFaultRequest{Id: ids})
+	return err
+}
+
+// RemoveAllFaults removes all faults of the target and returns their ids
+func (c *Client) RemoveAllFaults(ctx context.Context) ([]int32, error) {
+	return c.DeleteFaults(ctx, &pb.DeleteFaultRequest{All: true})
+}
+
+// PauseFaults pauses the faults without removing them, all faults if no ids given
+func (c *Client) PauseFaults(ctx context.Context, ids ...int32) error {
+	return c.setFaultsEnabled(ctx, ids, false)
+}
+
+// ResumeFaults resumes the paused faults, all faults if no ids given
+func (c *Client) ResumeFaults(ctx context.Context, ids ...int32) error {
+	return c.setFaultsEnabled(ctx, ids, true)
+}
+
+func (c *Client) setFaultsEnabled(ctx context.Context, ids []int32, enabled bool) error {
+	if len(ids) == 0 {
+		var err error
+		if enabled {
+			_, err = c.rpc.ResumeAll(ctx, &pb.TargetRequest{Target: c.target})
+		} else {
+			_, err = c.rpc.PauseAll(ctx, &pb.TargetRequest{Target: c.target})
+		}
+		return err
+	}
+
+	rsp, err := c.rpc.SetFaultEnabled(ctx, &pb.SetFaultEnabledRequest{
+		Id:      ids,
+		Enabled: enabled,
+		Target:  c.target,
+	})
+	if err != nil {
+		return err
+	}
+	if len(rsp.GetUpdatedIds()) != len(ids) {
+		return fmt.Errorf("some faults not found, updated: %v", rsp.GetUpdatedIds())
+	}
+	return nil
+}
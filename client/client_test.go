@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zperf/fusestream/pb"
+	"github.com/zperf/fusestream/rpcauth"
+	"github.com/zperf/fusestream/v1"
+)
+
+// serve starts a single target RPC server and returns its address
+func serve(t *testing.T) string {
+	server := grpc.NewServer()
+	pb.RegisterFuseStreamServer(server, &fusestream.Rpc{Faults: fusestream.NewFaultManager()})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func TestInject(t *testing.T) {
+	ctx := context.Background()
+	c := NewScoped(t, serve(t))
+
+	fuse, err := c.Fuse().Path("wal/.*").Op(Write).Delay(200*time.Millisecond).Probability(0.1).
+		Pid(42, true).OpenFlags(OSync).Inject(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "wal/.*", fuse.PathRe)
+	require.Equal(t, pb.FuseOp_FUSE_WRITE, fuse.Op)
+	require.Equal(t, float32(0.1), fuse.GetDelayFault().Possibility)
+	require.EqualValues(t, 200, fuse.GetDelayFault().DelayMs)
+	require.EqualValues(t, 42, fuse.Process.GetPid())
+	require.True(t, fuse.Process.IncludeChildren)
+	require.True(t, fuse.GetEnabled())
+
+	nbd, err := c.Nbd().Op(NbdWrite).Error("EIO").ReturnValue(-5).Range(0, 4096).MatchAll().Disabled().Inject(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "EIO", nbd.GetErrorFault().Err)
+	require.EqualValues(t, 1, nbd.GetErrorFault().Possibility)
+	require.EqualValues(t, -5, nbd.GetReturnValueFault().ReturnValue)
+	require.Equal(t, pb.NbdMatchPolicy_NBD_MATCH_ALL, nbd.MatchPolicy)
+	require.False(t, nbd.GetEnabled())
+
+	require.NoError(t, c.ResumeFaults(ctx, nbd.Id))
+	require.Error(t, c.PauseFaults(ctx, nbd.Id, 100))
+	require.NoError(t, c.PauseFaults(ctx))
+	rsp, err := c.ListFaults(ctx)
+	require.NoError(t, err)
+	require.True(t, rsp.Paused)
+	require.Len(t, rsp.FuseFaults, 1)
+	require.Len(t, rsp.NbdFaults, 1)
+
+	require.NoError(t, c.RemoveFaults(ctx, fuse.Id))
+	require.Equal(t, codes.NotFound, status.Code(c.RemoveFaults(ctx, fuse.Id)))
+	deleted, err := c.RemoveAllFaults(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []int32{nbd.Id}, deleted)
+
+	// single target servers only accept the empty target
+	_, err = c.Target("db").ListFaults(ctx)
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestToken(t *testing.T) {
+	server := grpc.NewServer(rpcauth.TokenServerOptions("secret")...)
+	pb.RegisterFuseStreamServer(server, &fusestream.Rpc{Faults: fusestream.NewFaultManager()})
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "test.sock"))
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	// the token is sent over a unix socket
+	_, err = NewScoped(t, "unix://"+listener.Addr().String(), WithToken("secret")).ListFaults(context.Background())
+	require.NoError(t, err)
+	_, err = NewScoped(t, "unix://"+listener.Addr().String()).ListFaults(context.Background())
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// but never in clear over TCP
+	_, err = New(serve(t), WithToken("secret"))
+	require.ErrorContains(t, err, "transport level security")
+}
+
+func TestInvalidFault(t *testing.T) {
+	c := NewScoped(t, serve(t))
+
+	_, err := c.Fuse().Path(".*").Op(Read).Inject(context.Background())
+	require.ErrorIs(t, err, errNoAction)
+	_, err = c.Nbd().Op(NbdRead).Delay(time.Second).Probability(2).Inject(context.Background())
+	require.Error(t, err)
+	_, err = c.Nbd().Op(NbdRead).Delay(500 * time.Microsecond).Inject(context.Background())
+	require.ErrorContains(t, err, "whole milliseconds")
+}
+
+func TestReuseBuilder(t *testing.T) {
+	c := NewScoped(t, serve(t))
+
+	b := c.Fuse().Path(".*").Op(Write).Delay(time.Millisecond).Uid(1000).OpenFlags(OSync).Fh(1)
+	first, err := b.Fault()
+	require.NoError(t, err)
+	b.Uid(0).OpenFlags(ODirect).Fh(2)
+	second, err := b.Fault()
+	require.NoError(t, err)
+
+	// building again leaves the faults already built alone
+	require.EqualValues(t, 1000, first.Process.GetUid())
+	require.Equal(t, []pb.OpenFlag{OSync}, first.OpenFlags)
+	require.Equal(t, []uint64{1}, first.Fhs)
+	require.EqualValues(t, 0, second.Process.GetUid())
+	require.Equal(t, []pb.OpenFlag{OSync, ODirect}, second.OpenFlags)
+}
+
+func TestInjectScoped(t *testing.T) {
+	ctx := context.Background()
+	c := NewScoped(t, serve(t))
+
+	t.Run("scoped", func(t *testing.T) {
+		c.Fuse().Path(".*").Op(Fsync).ReturnValue(-5).InjectScoped(t)
+		// a fault of the same path and op scoped to a process doesn't replace the first one
+		c.Fuse().Path(".*").Op(Fsync).Delay(time.Second).Pid(42, false).InjectScoped(t)
+		c.Nbd().Op(NbdSync).Delay(time.Second).InjectScoped(t)
+		removed := c.Fuse().Path(".*").Op(Read).Delay(time.Second).InjectScoped(t)
+		require.NoError(t, c.RemoveFaults(ctx, removed.Id))
+
+		rsp, err := c.ListFaults(ctx)
+		require.NoError(t, err)
+		require.Len(t, rsp.FuseFaults, 2)
+		require.Len(t, rsp.NbdFaults, 1)
+	})
+
+	rsp, err := c.ListFaults(ctx)
+	require.NoError(t, err)
+	require.Empty(t, rsp.FuseFaults)
+	require.Empty(t, rsp.NbdFaults)
+}
@@ -0,0 +1,331 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+// The FUSE operations of FuseFaultBuilder.Op
+const (
+	Statfs     = pb.FuseOp_FUSE_STATFS
+	Mknod      = pb.FuseOp_FUSE_MKNOD
+	Mkdir      = pb.FuseOp_FUSE_MKDIR
+	Unlink     = pb.FuseOp_FUSE_UNLINK
+	Rmdir      = pb.FuseOp_FUSE_RMDIR
+	Link       = pb.FuseOp_FUSE_LINK
+	Symlink    = pb.FuseOp_FUSE_SYMLINK
+	Readlink   = pb.FuseOp_FUSE_READLINK
+	Rename     = pb.FuseOp_FUSE_RENAME
+	Chmod      = pb.FuseOp_FUSE_CHMOD
+	Chown      = pb.FuseOp_FUSE_CHOWN
+	Utimens    = pb.FuseOp_FUSE_UTIMENS
+	Create     = pb.FuseOp_FUSE_CREATE
+	Open       = pb.FuseOp_FUSE_OPEN
+	Getattr    = pb.FuseOp_FUSE_GETATTR
+	Truncate   = pb.FuseOp_FUSE_TRUNCATE
+	Read       = pb.FuseOp_FUSE_READ
+	Write      = pb.FuseOp_FUSE_WRITE
+	Release    = pb.FuseOp_FUSE_RELEASE
+	Fsync      = pb.FuseOp_FUSE_FSYNC
+	Opendir    = pb.FuseOp_FUSE_OPENDIR
+	Readdir    = pb.FuseOp_FUSE_READDIR
+	Releasedir = pb.FuseOp_FUSE_RELEASEDIR
+)
+
+// The NBD operations of NbdFaultBuilder.Op
+const (
+	NbdRead  = pb.NbdOp_NBD_READAT
+	NbdWrite = pb.NbdOp_NBD_WRITEAT
+	NbdSize  = pb.NbdOp_NBD_SIZE
+	NbdSync  = pb.NbdOp_NBD_SYNC
+)
+
+// The open flags of FuseFaultBuilder.OpenFlags
+const (
+	ODirect = pb.OpenFlag_OPEN_FLAG_DIRECT
+	OSync   = pb.OpenFlag_OPEN_FLAG_SYNC
+	ODsync  = pb.OpenFlag_OPEN_FLAG_DSYNC
+	OAppend = pb.OpenFlag_OPEN_FLAG_APPEND
+)
+
+var errNoAction = errors.New("no fault action set")
+
+func checkProbability(p float32) error {
+	if p < 0 || p > 1 {
+		return fmt.Errorf("invalid probability %v, must be in [0, 1]", p)
+	}
+	return nil
+}
+
+// delayMs converts the delay of a builder, the faults only have a millisecond resolution
+func delayMs(d time.Duration) (int64, error) {
+	if d < 0 || d%time.Millisecond != 0 {
+		return 0, fmt.Errorf("invalid delay %v, must be whole milliseconds", d)
+	}
+	return d.Milliseconds(), nil
+}
+
+// FuseFaultBuilder builds a FUSE fault. The probability applies to all actions, 1 by default
+type FuseFaultBuilder struct {
+	client      *Client
+	fault       *pb.FuseFault
+	probability float32
+	delay       *time.Duration
+	returnValue *int64
+}
+
+// Path matches the paths relative to the mountpoint by the regex
+func (b *FuseFaultBuilder) Path(re string) *FuseFaultBuilder {
+	b.fault.PathRe = re
+	return b
+}
+
+func (b *FuseFaultBuilder) Op(op pb.FuseOp) *FuseFaultBuilder {
+	b.fault.Op = op
+	return b
+}
+
+// Delay delays the operations, d must be whole milliseconds
+func (b *FuseFaultBuilder) Delay(d time.Duration) *FuseFaultBuilder {
+	b.delay = &d
+	return b
+}
+
+// ReturnValue fails the operations with the return code, e.g. -5 for EIO
+func (b *FuseFaultBuilder) ReturnValue(rc int64) *FuseFaultBuilder {
+	b.returnValue = &rc
+	return b
+}
+
+func (b *FuseFaultBuilder) Probability(p float32) *FuseFaultBuilder {
+	b.probability = p
+	return b
+}
+
+// Disabled injects the fault paused, see Client.ResumeFaults
+func (b *FuseFaultBuilder) Disabled() *FuseFaultBuilder {
+	enabled := false
+	b.fault.Enabled = &enabled
+	return b
+}
+
+func (b *FuseFaultBuilder) process() *pb.ProcessFilter {
+	if b.fault.Process == nil {
+		b.fault.Process = &pb.ProcessFilter{}
+	}
+	return b.fault.Process
+}
+
+// Pid only matches the calling process, or its descendants too if includeChildren is set
+func (b *FuseFaultBuilder) Pid(pid int32, includeChildren bool) *FuseFaultBuilder {
+	b.process().Pid = &pid
+	b.process().IncludeChildren = includeChildren
+	return b
+}
+
+func (b *FuseFaultBuilder) Uid(uid uint32) *FuseFaultBuilder {
+	b.process().Uid = &uid
+	return b
+}
+
+func (b *FuseFaultBuilder) Gid(gid uint32) *FuseFaultBuilder {
+	b.process().Gid = &gid
+	return b
+}
+
+// Comm matches /proc/<pid>/comm of the calling process by the regex
+func (b *FuseFaultBuilder) Comm(re string) *FuseFaultBuilder {
+	b.process().CommRe = re
+	return b
+}
+
+// OpenFlags only matches the files opened with all of the flags
+func (b *FuseFaultBuilder) OpenFlags(flags ...pb.OpenFlag) *FuseFaultBuilder {
+	b.fault.OpenFlags = append(b.fault.OpenFlags, flags...)
+	return b
+}
+
+// Fh only matches the file handles
+func (b *FuseFaultBuilder) Fh(fhs ...uint64) *FuseFaultBuilder {
+	b.fault.Fhs = append(b.fault.Fhs, fhs...)
+	return b
+}
+
+// Fault returns the definition of the fault to inject
+func (b *FuseFaultBuilder) Fault() (*pb.FuseFault, error) {
+	if b.delay == nil && b.returnValue == nil {
+		return nil, errNoAction
+	}
+	if err := checkProbability(b.probability); err != nil {
+		return nil, err
+	}
+
+	// the builder may be reused, the faults built must not share its state
+	f := &pb.FuseFault{
+		PathRe:    b.fault.PathRe,
+		Op:        b.fault.Op,
+		Enabled:   b.fault.Enabled,
+		OpenFlags: slices.Clone(b.fault.OpenFlags),
+		Fhs:       slices.Clone(b.fault.Fhs),
+	}
+	if b.fault.Process != nil {
+		f.Process = proto.Clone(b.fault.Process).(*pb.ProcessFilter)
+	}
+	if b.delay != nil {
+		ms, err := delayMs(*b.delay)
+		if err != nil {
+			return nil, err
+		}
+		f.Delay = &pb.FuseFault_DelayFault{
+			DelayFault: &pb.DelayFault{Possibility: b.probability, DelayMs: ms},
+		}
+	}
+	if b.returnValue != nil {
+		f.ReturnValue = &pb.FuseFault_ReturnValueFault{
+			ReturnValueFault: &pb.ReturnValueFault{Possibility: b.probability, ReturnValue: *b.returnValue},
+		}
+	}
+	return f, nil
+}
+
+// Inject injects the fault and returns its definition, including the id
+func (b *FuseFaultBuilder) Inject(ctx context.Context) (*pb.FuseFault, error) {
+	f, err := b.Fault()
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := b.client.rpc.InjectFuseFault(ctx, &pb.InjectFuseFaultRequest{Fault: f, Target: b.client.target})
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetFault(), nil
+}
+
+// NbdFaultBuilder builds an NBD fault. The probability applies to all actions, 1 by default
+type NbdFaultBuilder struct {
+	client      *Client
+	fault       *pb.NbdFault
+	probability float32
+	delay       *time.Duration
+	returnValue *int64
+	err         *string
+}
+
+func (b *NbdFaultBuilder) Op(op pb.NbdOp) *NbdFaultBuilder {
+	b.fault.Op = op
+	return b
+}
+
+// Delay delays the I/Os, d must be whole milliseconds
+func (b *NbdFaultBuilder) Delay(d time.Duration) *NbdFaultBuilder {
+	b.delay = &d
+	return b
+}
+
+func (b *NbdFaultBuilder) ReturnValue(rc int64) *NbdFaultBuilder {
+	b.returnValue = &rc
+	return b
+}
+
+// Error fails the I/Os with the error message
+func (b *NbdFaultBuilder) Error(err string) *NbdFaultBuilder {
+	b.err = &err
+	return b
+}
+
+func (b *NbdFaultBuilder) Probability(p float32) *NbdFaultBuilder {
+	b.probability = p
+	return b
+}
+
+// Disabled injects the fault paused, see Client.ResumeFaults
+func (b *NbdFaultBuilder) Disabled() *NbdFaultBuilder {
+	enabled := false
+	b.fault.Enabled = &enabled
+	return b
+}
+
+// Priority orders the faults of the same op, the highest first
+func (b *NbdFaultBuilder) Priority(priority int32) *NbdFaultBuilder {
+	b.fault.Priority = priority
+	return b
+}
+
+// Range only matches the I/Os overlapping [start, end), end = 0 means unbounded
+func (b *NbdFaultBuilder) Range(start, end int64) *NbdFaultBuilder {
+	b.fault.OffsetStart = start
+	b.fault.OffsetEnd = end
+	return b
+}
+
+// MatchAll keeps evaluating the lower priority faults after this fault matched
+func (b *NbdFaultBuilder) MatchAll() *NbdFaultBuilder {
+	b.fault.MatchPolicy = pb.NbdMatchPolicy_NBD_MATCH_ALL
+	return b
+}
+
+// PreCond only matches the I/Os the tengo expression is true for, offset and length are defined
+func (b *NbdFaultBuilder) PreCond(expression string) *NbdFaultBuilder {
+	b.fault.PreCond = &pb.NbdFault_Expression{Expression: expression}
+	return b
+}
+
+// Fault returns the definition of the fault to inject
+func (b *NbdFaultBuilder) Fault() (*pb.NbdFault, error) {
+	if b.delay == nil && b.returnValue == nil && b.err == nil {
+		return nil, errNoAction
+	}
+	if err := checkProbability(b.probability); err != nil {
+		return nil, err
+	}
+
+	f := &pb.NbdFault{
+		Op:          b.fault.Op,
+		PreCond:     b.fault.PreCond,
+		Enabled:     b.fault.Enabled,
+		Priority:    b.fault.Priority,
+		OffsetStart: b.fault.OffsetStart,
+		OffsetEnd:   b.fault.OffsetEnd,
+		MatchPolicy: b.fault.MatchPolicy,
+	}
+	if b.delay != nil {
+		ms, err := delayMs(*b.delay)
+		if err != nil {
+			return nil, err
+		}
+		f.Delay = &pb.NbdFault_DelayFault{
+			DelayFault: &pb.DelayFault{Possibility: b.probability, DelayMs: ms},
+		}
+	}
+	if b.returnValue != nil {
+		f.ReturnValue = &pb.NbdFault_ReturnValueFault{
+			ReturnValueFault: &pb.ReturnValueFault{Possibility: b.probability, ReturnValue: *b.returnValue},
+		}
+	}
+	if b.err != nil {
+		f.Err = &pb.NbdFault_ErrorFault{
+			ErrorFault: &pb.ErrorFault{Possibility: b.probability, Err: *b.err},
+		}
+	}
+	return f, nil
+}
+
+// Inject injects the fault and returns its definition, including the id
+func (b *NbdFaultBuilder) Inject(ctx context.Context) (*pb.NbdFault, error) {
+	f, err := b.Fault()
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := b.client.rpc.InjectNbdFault(ctx, &pb.InjectNbdFaultRequest{Fault: f, Target: b.client.target})
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetFault(), nil
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+// scopedTimeout bounds the RPCs of the scoped helpers, the context of the test is already canceled when the cleanups
+// run
+const scopedTimeout = 10 * time.Second
+
+// TB is the part of testing.TB used by the scoped helpers
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+}
+
+// NewScoped connects like New and closes the client when the test ends, the test fails if the options are invalid
+func NewScoped(t TB, address string, opts ...Option) *Client {
+	t.Helper()
+	c, err := New(address, opts...)
+	if err != nil {
+		t.Fatalf("connect to %s: %v", address, err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+// InjectScoped injects the fault for the rest of the test, it's removed by t.Cleanup. The test fails if the fault
+// can't be injected or removed, a fault removed by the test itself is ignored
+func (b *FuseFaultBuilder) InjectScoped(t TB) *pb.FuseFault {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), scopedTimeout)
+	defer cancel()
+	f, err := b.Inject(ctx)
+	if err != nil {
+		t.Fatalf("inject FUSE fault: %v", err)
+	}
+	b.client.removeOnCleanup(t, f.Id)
+	return f
+}
+
+// InjectScoped is FuseFaultBuilder.InjectScoped of the NBD faults
+func (b *NbdFaultBuilder) InjectScoped(t TB) *pb.NbdFault {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), scopedTimeout)
+	defer cancel()
+	f, err := b.Inject(ctx)
+	if err != nil {
+		t.Fatalf("inject NBD fault: %v", err)
+	}
+	b.client.removeOnCleanup(t, f.Id)
+	return f
+}
+
+// removeOnCleanup removes the fault when the test ends, before the client is closed by the cleanups registered earlier
+func (c *Client) removeOnCleanup(t TB, id int32) {
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), scopedTimeout)
+		defer cancel()
+		if err := c.RemoveFaults(ctx, id); err != nil && status.Code(err) != codes.NotFound {
+			t.Errorf("remove fault %d: %v", id, err)
+		}
+	})
+}
@@ -0,0 +1,381 @@
+//go:build linux || windows
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/rodaine/table"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"github.com/zperf/fusestream/pb"
+	"github.com/zperf/fusestream/v1"
+)
+
+var daemonCommand = &cli.Command{
+	Name:  "daemon",
+	Usage: "Serve several FUSE mounts and NBD exports from one process",
+	Commands: []*cli.Command{
+		daemonRunCommand,
+		daemonMountCommand,
+		daemonServeNbdCommand,
+		daemonUnmountCommand,
+		daemonListCommand,
+		daemonStatusCommand,
+	},
+}
+
+var flagTargetName = &cli.StringFlag{
+	Name:     "name",
+	Usage:    "The target name, fault commands select the target by --target",
+	Required: true,
+}
+
+var daemonRunCommand = &cli.Command{
+	Name:  "run",
+	Usage: "Run the daemon, targets are mounted by the other daemon commands",
+	Flags: append([]cli.Flag{
+		flagVerbose,
+		&cli.StringFlag{
+			Name:    "listen",
+			Aliases: []string{"l"},
+			Usage:   "The RPC server listen address, host:port or unix:///path/to.sock",
+			Value:   "127.0.0.1:4321",
+		},
+	}, slices.Concat(rpcServerFlags, tracingFlags)...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		shutdownTracing, err := setupTracing(ctx, command, command.String("listen"))
+		if err != nil {
+			return err
+		}
+		defer shutdownTracing()
+
+		if command.Bool("verbose") {
+			fusestream.InitLogging(zerolog.TraceLevel)
+		}
+		syscallUmask()
+
+		// FUSE mounts take the signals over from cgofuse and send them here, see fusestream.NewFuseHost
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		daemon := fusestream.NewDaemon()
+		daemon.MountFuse = func(t *fusestream.Target, spec *pb.FuseTarget) (func() error, error) {
+			return fusestream.MountFuseTarget(t, spec, signals)
+		}
+		daemon.ServeNbd = fusestream.ServeNbdTarget
+
+		server, err := newRPCServer(command, command.String("listen"))
+		if err != nil {
+			return err
+		}
+		info := fusestream.NewServerInfo(pb.ServerMode_SERVER_MODE_DAEMON)
+		info.Exporter = exporterInfo(command)
+		pb.RegisterFuseStreamServer(server, &fusestream.Rpc{Targets: daemon, Info: info})
+		pb.RegisterDaemonServer(server, daemon)
+		info.RegisterHealth(server)
+
+		listener, err := listenRPC(command, command.String("listen"))
+		if err != nil {
+			return err
+		}
+
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- server.Serve(listener)
+		}()
+		info.SetLive(true)
+
+		select {
+		case sig := <-signals:
+			log.Info().Stringer("signal", sig).Msg("Stopping daemon")
+		case err = <-serveErr:
+		}
+		info.SetLive(false)
+
+		// stopping the server removes the unix socket, targets are drained and unmounted after no RPC is running
+		server.Stop()
+		return errors.Join(err, daemon.Close())
+	},
+}
+
+// mountTarget asks the daemon to mount the target and prints its status
+func mountTarget(ctx context.Context, command *cli.Command, req *pb.MountRequest) error {
+	format, err := outputFormat(command)
+	if err != nil {
+		return err
+	}
+
+	conn, err := dialRPC(command)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	rsp, err := pb.NewDaemonClient(conn).Mount(ctx, req)
+	if err != nil {
+		return err
+	}
+	rows := NewTargetRows([]*pb.TargetStatus{rsp}, time.Now())
+	if format != formatTable {
+		return printOutput(os.Stdout, format, rows[0])
+	}
+	printTargets(os.Stdout, rows)
+	return nil
+}
+
+var daemonMountCommand = &cli.Command{
+	Name:  "mount",
+	Usage: "Mount a base dir on the daemon",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		flagTargetName,
+		flagOutputFormat,
+		&cli.StringFlag{
+			Name:     "base-dir",
+			Aliases:  []string{"b"},
+			Usage:    "Data base directory",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "mountpoint",
+			Aliases:  []string{"m"},
+			Usage:    "Mount point",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "use-ino",
+			Usage: "Use own inode values [FUSE3 only]",
+			Value: runtime.GOOS != "windows",
+		},
+		&cli.StringSliceFlag{
+			Name:  "mount-options",
+			Usage: "FUSE mount options",
+		},
+		&cli.DurationFlag{
+			Name:  "drain-timeout",
+			Usage: "On unmount, wait up to the duration for in-flight operations",
+			Value: fusestream.DefaultDrainTimeout,
+		},
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		return mountTarget(ctx, command, &pb.MountRequest{
+			Name: command.String("name"),
+			Spec: &pb.MountRequest_Fuse{
+				Fuse: &pb.FuseTarget{
+					BaseDir:        command.String("base-dir"),
+					Mountpoint:     command.String("mountpoint"),
+					MountOptions:   command.StringSlice("mount-options"),
+					UseIno:         command.Bool("use-ino"),
+					DrainTimeoutMs: command.Duration("drain-timeout").Milliseconds(),
+				},
+			},
+		})
+	},
+}
+
+var daemonServeNbdCommand = &cli.Command{
+	Name:  "serve-nbd",
+	Usage: "Export a backend file over NBD on the daemon",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		flagTargetName,
+		flagOutputFormat,
+		&cli.StringFlag{
+			Name:     "backend-file",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "export",
+			Required: true,
+		},
+		flagNetwork,
+		&cli.StringFlag{
+			Name:     "listen",
+			Aliases:  []string{"l"},
+			Usage:    "The NBD server listen address",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:    "read-only",
+			Aliases: []string{"ro"},
+		},
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		return mountTarget(ctx, command, &pb.MountRequest{
+			Name: command.String("name"),
+			Spec: &pb.MountRequest_Nbd{
+				Nbd: &pb.NbdTarget{
+					BackendFile: command.String("backend-file"),
+					Export:      command.String("export"),
+					Network:     command.String("network"),
+					Listen:      command.String("listen"),
+					ReadOnly:    command.Bool("read-only"),
+				},
+			},
+		})
+	},
+}
+
+var daemonUnmountCommand = &cli.Command{
+	Name:  "unmount",
+	Usage: "Unmount a target of the daemon, its faults are removed",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		flagTargetName,
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		conn, err := dialRPC(command)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close() }()
+
+		_, err = pb.NewDaemonClient(conn).Unmount(ctx, &pb.UnmountRequest{Name: command.String("name")})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Target unmounted: %s\n", command.String("name"))
+		return nil
+	},
+}
+
+var daemonListCommand = &cli.Command{
+	Name:  "list",
+	Usage: "List the targets of the daemon",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		flagOutputFormat,
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		format, err := outputFormat(command)
+		if err != nil {
+			return err
+		}
+
+		conn, err := dialRPC(command)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close() }()
+
+		rsp, err := pb.NewDaemonClient(conn).ListTargets(ctx, &pb.Void{})
+		if err != nil {
+			return err
+		}
+
+		rows := NewTargetRows(rsp.Targets, time.Now())
+		if format != formatTable {
+			return printOutput(os.Stdout, format, rows)
+		}
+		printTargets(os.Stdout, rows)
+		return nil
+	},
+}
+
+var daemonStatusCommand = &cli.Command{
+	Name:  "status",
+	Usage: "Show the status of a target of the daemon",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		flagTarget,
+		flagOutputFormat,
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		format, err := outputFormat(command)
+		if err != nil {
+			return err
+		}
+
+		conn, err := dialRPC(command)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close() }()
+
+		rsp, err := pb.NewDaemonClient(conn).GetTargetStatus(ctx, &pb.TargetRequest{Target: command.String("target")})
+		if err != nil {
+			return err
+		}
+
+		rows := NewTargetRows([]*pb.TargetStatus{rsp}, time.Now())
+		if format != formatTable {
+			return printOutput(os.Stdout, format, rows[0])
+		}
+		printTargets(os.Stdout, rows)
+		return nil
+	},
+}
+
+// TargetRow is a target of the daemon, Source is the base dir or the backend file, Mount is the mountpoint or
+// the export and its listen address
+type TargetRow struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Source   string `json:"source"`
+	Mount    string `json:"mount"`
+	UptimeNs int64  `json:"uptime_ns"`
+	Faults   int32  `json:"faults"`
+	Paused   bool   `json:"paused"`
+	Ops      int64  `json:"ops"`
+	Inflight int32  `json:"inflight"`
+}
+
+// NewTargetRows converts the target statuses, uptimes are as of now
+func NewTargetRows(targets []*pb.TargetStatus, now time.Time) []TargetRow {
+	rows := make([]TargetRow, 0, len(targets))
+	for _, t := range targets {
+		row := TargetRow{
+			Name:     t.Name,
+			Faults:   t.Faults,
+			Paused:   t.Paused,
+			Ops:      t.Ops,
+			Inflight: t.Inflight,
+		}
+		if t.StartTimeNs != 0 {
+			row.UptimeNs = now.UnixNano() - t.StartTimeNs
+		}
+
+		switch spec := t.Spec.(type) {
+		case *pb.TargetStatus_Fuse:
+			row.Type = "fuse"
+			row.Source = spec.Fuse.BaseDir
+			row.Mount = spec.Fuse.Mountpoint
+		case *pb.TargetStatus_Nbd:
+			row.Type = "nbd"
+			row.Source = spec.Nbd.BackendFile
+			row.Mount = fmt.Sprintf("%s@%s", spec.Nbd.Export, spec.Nbd.Listen)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func printTargets(w io.Writer, rows []TargetRow) {
+	tbl := table.New("Name", "Type", "Source", "Mount", "Uptime", "Faults", "Ops", "Inflight").WithWriter(w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	for _, r := range rows {
+		uptime := "mounting"
+		if r.UptimeNs != 0 {
+			uptime = time.Duration(r.UptimeNs).Round(time.Second).String()
+		}
+		faults := fmt.Sprint(r.Faults)
+		if r.Paused {
+			faults += " (paused)"
+		}
+		tbl.AddRow(r.Name, r.Type, r.Source, r.Mount, uptime, faults, r.Ops, r.Inflight)
+	}
+	tbl.Print()
+}
@@ -4,14 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/rodaine/table"
 	"github.com/urfave/cli/v3"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/zperf/fusestream/pb"
 )
@@ -22,25 +21,86 @@ var faultCommand = &cli.Command{
 	Commands: []*cli.Command{
 		listFaultCommand,
 		removeFaultCommand,
+		pauseFaultCommand,
+		resumeFaultCommand,
 	},
 }
 
-func removeFaults(ctx context.Context, address string, request *pb.DeleteFaultRequest) error {
-	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func setFaultsEnabled(ctx context.Context, command *cli.Command, ids []int32, enabled bool) error {
+	c, err := dialClient(command)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = conn.Close() }()
-	client := pb.NewFuseStreamClient(conn)
-	_, err = client.DeleteFault(ctx, request)
-	return err
+	defer func() { _ = c.Close() }()
+
+	if enabled {
+		return c.ResumeFaults(ctx, ids...)
+	}
+	return c.PauseFaults(ctx, ids...)
+}
+
+var pauseFaultCommand = &cli.Command{
+	Name:  "pause",
+	Usage: "Pause faults without removing them, pause all faults if no ids specified",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		flagTarget,
+		&cli.Int32SliceFlag{
+			Name: "ids",
+		},
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		return setFaultsEnabled(ctx, command, command.Int32Slice("ids"), false)
+	},
+}
+
+var resumeFaultCommand = &cli.Command{
+	Name:  "resume",
+	Usage: "Resume paused faults, resume all faults if no ids specified",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		flagTarget,
+		&cli.Int32SliceFlag{
+			Name: "ids",
+		},
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		return setFaultsEnabled(ctx, command, command.Int32Slice("ids"), true)
+	},
+}
+
+func removeFaults(ctx context.Context, command *cli.Command, request *pb.DeleteFaultRequest) error {
+	format, err := outputFormat(command)
+	if err != nil {
+		return err
+	}
+
+	c, err := dialClient(command)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	deleted, err := c.DeleteFaults(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	if format != formatTable {
+		// deleted_ids is an empty list rather than null if nothing matched
+		return printOutput(os.Stdout, format, FaultRemovedView{DeletedIDs: append([]int32{}, deleted...)})
+	}
+	fmt.Printf("Fault removed, ids: %v\n", deleted)
+	return nil
 }
 
 var removeFaultCommand = &cli.Command{
 	Name:  "remove",
 	Usage: "Remove faults",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		flagAddress,
+		flagTarget,
+		flagOutputFormat,
 		&cli.StringFlag{
 			Name:    "path-regex",
 			Aliases: []string{"g"},
@@ -48,104 +108,319 @@ var removeFaultCommand = &cli.Command{
 		&cli.Int32SliceFlag{
 			Name: "ids",
 		},
+		&cli.GenericFlag{
+			Name:  "fuse-op",
+			Usage: "Remove all FUSE faults of the operation type",
+			Value: NewFuseOpCliEnum(),
+		},
+		&cli.GenericFlag{
+			Name:  "nbd-op",
+			Usage: "Remove all NBD faults of the operation type",
+			Value: NewNbdOpCliEnum(),
+		},
 		&cli.BoolFlag{
 			Name: "all",
 		},
-	},
+	}, rpcClientFlags...),
 	Action: func(ctx context.Context, command *cli.Command) error {
 		ids := command.Int32Slice("ids")
 		pathRegex := command.String("path-regex")
+		fuseOp := command.Value("fuse-op").(pb.FuseOp)
+		nbdOp := command.Value("nbd-op").(pb.NbdOp)
 		all := command.Bool("all")
 
-		if len(ids) == 0 && pathRegex == "" && !all {
-			return errors.New("must specify at least one fault to remove")
-		}
-
 		var req *pb.DeleteFaultRequest
 		if all {
 			req = &pb.DeleteFaultRequest{All: true}
 		} else if len(ids) > 0 {
 			req = &pb.DeleteFaultRequest{Id: ids}
-		} else {
+		} else if pathRegex != "" {
 			req = &pb.DeleteFaultRequest{PathRe: pathRegex}
+		} else if fuseOp != pb.FuseOp_FUSE_UNKNOWN {
+			req = &pb.DeleteFaultRequest{FuseOp: fuseOp}
+		} else if nbdOp != pb.NbdOp_NBD_UNKNOWN {
+			req = &pb.DeleteFaultRequest{NbdOp: nbdOp}
+		} else {
+			return errors.New("must specify at least one fault to remove")
 		}
 
-		return removeFaults(ctx, command.String("address"), req)
+		return removeFaults(ctx, command, req)
 	},
 }
 
 var listFaultCommand = &cli.Command{
 	Name:  "list",
 	Usage: "List faults",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		flagAddress,
-	},
+		flagTarget,
+		flagOutputFormat,
+	}, rpcClientFlags...),
 	Action: func(ctx context.Context, command *cli.Command) error {
-		address := command.String("address")
-		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		format, err := outputFormat(command)
+		if err != nil {
+			return err
+		}
+
+		c, err := dialClient(command)
 		if err != nil {
 			return err
 		}
-		defer func() { _ = conn.Close() }()
+		defer func() { _ = c.Close() }()
 
-		client := pb.NewFuseStreamClient(conn)
-		rsp, err := client.ListFaults(ctx, &pb.Void{})
+		rsp, err := c.ListFaults(ctx)
 		if err != nil {
 			return err
 		}
 
-		tbl := table.New("ID", "Type", "Path", "Op", "Fault")
+		if format != formatTable {
+			return printOutput(os.Stdout, format, NewFaultListView(rsp))
+		}
+
+		if rsp.GetPaused() {
+			fmt.Println("All faults are paused")
+		}
+
+		tbl := table.New("ID", "Type", "Path", "Op", "Enabled", "Hits", "Fault")
 		tbl.WithHeaderFormatter(color.New(color.FgGreen, color.Underline).SprintfFunc()).
 			WithFirstColumnFormatter(color.New(color.FgYellow).SprintfFunc())
 
 		for _, f := range rsp.FuseFaults {
-			faults := make([]string, 0)
+			tbl.AddRow(f.Id, "fs", f.PathRe, f.Op.String(), f.GetEnabled(), f.GetHits(), describeFuseFault(f))
+		}
 
-			switch m := f.Delay.(type) {
-			case *pb.FuseFault_DelayFault:
-				faults = append(faults, fmt.Sprintf("delay{p=%.2f,v=%v}",
-					m.DelayFault.Possibility,
-					time.Duration(m.DelayFault.DelayMs)*time.Millisecond))
-			}
+		for _, f := range rsp.NbdFaults {
+			tbl.AddRow(f.Id, "nbd", "/", f.Op.String(), f.GetEnabled(), f.GetHits(), describeNbdFault(f))
+		}
+
+		tbl.Print()
+		return nil
+	},
+}
+
+// describeFuseFault formats the actions and conditions of the fault, e.g. delay{p=1.00,v=1s}/proc{pid=1}
+func describeFuseFault(f *pb.FuseFault) string {
+	faults := make([]string, 0)
+
+	switch m := f.Delay.(type) {
+	case *pb.FuseFault_DelayFault:
+		faults = append(faults, fmt.Sprintf("delay{p=%.2f,v=%v}",
+			m.DelayFault.Possibility,
+			time.Duration(m.DelayFault.DelayMs)*time.Millisecond))
+	}
+
+	switch m := f.ReturnValue.(type) {
+	case *pb.FuseFault_ReturnValueFault:
+		faults = append(faults, fmt.Sprintf("rc{p=%.2f,v=%v}",
+			m.ReturnValueFault.Possibility,
+			m.ReturnValueFault.ReturnValue))
+	}
 
-			switch m := f.ReturnValue.(type) {
-			case *pb.FuseFault_ReturnValueFault:
-				faults = append(faults, fmt.Sprintf("rc{p=%.2f,v=%v}",
-					m.ReturnValueFault.Possibility,
-					m.ReturnValueFault.ReturnValue))
+	if p := f.Process; p != nil {
+		conds := make([]string, 0)
+		if p.Pid != nil {
+			conds = append(conds, fmt.Sprintf("pid=%d", p.GetPid()))
+			if p.IncludeChildren {
+				conds = append(conds, "children")
 			}
+		}
+		if p.Uid != nil {
+			conds = append(conds, fmt.Sprintf("uid=%d", p.GetUid()))
+		}
+		if p.Gid != nil {
+			conds = append(conds, fmt.Sprintf("gid=%d", p.GetGid()))
+		}
+		if p.CommRe != "" {
+			conds = append(conds, fmt.Sprintf("comm=%q", p.CommRe))
+		}
+		faults = append(faults, fmt.Sprintf("proc{%s}", strings.Join(conds, ",")))
+	}
 
-			tbl.AddRow(f.Id, "fs", f.PathRe, f.Op.String(), strings.Join(faults, "/"))
+	if len(f.OpenFlags) > 0 || len(f.Fhs) > 0 {
+		conds := make([]string, 0)
+		for _, flag := range f.OpenFlags {
+			conds = append(conds, openFlagName(flag))
 		}
+		for _, fh := range f.Fhs {
+			conds = append(conds, fmt.Sprintf("fh=%d", fh))
+		}
+		faults = append(faults, fmt.Sprintf("file{%s}", strings.Join(conds, ",")))
+	}
 
-		for _, f := range rsp.NbdFaults {
-			faults := make([]string, 0)
+	return strings.Join(faults, "/")
+}
 
-			switch m := f.Delay.(type) {
-			case *pb.NbdFault_DelayFault:
-				faults = append(faults, fmt.Sprintf("delay{p=%.2f,v=%v}",
-					m.DelayFault.Possibility,
-					time.Duration(m.DelayFault.DelayMs)*time.Millisecond))
-			}
+// describeNbdFault formats the actions and conditions of the fault, e.g. err{p=1.00,v=EIO}/cond{prio=0,MATCH_FIRST}
+func describeNbdFault(f *pb.NbdFault) string {
+	faults := make([]string, 0)
 
-			switch m := f.ReturnValue.(type) {
-			case *pb.NbdFault_ReturnValueFault:
-				faults = append(faults, fmt.Sprintf("rc{p=%.2f,v=%v}",
-					m.ReturnValueFault.Possibility,
-					m.ReturnValueFault.ReturnValue))
-			}
+	switch m := f.Delay.(type) {
+	case *pb.NbdFault_DelayFault:
+		faults = append(faults, fmt.Sprintf("delay{p=%.2f,v=%v}",
+			m.DelayFault.Possibility,
+			time.Duration(m.DelayFault.DelayMs)*time.Millisecond))
+	}
 
-			switch m := f.Err.(type) {
-			case *pb.NbdFault_ErrorFault:
-				faults = append(faults, fmt.Sprintf("err{p=%.2f,v=%v}",
-					m.ErrorFault.Possibility,
-					m.ErrorFault.Err))
-			}
+	switch m := f.ReturnValue.(type) {
+	case *pb.NbdFault_ReturnValueFault:
+		faults = append(faults, fmt.Sprintf("rc{p=%.2f,v=%v}",
+			m.ReturnValueFault.Possibility,
+			m.ReturnValueFault.ReturnValue))
+	}
 
-			tbl.AddRow(f.Id, "nbd", "/", f.Op.String(), strings.Join(faults, "/"))
-		}
+	switch m := f.Err.(type) {
+	case *pb.NbdFault_ErrorFault:
+		faults = append(faults, fmt.Sprintf("err{p=%.2f,v=%v}",
+			m.ErrorFault.Possibility,
+			m.ErrorFault.Err))
+	}
 
-		tbl.Print()
+	conds := []string{fmt.Sprintf("prio=%d", f.Priority), strings.TrimPrefix(f.MatchPolicy.String(), "NBD_")}
+	if f.OffsetStart != 0 || f.OffsetEnd != 0 {
+		conds = append(conds, fmt.Sprintf("range=[%d,%d)", f.OffsetStart, f.OffsetEnd))
+	}
+	if exp := f.GetExpression(); exp != "" {
+		conds = append(conds, fmt.Sprintf("exp=%q", exp))
+	}
+	faults = append(faults, fmt.Sprintf("cond{%s}", strings.Join(conds, ",")))
+
+	return strings.Join(faults, "/")
+}
+
+// printInjectedFault prints the id of the injected fault, or its full definition in the json and yaml formats
+func printInjectedFault(format string, view *FaultView) error {
+	if format == formatTable {
+		fmt.Printf("Fault injected, id: %d\n", view.ID)
 		return nil
-	},
+	}
+	return printOutput(os.Stdout, format, view)
+}
+
+func openFlagName(flag pb.OpenFlag) string {
+	return "O_" + strings.TrimPrefix(flag.String(), "OPEN_FLAG_")
+}
+
+// FaultListView is the output of fault list in the json and yaml formats
+type FaultListView struct {
+	Paused bool         `json:"paused"`
+	Faults []*FaultView `json:"faults"`
+}
+
+func NewFaultListView(rsp *pb.ListFaultsResponse) *FaultListView {
+	v := &FaultListView{
+		Paused: rsp.GetPaused(),
+		Faults: make([]*FaultView, 0, len(rsp.FuseFaults)+len(rsp.NbdFaults)),
+	}
+	for _, f := range rsp.FuseFaults {
+		v.Faults = append(v.Faults, NewFuseFaultView(f))
+	}
+	for _, f := range rsp.NbdFaults {
+		v.Faults = append(v.Faults, NewNbdFaultView(f))
+	}
+	return v
+}
+
+type FaultRemovedView struct {
+	DeletedIDs []int32 `json:"deleted_ids"`
+}
+
+// FaultView is the full definition of a FUSE or NBD fault, Type is fuse or nbd. The conditions of the other type are
+// omitted
+type FaultView struct {
+	ID          int32              `json:"id"`
+	Type        string             `json:"type"`
+	Op          string             `json:"op"`
+	Enabled     bool               `json:"enabled"`
+	Hits        int64              `json:"hits"`
+	PathRe      string             `json:"path_regex,omitempty"`
+	Process     *ProcessFilterView `json:"process,omitempty"`
+	OpenFlags   []string           `json:"open_flags,omitempty"`
+	Fhs         []uint64           `json:"fhs,omitempty"`
+	Priority    int32              `json:"priority,omitempty"`
+	OffsetStart int64              `json:"offset_start,omitempty"`
+	OffsetEnd   int64              `json:"offset_end,omitempty"`
+	MatchPolicy string             `json:"match_policy,omitempty"`
+	Expression  string             `json:"expression,omitempty"`
+	Delay       *DelayView         `json:"delay,omitempty"`
+	ReturnValue *ReturnValueView   `json:"return_value,omitempty"`
+	Error       *ErrorView         `json:"error,omitempty"`
+}
+
+type ProcessFilterView struct {
+	Pid             *int32  `json:"pid,omitempty"`
+	IncludeChildren bool    `json:"include_children,omitempty"`
+	Uid             *uint32 `json:"uid,omitempty"`
+	Gid             *uint32 `json:"gid,omitempty"`
+	CommRe          string  `json:"comm_regex,omitempty"`
+}
+
+type DelayView struct {
+	Possibility float32 `json:"possibility"`
+	DelayMs     int64   `json:"delay_ms"`
+}
+
+type ReturnValueView struct {
+	Possibility float32 `json:"possibility"`
+	ReturnValue int64   `json:"return_value"`
+}
+
+type ErrorView struct {
+	Possibility float32 `json:"possibility"`
+	Error       string  `json:"error"`
+}
+
+func NewFuseFaultView(f *pb.FuseFault) *FaultView {
+	v := &FaultView{
+		ID:      f.GetId(),
+		Type:    "fuse",
+		Op:      f.GetOp().String(),
+		Enabled: f.GetEnabled(),
+		Hits:    f.GetHits(),
+		PathRe:  f.GetPathRe(),
+		Fhs:     f.GetFhs(),
+	}
+	for _, flag := range f.GetOpenFlags() {
+		v.OpenFlags = append(v.OpenFlags, openFlagName(flag))
+	}
+	if p := f.GetProcess(); p != nil {
+		v.Process = &ProcessFilterView{
+			Pid:             p.Pid,
+			IncludeChildren: p.IncludeChildren,
+			Uid:             p.Uid,
+			Gid:             p.Gid,
+			CommRe:          p.CommRe,
+		}
+	}
+	if d := f.GetDelayFault(); d != nil {
+		v.Delay = &DelayView{Possibility: d.Possibility, DelayMs: d.DelayMs}
+	}
+	if rv := f.GetReturnValueFault(); rv != nil {
+		v.ReturnValue = &ReturnValueView{Possibility: rv.Possibility, ReturnValue: rv.ReturnValue}
+	}
+	return v
+}
+
+func NewNbdFaultView(f *pb.NbdFault) *FaultView {
+	v := &FaultView{
+		ID:          f.GetId(),
+		Type:        "nbd",
+		Op:          f.GetOp().String(),
+		Enabled:     f.GetEnabled(),
+		Hits:        f.GetHits(),
+		Priority:    f.GetPriority(),
+		OffsetStart: f.GetOffsetStart(),
+		OffsetEnd:   f.GetOffsetEnd(),
+		MatchPolicy: f.GetMatchPolicy().String(),
+		Expression:  f.GetExpression(),
+	}
+	if d := f.GetDelayFault(); d != nil {
+		v.Delay = &DelayView{Possibility: d.Possibility, DelayMs: d.DelayMs}
+	}
+	if rv := f.GetReturnValueFault(); rv != nil {
+		v.ReturnValue = &ReturnValueView{Possibility: rv.Possibility, ReturnValue: rv.ReturnValue}
+	}
+	if e := f.GetErrorFault(); e != nil {
+		v.Error = &ErrorView{Possibility: e.Possibility, Error: e.Err}
+	}
+	return v
 }
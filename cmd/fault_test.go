@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+func TestFaultListView(t *testing.T) {
+	enabled := true
+	pid := int32(42)
+	view := NewFaultListView(&pb.ListFaultsResponse{
+		Paused: true,
+		FuseFaults: []*pb.FuseFault{{
+			Id:        1,
+			PathRe:    "wal/.*",
+			Op:        pb.FuseOp_FUSE_FSYNC,
+			Enabled:   &enabled,
+			Process:   &pb.ProcessFilter{Pid: &pid, CommRe: "^postgres$"},
+			OpenFlags: []pb.OpenFlag{pb.OpenFlag_OPEN_FLAG_SYNC},
+			Delay:     &pb.FuseFault_DelayFault{DelayFault: &pb.DelayFault{Possibility: 1, DelayMs: 200}},
+			Hits:      3,
+		}},
+		NbdFaults: []*pb.NbdFault{{
+			Id:        2,
+			Op:        pb.NbdOp_NBD_WRITEAT,
+			Enabled:   &enabled,
+			OffsetEnd: 4096,
+			PreCond:   &pb.NbdFault_Expression{Expression: "offset > 0"},
+			Err:       &pb.NbdFault_ErrorFault{ErrorFault: &pb.ErrorFault{Possibility: 0.5, Err: "EIO"}},
+		}},
+	})
+	require.True(t, view.Paused)
+	require.Len(t, view.Faults, 2)
+
+	fuse := view.Faults[0]
+	require.Equal(t, "fuse", fuse.Type)
+	require.Equal(t, "FUSE_FSYNC", fuse.Op)
+	require.Equal(t, []string{"O_SYNC"}, fuse.OpenFlags)
+	require.Equal(t, &pid, fuse.Process.Pid)
+	require.Equal(t, &DelayView{Possibility: 1, DelayMs: 200}, fuse.Delay)
+	require.Empty(t, fuse.MatchPolicy)
+
+	nbd := view.Faults[1]
+	require.Equal(t, "nbd", nbd.Type)
+	require.Equal(t, "offset > 0", nbd.Expression)
+	require.Equal(t, &ErrorView{Possibility: 0.5, Error: "EIO"}, nbd.Error)
+	require.Nil(t, nbd.Delay)
+
+	// json and yaml print the same document
+	var jsonBuf, yamlBuf bytes.Buffer
+	require.NoError(t, printOutput(&jsonBuf, formatJSON, view))
+	require.NoError(t, printOutput(&yamlBuf, formatYAML, view))
+	require.Contains(t, yamlBuf.String(), "path_regex: wal/.*\n")
+	require.NotContains(t, yamlBuf.String(), "{")
+
+	var fromJSON, fromYAML any
+	require.NoError(t, json.Unmarshal(jsonBuf.Bytes(), &fromJSON))
+	require.NoError(t, yaml.Unmarshal(yamlBuf.Bytes(), &fromYAML))
+	require.Equal(t, fromJSON, normalizeYAML(fromYAML))
+
+	require.Error(t, printOutput(&jsonBuf, formatCSV, view))
+}
+
+// normalizeYAML converts the yaml numbers to the float64 of encoding/json
+func normalizeYAML(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = normalizeYAML(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = normalizeYAML(e)
+		}
+	case int:
+		return float64(v)
+	}
+	return v
+}
@@ -7,10 +7,16 @@ import (
 var flagAddress = &cli.StringFlag{
 	Name:    "address",
 	Aliases: []string{"a"},
-	Usage:   "The server address connect to",
+	Usage:   "The server address connect to, host:port or unix:///path/to.sock",
 	Value:   "127.0.0.1:4321",
 }
 
+var flagTarget = &cli.StringFlag{
+	Name:    "target",
+	Aliases: []string{"t"},
+	Usage:   "The target of fusestream daemon, may be omitted if the daemon serves only one",
+}
+
 var flagNetwork = &cli.StringFlag{
 	Name:  "network",
 	Value: "tcp",
@@ -56,6 +62,26 @@ var flagPreCond = &cli.StringFlag{
 	Aliases: []string{"pred"},
 }
 
+var flagPriority = &cli.Int32Flag{
+	Name:  "priority",
+	Usage: "Faults of the same op are evaluated from the highest priority",
+}
+
+var flagOffsetStart = &cli.Int64Flag{
+	Name:  "offset-start",
+	Usage: "Only I/Os overlapping [offset-start, offset-end) are affected",
+}
+
+var flagOffsetEnd = &cli.Int64Flag{
+	Name:  "offset-end",
+	Usage: "Only I/Os overlapping [offset-start, offset-end) are affected, 0 means unbounded",
+}
+
+var flagMatchAll = &cli.BoolFlag{
+	Name:  "match-all",
+	Usage: "Keep evaluating lower priority faults after this fault matched",
+}
+
 var flagDelay = &cli.DurationFlag{
 	Name:     "delay",
 	Aliases:  []string{"d", "lat"},
@@ -67,3 +93,120 @@ var flagReturnValue = &cli.Int64Flag{
 	Aliases:  []string{"rc", "ec"},
 	Required: true,
 }
+
+var flagPid = &cli.Int32Flag{
+	Name:  "pid",
+	Usage: "Only affect the calling process with the pid",
+}
+
+var flagIncludeChildren = &cli.BoolFlag{
+	Name:  "include-children",
+	Usage: "Also affect the descendants of --pid",
+}
+
+var flagUid = &cli.Uint32Flag{
+	Name:  "uid",
+	Usage: "Only affect the calling processes with the uid",
+}
+
+var flagGid = &cli.Uint32Flag{
+	Name:  "gid",
+	Usage: "Only affect the calling processes with the gid",
+}
+
+var flagComm = &cli.StringFlag{
+	Name:  "comm",
+	Usage: "Only affect the calling processes whose /proc/<pid>/comm matches the regex",
+}
+
+var flagOpenFlags = &cli.StringSliceFlag{
+	Name:  "open-flags",
+	Usage: "Only affect files opened with all of the flags, e.g. O_DIRECT,O_SYNC",
+}
+
+var flagFh = &cli.Uint64SliceFlag{
+	Name:  "fh",
+	Usage: "Only affect the file handles",
+}
+
+var flagExportPath = &cli.StringFlag{
+	Name:    "export-path",
+	Usage:   "Export spans to the parquet file",
+	Sources: cli.NewValueSourceChain(cli.EnvVar("FUSESTREAM_EXPORT_PATH")),
+}
+
+var flagExportRotateSize = &cli.StringFlag{
+	Name:  "export-rotate-size",
+	Usage: "Rotate the parquet file to timestamped segments of the size, e.g. 256MiB",
+}
+
+var flagExportRotateInterval = &cli.DurationFlag{
+	Name:  "export-rotate-interval",
+	Usage: "Rotate the parquet file to timestamped segments every interval",
+}
+
+var flagOtlpEndpoint = &cli.StringFlag{
+	Name:    "otlp-endpoint",
+	Usage:   "Export spans to the OTLP collector, e.g. http://localhost:4317",
+	Sources: cli.NewValueSourceChain(cli.EnvVar("FUSESTREAM_OTLP_ENDPOINT")),
+}
+
+var flagOtlpProtocol = &cli.StringFlag{
+	Name:  "otlp-protocol",
+	Usage: "The OTLP protocol, grpc or http",
+	Value: "grpc",
+}
+
+var flagTraceSampleRatio = &cli.Float64Flag{
+	Name:  "trace-sample-ratio",
+	Usage: "The ratio of traces to sample",
+	Value: 1,
+}
+
+var flagServiceNamespace = &cli.StringFlag{
+	Name:  "service-namespace",
+	Usage: "The service.namespace resource attribute",
+	Value: "zbs",
+}
+
+var flagServiceName = &cli.StringFlag{
+	Name:  "service-name",
+	Usage: "The service.name resource attribute",
+	Value: "fusestream",
+}
+
+var flagResourceAttrs = &cli.StringSliceFlag{
+	Name:  "resource-attr",
+	Usage: "Extra resource attributes, in key=value format",
+}
+
+var tracingFlags = []cli.Flag{
+	flagExportPath,
+	flagExportRotateSize,
+	flagExportRotateInterval,
+	flagOtlpEndpoint,
+	flagOtlpProtocol,
+	flagTraceSampleRatio,
+	flagServiceNamespace,
+	flagServiceName,
+	flagResourceAttrs,
+}
+
+var flagMetricsListen = &cli.StringFlag{
+	Name:    "metrics-listen",
+	Usage:   "Serve Prometheus metrics on the address at /metrics, e.g. 127.0.0.1:9100",
+	Sources: cli.NewValueSourceChain(cli.EnvVar("FUSESTREAM_METRICS_LISTEN")),
+}
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+	formatCSV   = "csv"
+)
+
+var flagOutputFormat = &cli.StringFlag{
+	Name:  "output",
+	Usage: "Output format, table, json or yaml",
+	Value: formatTable,
+}
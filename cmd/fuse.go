@@ -4,17 +4,21 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net"
+	"os"
+	"os/signal"
 	"runtime"
+	"slices"
+	"strconv"
+	"syscall"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
 	"github.com/winfsp/cgofuse/fuse"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/zperf/fusestream/client"
 	"github.com/zperf/fusestream/pb"
 	"github.com/zperf/fusestream/v1"
 )
@@ -24,6 +28,7 @@ var fuseCommand = &cli.Command{
 	Usage: "FUSE commands",
 	Commands: []*cli.Command{
 		fuseMountCommand,
+		fuseUnmountCommand,
 		injectFuseDelayCommand,
 		injectFuseReturnValueCommand,
 	},
@@ -32,12 +37,12 @@ var fuseCommand = &cli.Command{
 var fuseMountCommand = &cli.Command{
 	Name:  "mount",
 	Usage: "Mount the filesystem",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		flagVerbose,
 		&cli.StringFlag{
 			Name:    "listen",
 			Aliases: []string{"l"},
-			Usage:   "The RPC server listen address",
+			Usage:   "The RPC server listen address, host:port or unix:///path/to.sock",
 			Value:   "127.0.0.1:4321",
 		},
 		&cli.StringFlag{
@@ -66,27 +71,29 @@ var fuseMountCommand = &cli.Command{
 			Usage: "FUSE mount without faults",
 			Value: false,
 		},
+		&cli.DurationFlag{
+			Name:  "drain-timeout",
+			Usage: "On SIGINT, SIGTERM or the unmount command, wait up to the duration for in-flight operations",
+			Value: fusestream.DefaultDrainTimeout,
+		},
 		&cli.StringFlag{
-			Name:    "export-path",
-			Sources: cli.NewValueSourceChain(cli.EnvVar("FUSESTREAM_EXPORT_PATH")),
+			Name:  "ready-file",
+			Usage: "Write the pid to the file once the mount is live, it's removed on unmount",
 		},
-	},
+		flagMetricsListen,
+	}, slices.Concat(rpcServerFlags, tracingFlags)...),
 	Action: func(ctx context.Context, command *cli.Command) error {
-		exportPath := command.String("export-path")
-		if exportPath == "" {
-			log.Info().Msg("Export path not set, spans won't be exported")
-		} else {
-			exporter, err := fusestream.NewSpanExporter(exportPath)
-			if err != nil {
-				return fmt.Errorf("failed to create span exporter: %w", err)
-			}
-			defer func() {
-				if err := exporter.Shutdown(context.Background()); err != nil {
-					log.Error().Err(err).Msg("Shutdown exporter failed")
-				}
-			}()
-			fusestream.SetupOTelSDK(exporter)
+		shutdownTracing, err := setupTracing(ctx, command, command.String("mountpoint"))
+		if err != nil {
+			return err
 		}
+		defer shutdownTracing()
+
+		metrics, shutdownMetrics, err := setupMetrics(command)
+		if err != nil {
+			return err
+		}
+		defer shutdownMetrics()
 
 		verbose := command.Bool("verbose")
 		if verbose {
@@ -94,23 +101,68 @@ var fuseMountCommand = &cli.Command{
 		}
 		syscallUmask()
 
+		mountpoint := command.String("mountpoint")
+		stale, err := fusestream.CleanStaleMountpoint(mountpoint)
+		if err != nil {
+			return err
+		}
+		if stale {
+			log.Warn().Str("mountpoint", mountpoint).Msg("Unmounted the stale mountpoint of a crashed mount")
+		}
+
+		baseDir := command.String("base-dir")
+		info := fusestream.NewServerInfo(pb.ServerMode_SERVER_MODE_FUSE)
+		info.BaseDir = baseDir
+		info.Mountpoint = mountpoint
+		info.Exporter = exporterInfo(command)
+
 		faults := fusestream.NewFaultManager()
-		server := grpc.NewServer(grpc.Creds(insecure.NewCredentials()))
-		pb.RegisterFuseStreamServer(server, &fusestream.Rpc{Faults: faults})
+		rpc := &fusestream.Rpc{Faults: faults, Info: info}
+		server, err := newRPCServer(command, command.String("listen"))
+		if err != nil {
+			return err
+		}
+		pb.RegisterFuseStreamServer(server, rpc)
+		info.RegisterHealth(server)
 
 		var fs fuse.FileSystemInterface
-		baseDir := command.String("base-dir")
+		var inflight *fusestream.InflightTable
 		if command.Bool("without-faults") {
 			fs = fusestream.NewRawFS(baseDir)
 		} else {
-			fs = fusestream.NewSlowFS(baseDir, faults)
+			slowFS := fusestream.NewSlowFS(baseDir, faults)
+			if metrics != nil {
+				slowFS.Metrics = metrics
+				metrics.TrackOpenHandles(slowFS.OpenHandles)
+			}
+			rpc.Stats = slowFS.Stats
+			rpc.Inflight = slowFS.Inflight
+			inflight = slowFS.Inflight
+			fs = slowFS
+		}
+
+		// signals unmount after draining, cgofuse would unmount right away, see fusestream.NewFuseHost
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		host := fusestream.NewFuseHost(fs, inflight, signals)
+		host.SetUseIno(command.Bool("use-ino"))
+
+		drainTimeout := command.Duration("drain-timeout")
+		unmount := func() error {
+			info.SetLive(false)
+			_ = fusestream.SdNotify("STOPPING=1")
+			return host.Unmount(drainTimeout)
 		}
+		rpc.Unmounter = unmount
 
-		// start RPC server
-		listener, err := net.Listen("tcp", command.String("listen"))
+		// start RPC server, the health service reports NOT_SERVING until mounted. Stopping the server removes
+		// the unix socket, stopping it gracefully lets the unmount RPC return
+		listener, err := listenRPC(command, command.String("listen"))
 		if err != nil {
 			return err
 		}
+		defer stopRPCServer(server)
 		go func() {
 			err := server.Serve(listener)
 			if err != nil {
@@ -119,91 +171,189 @@ var fuseMountCommand = &cli.Command{
 		}()
 
 		// mount FUSE
-		host := fuse.NewFileSystemHost(fs)
-		host.SetUseIno(command.Bool("use-ino"))
-		host.Mount(command.String("mountpoint"), command.StringSlice("mount-options"))
+		if err := host.Mount(mountpoint, command.StringSlice("mount-options")); err != nil {
+			return err
+		}
+		info.SetLive(true)
+		if err := signalReady(command.String("ready-file")); err != nil {
+			return errors.Join(err, unmount())
+		}
+		defer removeReadyFile(command.String("ready-file"))
+		log.Info().Str("mountpoint", mountpoint).Msg("Mounted")
+
+		select {
+		case sig := <-signals:
+			log.Info().Stringer("signal", sig).Msg("Unmounting")
+			if err := unmount(); err != nil {
+				return err
+			}
+		case <-host.Done():
+			info.SetLive(false)
+			_ = fusestream.SdNotify("STOPPING=1")
+		}
+		log.Info().Str("mountpoint", mountpoint).Msg("Unmounted")
+		return nil
+	},
+}
+
+// signalReady writes the pid to the ready file if set, and notifies the service manager
+func signalReady(readyFile string) error {
+	if readyFile != "" {
+		err := os.WriteFile(readyFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	if err := fusestream.SdNotify("READY=1"); err != nil {
+		log.Warn().Err(err).Msg("Failed to notify the service manager")
+	}
+	return nil
+}
+
+func removeReadyFile(readyFile string) {
+	if readyFile == "" {
+		return
+	}
+	if err := os.Remove(readyFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn().Err(err).Str("path", readyFile).Msg("Failed to remove the ready file")
+	}
+}
 
+var fuseUnmountCommand = &cli.Command{
+	Name:  "unmount",
+	Usage: "Unmount the filesystem of a running fuse mount, after draining in-flight operations",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		flagTarget,
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		conn, err := dialRPC(command)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close() }()
+
+		_, err = pb.NewFuseStreamClient(conn).Unmount(ctx, &pb.TargetRequest{Target: command.String("target")})
+		if err != nil {
+			return err
+		}
+		fmt.Println("Unmounted")
 		return nil
 	},
 }
 
+// fuseFaultFromCommand sets the conditions and the probability shared by the FUSE inject commands
+func fuseFaultFromCommand(c *client.Client, command *cli.Command) (*client.FuseFaultBuilder, error) {
+	openFlags, err := parseOpenFlags(command.StringSlice("open-flags"))
+	if err != nil {
+		return nil, err
+	}
+
+	b := c.Fuse().
+		Path(command.String("path-regex")).
+		Op(command.Value("op").(pb.FuseOp)).
+		Probability(command.Float32("possibility")).
+		OpenFlags(openFlags...).
+		Fh(command.Uint64Slice("fh")...)
+	if command.IsSet("pid") {
+		b.Pid(command.Int32("pid"), command.Bool("include-children"))
+	}
+	if command.IsSet("uid") {
+		b.Uid(command.Uint32("uid"))
+	}
+	if command.IsSet("gid") {
+		b.Gid(command.Uint32("gid"))
+	}
+	if comm := command.String("comm"); comm != "" {
+		b.Comm(comm)
+	}
+	return b, nil
+}
+
 var injectFuseDelayCommand = &cli.Command{
 	Name:  "inject-latency",
 	Usage: "Inject delay to the filesystem",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		flagAddress,
+		flagTarget,
+		flagOutputFormat,
 		flagPathRegex,
 		flagPossibility,
 		flagFuseOp,
 		flagDelay,
-	},
+		flagPid,
+		flagIncludeChildren,
+		flagUid,
+		flagGid,
+		flagComm,
+		flagOpenFlags,
+		flagFh,
+	}, rpcClientFlags...),
 	Action: func(ctx context.Context, command *cli.Command) error {
-		address := command.String("address")
-		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		format, err := outputFormat(command)
 		if err != nil {
 			return err
 		}
-		defer func() { _ = conn.Close() }()
-
-		client := pb.NewFuseStreamClient(conn)
 
-		fault := &pb.FuseFault{
-			PathRe: command.String("path-regex"),
-			Op:     command.Value("op").(pb.FuseOp),
-			Delay: &pb.FuseFault_DelayFault{
-				DelayFault: &pb.DelayFault{
-					Possibility: command.Float32("possibility"),
-					DelayMs:     command.Duration("delay").Milliseconds(),
-				},
-			},
+		c, err := dialClient(command)
+		if err != nil {
+			return err
 		}
+		defer func() { _ = c.Close() }()
 
-		rsp, err := client.InjectFuseFault(ctx, &pb.InjectFuseFaultRequest{Fault: fault})
+		b, err := fuseFaultFromCommand(c, command)
+		if err != nil {
+			return err
+		}
+		fault, err := b.Delay(command.Duration("delay")).Inject(ctx)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Fault injected, id: %d\n", rsp.GetId())
-		return nil
+		return printInjectedFault(format, NewFuseFaultView(fault))
 	},
 }
 
 var injectFuseReturnValueCommand = &cli.Command{
 	Name:  "inject-return-value",
 	Usage: "Inject return-value fault to the filesystem",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		flagAddress,
+		flagTarget,
+		flagOutputFormat,
 		flagPathRegex,
 		flagPossibility,
 		flagFuseOp,
 		flagReturnValue,
-	},
+		flagPid,
+		flagIncludeChildren,
+		flagUid,
+		flagGid,
+		flagComm,
+		flagOpenFlags,
+		flagFh,
+	}, rpcClientFlags...),
 	Action: func(ctx context.Context, command *cli.Command) error {
-		address := command.String("address")
-		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		format, err := outputFormat(command)
 		if err != nil {
 			return err
 		}
-		defer func() { _ = conn.Close() }()
 
-		client := pb.NewFuseStreamClient(conn)
-		rsp, err := client.InjectFuseFault(ctx, &pb.InjectFuseFaultRequest{
-			Fault: &pb.FuseFault{
-				PathRe: command.String("path-regex"),
-				Op:     command.Value("op").(pb.FuseOp),
-				ReturnValue: &pb.FuseFault_ReturnValueFault{
-					ReturnValueFault: &pb.ReturnValueFault{
-						Possibility: command.Float32("possibility"),
-						ReturnValue: command.Int64("return-value"),
-					},
-				},
-			},
-		})
+		c, err := dialClient(command)
 		if err != nil {
 			return err
 		}
+		defer func() { _ = c.Close() }()
 
-		fmt.Printf("Fault injected, id: %d\n", rsp.GetId())
-		return nil
+		b, err := fuseFaultFromCommand(c, command)
+		if err != nil {
+			return err
+		}
+		fault, err := b.ReturnValue(command.Int64("return-value")).Inject(ctx)
+		if err != nil {
+			return err
+		}
+
+		return printInjectedFault(format, NewFuseFaultView(fault))
 	},
 }
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rodaine/table"
+	"github.com/urfave/cli/v3"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+var inflightCommand = &cli.Command{
+	Name:  "inflight",
+	Usage: "List the operations in-flight on a running server, the longest running first",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		flagTarget,
+		&cli.DurationFlag{
+			Name:  "min-elapsed",
+			Usage: "Only list the operations running for at least the duration",
+		},
+		flagOutputFormat,
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		format, err := outputFormat(command)
+		if err != nil {
+			return err
+		}
+
+		conn, err := dialRPC(command)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close() }()
+
+		client := pb.NewFuseStreamClient(conn)
+		rsp, err := client.ListInflight(ctx, &pb.TargetRequest{Target: command.String("target")})
+		if err != nil {
+			return err
+		}
+
+		rows := NewInflightRows(rsp, command.Duration("min-elapsed"))
+		if format != formatTable {
+			return printOutput(os.Stdout, format, rows)
+		}
+		printInflight(os.Stdout, rows)
+		return nil
+	},
+}
+
+// InflightRow is an in-flight operation, State tells whether it's sleeping in an injected delay
+type InflightRow struct {
+	ID        uint64  `json:"id"`
+	Op        string  `json:"op"`
+	Path      string  `json:"path"`
+	Offset    int64   `json:"offset"`
+	Length    int64   `json:"length"`
+	Pid       int32   `json:"pid"`
+	ElapsedNs int64   `json:"elapsed_ns"`
+	State     string  `json:"state"`
+	FaultIDs  []int32 `json:"fault_ids,omitempty"`
+	DelayNs   int64   `json:"delay_ns,omitempty"`
+}
+
+const (
+	inflightStateDelaying = "fault-delay"
+	inflightStateRunning  = "running"
+)
+
+// NewInflightRows converts the response, operations running shorter than minElapsed are dropped
+func NewInflightRows(rsp *pb.ListInflightResponse, minElapsed time.Duration) []InflightRow {
+	rows := make([]InflightRow, 0, len(rsp.Ops))
+	for _, op := range rsp.Ops {
+		elapsed := rsp.TimeNs - op.StartTimeNs
+		if elapsed < minElapsed.Nanoseconds() {
+			continue
+		}
+
+		state := inflightStateRunning
+		if op.Delaying {
+			state = inflightStateDelaying
+		}
+		rows = append(rows, InflightRow{
+			ID:        op.Id,
+			Op:        op.Op,
+			Path:      op.Path,
+			Offset:    op.Offset,
+			Length:    op.Length,
+			Pid:       op.Pid,
+			ElapsedNs: elapsed,
+			State:     state,
+			FaultIDs:  op.FaultIds,
+			DelayNs:   op.DelayNs,
+		})
+	}
+	return rows
+}
+
+func printInflight(w io.Writer, rows []InflightRow) {
+	tbl := table.New("ID", "Op", "Path", "Offset", "Length", "Pid", "Elapsed", "State", "Fault").WithWriter(w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	for _, r := range rows {
+		tbl.AddRow(r.ID, r.Op, r.Path, r.Offset, r.Length, r.Pid, time.Duration(r.ElapsedNs), r.State, r.describeFault())
+	}
+	tbl.Print()
+}
+
+// describeFault returns the ids of the faults injected into the operation and the delay, empty if none
+func (r InflightRow) describeFault() string {
+	if len(r.FaultIDs) == 0 {
+		return ""
+	}
+	fault := fmt.Sprintf("ids=%v", r.FaultIDs)
+	if r.DelayNs > 0 {
+		fault += fmt.Sprintf(",delay=%v", time.Duration(r.DelayNs))
+	}
+	return fault
+}
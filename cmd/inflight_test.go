@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+func TestInflightRows(t *testing.T) {
+	rsp := &pb.ListInflightResponse{
+		TimeNs: 10 * time.Second.Nanoseconds(),
+		Ops: []*pb.InflightOp{
+			{Id: 1, Op: "fuse.Write", Path: "/a", Pid: 42, StartTimeNs: time.Second.Nanoseconds(),
+				FaultIds: []int32{3}, DelayNs: time.Minute.Nanoseconds(), Delaying: true},
+			{Id: 2, Op: "fuse.Read", Path: "/b", StartTimeNs: 9 * time.Second.Nanoseconds()},
+		},
+	}
+
+	rows := NewInflightRows(rsp, 0)
+	require.Len(t, rows, 2)
+	require.Equal(t, inflightStateDelaying, rows[0].State)
+	require.Equal(t, 9*time.Second.Nanoseconds(), rows[0].ElapsedNs)
+	require.Equal(t, inflightStateRunning, rows[1].State)
+
+	rows = NewInflightRows(rsp, 5*time.Second)
+	require.Len(t, rows, 1)
+	require.Equal(t, uint64(1), rows[0].ID)
+
+	var buf bytes.Buffer
+	printInflight(&buf, rows)
+	require.Contains(t, buf.String(), "ids=[3],delay=1m0s")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"github.com/zperf/fusestream/v1"
+)
+
+// setupMetrics serves the metrics at flagMetricsListen, it returns nil metrics if the flag is not set.
+// The returned function stops the server
+func setupMetrics(command *cli.Command) (*fusestream.Metrics, func(), error) {
+	listen := command.String("metrics-listen")
+	if listen == "" {
+		return nil, func() {}, nil
+	}
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metrics := fusestream.NewMetrics()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{Handler: mux}
+	go func() {
+		log.Info().Str("listen", listener.Addr().String()).Msg("Metrics server listening")
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("Metrics server exited with error")
+		}
+	}()
+
+	return metrics, func() {
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Shutdown metrics server failed")
+		}
+	}, nil
+}
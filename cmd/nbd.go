@@ -5,19 +5,18 @@ package cmd
 import (
 	"context"
 	"errors"
-	"fmt"
 	"net"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 
-	"github.com/pojntfx/go-nbd/pkg/client"
+	nbdclient "github.com/pojntfx/go-nbd/pkg/client"
 	"github.com/pojntfx/go-nbd/pkg/server"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/zperf/fusestream/client"
 	"github.com/zperf/fusestream/pb"
 	"github.com/zperf/fusestream/v1"
 )
@@ -37,7 +36,7 @@ var nbdCommand = &cli.Command{
 var nbdServeCommand = &cli.Command{
 	Name:  "serve",
 	Usage: "Start the NBD server",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		flagNetwork,
 		&cli.StringFlag{
 			Name:    "listen",
@@ -48,7 +47,7 @@ var nbdServeCommand = &cli.Command{
 		&cli.StringFlag{
 			Name:    "rpc-listen",
 			Aliases: []string{"rl"},
-			Usage:   "The RPC server listen address",
+			Usage:   "The RPC server listen address, host:port or unix:///path/to.sock",
 			Value:   "127.0.0.1:4321",
 		},
 		&cli.StringFlag{
@@ -66,26 +65,38 @@ var nbdServeCommand = &cli.Command{
 		},
 		&cli.Uint32Flag{
 			Name:  "min-block-size",
-			Value: client.MinimumBlockSize,
+			Value: nbdclient.MinimumBlockSize,
 		},
 		&cli.Uint32Flag{
 			Name:  "preferred-block-size",
-			Value: client.MaximumBlockSize,
+			Value: nbdclient.MaximumBlockSize,
 		},
 		&cli.Uint32Flag{
 			Name:  "max-block-size",
-			Value: client.MaximumBlockSize,
+			Value: nbdclient.MaximumBlockSize,
 		},
 		&cli.BoolFlag{
 			Name:  "multi-conn",
 			Value: true,
 		},
-	},
+		flagMetricsListen,
+	}, slices.Concat(rpcServerFlags, tracingFlags)...),
 	Action: func(ctx context.Context, command *cli.Command) error {
+		shutdownTracing, err := setupTracing(ctx, command, command.String("export"))
+		if err != nil {
+			return err
+		}
+		defer shutdownTracing()
+
+		metrics, shutdownMetrics, err := setupMetrics(command)
+		if err != nil {
+			return err
+		}
+		defer shutdownMetrics()
+
 		backendFilePath := command.String("backend-file")
 		readOnly := command.Bool("read-only")
 
-		var err error
 		var fh *os.File
 		if readOnly {
 			fh, err = os.OpenFile(backendFilePath, os.O_RDONLY, 0644)
@@ -97,10 +108,27 @@ var nbdServeCommand = &cli.Command{
 		}
 		defer func() { _ = fh.Close() }()
 
+		info := fusestream.NewServerInfo(pb.ServerMode_SERVER_MODE_NBD)
+		info.BackendFile = backendFilePath
+		info.Export = command.String("export")
+		info.Exporter = exporterInfo(command)
+
 		faults := fusestream.NewFaultManager()
-		rpcServer := grpc.NewServer(grpc.Creds(insecure.NewCredentials()))
-			   pb.RegisterFuseStreamServer(rpcServer, &fusestream.Rpc{Faults: faults})
-		fileBackend := fusestream.NewFileBackend(fh, faults)
+		rpcServer, err := newRPCServer(command, command.String("rpc-listen"))
+		if err != nil {
+			return err
+		}
+		fileBackend := fusestream.NewFileBackend(ctx, command.String("export"), fh, faults)
+		fileBackend.Metrics = metrics
+		defer fileBackend.Close()
+		rpc := &fusestream.Rpc{
+			Faults:   faults,
+			Stats:    fileBackend.Stats,
+			Inflight: fileBackend.Inflight,
+			Info:     info,
+		}
+		pb.RegisterFuseStreamServer(rpcServer, rpc)
+		info.RegisterHealth(rpcServer)
 
 		options := &server.Options{
 			ReadOnly:           readOnly,
@@ -120,11 +148,17 @@ var nbdServeCommand = &cli.Command{
 		if err != nil {
 			return err
 		}
+		stopServing := func() error {
+			info.SetLive(false)
+			return listener.Close()
+		}
+		rpc.Unmounter = stopServing
 
-		rpcListener, err := net.Listen("tcp", command.String("rpc-listen"))
+		rpcListener, err := listenRPC(command, command.String("rpc-listen"))
 		if err != nil {
 			return err
 		}
+		defer stopRPCServer(rpcServer)
 		go func() {
 			err := rpcServer.Serve(rpcListener)
 			if err != nil {
@@ -137,9 +171,11 @@ var nbdServeCommand = &cli.Command{
 		go func() {
 			<-sigs
 			log.Info().Msg("Closing NBD server")
-			_ = listener.Close()
+			_ = stopServing()
 		}()
 
+		info.SetLive(true)
+
 		for {
 			conn, err := listener.Accept()
 			if err != nil {
@@ -193,150 +229,139 @@ var nbdConnectCommand = &cli.Command{
 		}
 		defer func() { _ = fh.Close() }()
 
-		return client.Connect(conn, fh, &client.Options{
+		return nbdclient.Connect(conn, fh, &nbdclient.Options{
 			ExportName: command.String("export"),
 			BlockSize:  command.Uint32("block-size"),
 		})
 	},
 }
 
+// nbdFaultFromCommand sets the conditions and the probability shared by the NBD inject commands
+func nbdFaultFromCommand(c *client.Client, command *cli.Command) *client.NbdFaultBuilder {
+	b := c.Nbd().
+		Op(command.Value("op").(pb.NbdOp)).
+		Probability(command.Float32("possibility")).
+		Priority(command.Int32("priority")).
+		Range(command.Int64("offset-start"), command.Int64("offset-end"))
+	if command.Bool("match-all") {
+		b.MatchAll()
+	}
+	if preCond := command.String("pre-cond"); preCond != "" {
+		b.PreCond(preCond)
+	}
+	return b
+}
+
 var injectNbdDelayCommand = &cli.Command{
 	Name:  "inject-delay",
 	Usage: "Inject delay for NBD",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		flagAddress,
+		flagTarget,
+		flagOutputFormat,
 		flagPossibility,
 		flagNbdOp,
 		flagPreCond,
+		flagPriority,
+		flagOffsetStart,
+		flagOffsetEnd,
+		flagMatchAll,
 		flagDelay,
-	},
+	}, rpcClientFlags...),
 	Action: func(ctx context.Context, command *cli.Command) error {
-		address := command.String("address")
-		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		format, err := outputFormat(command)
 		if err != nil {
 			return err
 		}
-		defer func() { _ = conn.Close() }()
-			   client := pb.NewFuseStreamClient(conn)
-
-		fault := &pb.NbdFault{
-			Op: command.Value("op").(pb.NbdOp),
-			Delay: &pb.NbdFault_DelayFault{
-				DelayFault: &pb.DelayFault{
-					Possibility: command.Float32("possibility"),
-					DelayMs:     command.Duration("delay").Milliseconds(),
-				},
-			},
-		}
 
-		preCond := command.String("pre-cond")
-		if preCond != "" {
-			fault.PreCond = &pb.NbdFault_Expression{
-				Expression: preCond,
-			}
+		c, err := dialClient(command)
+		if err != nil {
+			return err
 		}
+		defer func() { _ = c.Close() }()
 
-		rsp, err := client.InjectNbdFault(ctx, &pb.InjectNbdFaultRequest{Fault: fault})
+		fault, err := nbdFaultFromCommand(c, command).Delay(command.Duration("delay")).Inject(ctx)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Fault injected, id: %d\n", rsp.GetId())
-		return nil
+		return printInjectedFault(format, NewNbdFaultView(fault))
 	},
 }
 
 var injectNbdErrorCommand = &cli.Command{
 	Name:  "inject-error",
 	Usage: "Inject error for block device",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		flagAddress,
+		flagTarget,
+		flagOutputFormat,
 		flagPossibility,
 		flagNbdOp,
 		flagPreCond,
+		flagPriority,
+		flagOffsetStart,
+		flagOffsetEnd,
+		flagMatchAll,
 		&cli.StringFlag{
 			Name:     "error",
 			Required: true,
 		},
-	},
+	}, rpcClientFlags...),
 	Action: func(ctx context.Context, command *cli.Command) error {
-		address := command.String("address")
-		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		format, err := outputFormat(command)
 		if err != nil {
 			return err
 		}
-		defer func() { _ = conn.Close() }()
-			   client := pb.NewFuseStreamClient(conn)
-
-		fault := &pb.NbdFault{
-			Op: command.Value("op").(pb.NbdOp),
-			Err: &pb.NbdFault_ErrorFault{
-				ErrorFault: &pb.ErrorFault{
-					Possibility: command.Float32("possibility"),
-					Err:         command.String("error"),
-				},
-			},
-		}
 
-		preCond := command.String("pre-cond")
-		if preCond != "" {
-			fault.PreCond = &pb.NbdFault_Expression{
-				Expression: preCond,
-			}
+		c, err := dialClient(command)
+		if err != nil {
+			return err
 		}
+		defer func() { _ = c.Close() }()
 
-		rsp, err := client.InjectNbdFault(ctx, &pb.InjectNbdFaultRequest{Fault: fault})
+		fault, err := nbdFaultFromCommand(c, command).Error(command.String("error")).Inject(ctx)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Fault injected, id: %d\n", rsp.GetId())
-		return nil
+		return printInjectedFault(format, NewNbdFaultView(fault))
 	},
 }
 
 var injectNbdReturnValueCommand = &cli.Command{
 	Name:  "inject-return-value",
 	Usage: "Inject return value for block device",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		flagAddress,
+		flagTarget,
+		flagOutputFormat,
 		flagPossibility,
 		flagReturnValue,
 		flagNbdOp,
 		flagPreCond,
-	},
+		flagPriority,
+		flagOffsetStart,
+		flagOffsetEnd,
+		flagMatchAll,
+	}, rpcClientFlags...),
 	Action: func(ctx context.Context, command *cli.Command) error {
-		address := command.String("address")
-		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		format, err := outputFormat(command)
 		if err != nil {
 			return err
 		}
-		defer func() { _ = conn.Close() }()
-			   client := pb.NewFuseStreamClient(conn)
-
-		fault := &pb.NbdFault{
-			Op: command.Value("op").(pb.NbdOp),
-			ReturnValue: &pb.NbdFault_ReturnValueFault{
-				ReturnValueFault: &pb.ReturnValueFault{
-					Possibility: command.Float32("possibility"),
-					ReturnValue: command.Int64("return-value"),
-				},
-			},
-		}
 
-		preCond := command.String("pre-cond")
-		if preCond != "" {
-			fault.PreCond = &pb.NbdFault_Expression{
-				Expression: preCond,
-			}
+		c, err := dialClient(command)
+		if err != nil {
+			return err
 		}
+		defer func() { _ = c.Close() }()
 
-		rsp, err := client.InjectNbdFault(ctx, &pb.InjectNbdFaultRequest{Fault: fault})
+		fault, err := nbdFaultFromCommand(c, command).ReturnValue(command.Int64("return-value")).Inject(ctx)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Fault injected, id: %d\n", rsp.GetId())
-		return nil
+		return printInjectedFault(format, NewNbdFaultView(fault))
 	},
 }
@@ -53,3 +53,16 @@ func NewNbdOpCliEnum() flag.Getter {
 		cast: func(a int32) pb.NbdOp { return pb.NbdOp(a) },
 	}
 }
+
+// parseOpenFlags accepts names like O_DIRECT or direct
+func parseOpenFlags(names []string) ([]pb.OpenFlag, error) {
+	flags := make([]pb.OpenFlag, 0, len(names))
+	for _, name := range names {
+		v, ok := pb.OpenFlag_value["OPEN_FLAG_"+strings.TrimPrefix(strings.ToUpper(name), "O_")]
+		if !ok || v == int32(pb.OpenFlag_OPEN_FLAG_UNKNOWN) || v == int32(pb.OpenFlag_OPEN_FLAG_TRUNC) {
+			return nil, fmt.Errorf("invalid open flag: %s. Allowed values are O_DIRECT, O_SYNC, O_DSYNC, O_APPEND", name)
+		}
+		flags = append(flags, pb.OpenFlag(v))
+	}
+	return flags, nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormat validates the flagOutputFormat value, table, json and yaml are always supported
+func outputFormat(command *cli.Command, extra ...string) (string, error) {
+	format := command.String("output")
+	if format == formatTable || format == formatJSON || format == formatYAML || slices.Contains(extra, format) {
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown output format: %s", format)
+}
+
+// printOutput prints v in the machine-readable format, json or yaml
+func printOutput(w io.Writer, format string, v any) error {
+	switch format {
+	case formatJSON:
+		return printJSON(w, v)
+	case formatYAML:
+		return printYAML(w, v)
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
+func printJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printYAML prints v with the field names and the field order of its JSON encoding, so both formats are the same
+// document
+func printYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON is YAML in the flow style
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetYAMLStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// resetYAMLStyle switches the nodes to the block style and unquoted strings where possible
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetYAMLStyle(n)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rodaine/table"
+	"github.com/urfave/cli/v3"
+
+	"github.com/zperf/fusestream/v1"
+)
+
+const (
+	timingFast     = "fast"
+	timingOriginal = "original"
+	timingScaled   = "scaled"
+)
+
+var replayCommand = &cli.Command{
+	Name:  "replay",
+	Usage: "Replay the FUSE operations of a trace against a directory or mount",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "input",
+			Aliases:  []string{"i"},
+			Usage:    "The parquet file, or a directory or glob of rotated segments",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "target",
+			Aliases:  []string{"t"},
+			Usage:    "The directory or mountpoint to replay against",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "timing",
+			Usage: "fast replays as fast as possible, original keeps the recorded timing, scaled divides it by --speed",
+			Value: timingOriginal,
+		},
+		&cli.Float64Flag{
+			Name:  "speed",
+			Usage: "The speed factor of the scaled timing",
+			Value: 1,
+		},
+		&cli.IntFlag{
+			Name:    "concurrency",
+			Aliases: []string{"c"},
+			Usage:   "The number of workers, operations on the same path keep their order",
+			Value:   4,
+		},
+		&cli.BoolFlag{
+			Name:  "include-failed",
+			Usage: "Replay the operations failed in the trace",
+		},
+		flagOutputFormat,
+	},
+	Action: func(ctx context.Context, command *cli.Command) error {
+		format, err := outputFormat(command)
+		if err != nil {
+			return err
+		}
+
+		opts := fusestream.ReplayOptions{
+			Target:      command.String("target"),
+			Concurrency: int(command.Int("concurrency")),
+			SkipFailed:  !command.Bool("include-failed"),
+		}
+		switch command.String("timing") {
+		case timingFast:
+		case timingOriginal:
+			opts.Speed = 1
+		case timingScaled:
+			opts.Speed = command.Float64("speed")
+			if opts.Speed <= 0 {
+				return fmt.Errorf("speed must be positive, got %v", opts.Speed)
+			}
+		default:
+			return fmt.Errorf("unknown timing: %s", command.String("timing"))
+		}
+
+		st, err := os.Stat(opts.Target)
+		if err != nil {
+			return err
+		}
+		if !st.IsDir() {
+			return fmt.Errorf("target is not a directory: %s", opts.Target)
+		}
+
+		records, err := readRecords(command.String("input"))
+		if err != nil {
+			return err
+		}
+
+		report, err := fusestream.Replay(ctx, records, opts)
+		if err != nil {
+			return err
+		}
+
+		if format != formatTable {
+			return printOutput(os.Stdout, format, report)
+		}
+
+		tbl := table.New("Op", "Count", "Errors", "Skipped", "Mean").WithWriter(os.Stdout).
+			WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+		for _, op := range report.Ops {
+			tbl.AddRow(op.Op, op.Count, op.Errors, op.Skipped, formatMs(op.ElapsedNs/max(op.Count, 1)))
+		}
+		tbl.Print()
+		fmt.Printf("Replayed in %v, %d records skipped\n", time.Duration(report.ElapsedNs), report.Skipped)
+		return nil
+	},
+}
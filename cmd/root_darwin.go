@@ -11,5 +11,9 @@ var RootCommand = &cli.Command{
 		faultCommand,
 		toolCommand,
 		statCommand,
+		replayCommand,
+		topCommand,
+		inflightCommand,
+		statusCommand,
 	},
 }
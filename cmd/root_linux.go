@@ -13,5 +13,10 @@ var RootCommand = &cli.Command{
 		faultCommand,
 		toolCommand,
 		statCommand,
+		replayCommand,
+		topCommand,
+		inflightCommand,
+		statusCommand,
+		daemonCommand,
 	},
 }
@@ -12,5 +12,10 @@ var RootCommand = &cli.Command{
 		faultCommand,
 		toolCommand,
 		statCommand,
+		replayCommand,
+		topCommand,
+		inflightCommand,
+		statusCommand,
+		daemonCommand,
 	},
 }
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/zperf/fusestream/client"
+	"github.com/zperf/fusestream/rpcauth"
+	"github.com/zperf/fusestream/v1"
+)
+
+var flagTLS = &cli.BoolFlag{
+	Name:  "tls",
+	Usage: "Connect with TLS, verified by the system CAs if --tls-ca is not set",
+}
+
+var flagTLSCert = &cli.StringFlag{
+	Name:  "tls-cert",
+	Usage: "The TLS certificate, the client certificate for mTLS on clients",
+}
+
+var flagTLSKey = &cli.StringFlag{
+	Name:  "tls-key",
+	Usage: "The TLS private key of --tls-cert",
+}
+
+var flagTLSCA = &cli.StringFlag{
+	Name:  "tls-ca",
+	Usage: "The CA certificate verifying the peer, servers require client certificates (mTLS) if set",
+}
+
+var flagTLSServerName = &cli.StringFlag{
+	Name:  "tls-server-name",
+	Usage: "Verify the server certificate against the name instead of the address host",
+}
+
+var flagAuthToken = &cli.StringFlag{
+	Name:    "auth-token",
+	Usage:   "The bearer token, servers reject RPCs without it if set",
+	Sources: cli.NewValueSourceChain(cli.EnvVar("FUSESTREAM_AUTH_TOKEN")),
+}
+
+var flagSocketMode = &cli.StringFlag{
+	Name:  "socket-mode",
+	Usage: "The file mode of the unix socket RPC server, in octal",
+	Value: "0660",
+}
+
+// rpcServerFlags configure the RPC server of fuse mount and nbd serve
+var rpcServerFlags = []cli.Flag{
+	flagSocketMode,
+	flagTLSCert,
+	flagTLSKey,
+	flagTLSCA,
+	flagAuthToken,
+}
+
+// rpcClientFlags are used by dialRPC, along with flagAddress
+var rpcClientFlags = []cli.Flag{
+	flagTLS,
+	flagTLSCert,
+	flagTLSKey,
+	flagTLSCA,
+	flagTLSServerName,
+	flagAuthToken,
+}
+
+// checkRPCServerSecurity rejects the settings serving less than asked for: a CA without a server certificate would
+// serve plaintext without client auth, and a token over plaintext TCP would be sent in clear
+func checkRPCServerSecurity(address, certFile, keyFile, caFile, token string) error {
+	useTLS := certFile != "" || keyFile != ""
+	if caFile != "" && (certFile == "" || keyFile == "") {
+		return errors.New("--tls-ca requires --tls-cert and --tls-key")
+	}
+	if _, unix := fusestream.UnixSocketPath(address); token != "" && !useTLS && !unix {
+		return errors.New("--auth-token requires TLS or a unix socket listener, the token would be sent in clear")
+	}
+	return nil
+}
+
+// newRPCServer creates the gRPC server listening on address from rpcServerFlags, TLS is enabled if the certificate
+// is set
+func newRPCServer(command *cli.Command, address string) (*grpc.Server, error) {
+	err := checkRPCServerSecurity(address, command.String("tls-cert"), command.String("tls-key"),
+		command.String("tls-ca"), command.String("auth-token"))
+	if err != nil {
+		return nil, err
+	}
+
+	creds := insecure.NewCredentials()
+	if command.String("tls-cert") != "" || command.String("tls-key") != "" {
+		config, err := rpcauth.ServerTLSConfig(command.String("tls-cert"), command.String("tls-key"),
+			command.String("tls-ca"))
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(config)
+	}
+
+	opts := []grpc.ServerOption{grpc.Creds(creds)}
+	if token := command.String("auth-token"); token != "" {
+		opts = append(opts, rpcauth.TokenServerOptions(token)...)
+	}
+	return grpc.NewServer(opts...), nil
+}
+
+// rpcStopTimeout bounds the graceful stop of the RPC server, streams such as health watches never end by themselves
+const rpcStopTimeout = 5 * time.Second
+
+// stopRPCServer lets the running RPCs return, the server is stopped forcibly after rpcStopTimeout
+func stopRPCServer(server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(rpcStopTimeout):
+		server.Stop()
+	}
+}
+
+// listenRPC listens on a TCP or a unix:// address, the socket file is created with flagSocketMode
+func listenRPC(command *cli.Command, address string) (net.Listener, error) {
+	mode, err := strconv.ParseUint(command.String("socket-mode"), 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid socket mode: %w", err)
+	}
+	return fusestream.ListenRPC(address, os.FileMode(mode))
+}
+
+// dialClient connects to the server at flagAddress with rpcClientFlags, the faults of flagTarget are controlled
+func dialClient(command *cli.Command) (*client.Client, error) {
+	var opts []client.Option
+	if command.Bool("tls") || command.String("tls-ca") != "" || command.String("tls-cert") != "" {
+		opts = append(opts, client.WithTLS(command.String("tls-ca"), command.String("tls-cert"),
+			command.String("tls-key"), command.String("tls-server-name")))
+	}
+	if token := command.String("auth-token"); token != "" {
+		opts = append(opts, client.WithToken(token))
+	}
+	opts = append(opts, client.WithTarget(command.String("target")))
+	return client.New(command.String("address"), opts...)
+}
+
+// dialRPC is dialClient for the RPCs without a client helper
+func dialRPC(command *cli.Command) (*grpc.ClientConn, error) {
+	c, err := dialClient(command)
+	if err != nil {
+		return nil, err
+	}
+	return c.Conn(), nil
+}
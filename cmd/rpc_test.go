@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRPCServerSecurity(t *testing.T) {
+	require.NoError(t, checkRPCServerSecurity("127.0.0.1:4321", "", "", "", ""))
+	require.NoError(t, checkRPCServerSecurity("127.0.0.1:4321", "server.pem", "server-key.pem", "ca.pem", "secret"))
+	require.NoError(t, checkRPCServerSecurity("unix:///run/fs.sock", "", "", "", "secret"))
+
+	// mTLS without a server certificate
+	require.Error(t, checkRPCServerSecurity("127.0.0.1:4321", "", "", "ca.pem", ""))
+	require.Error(t, checkRPCServerSecurity("127.0.0.1:4321", "server.pem", "", "ca.pem", ""))
+	// the token in clear
+	require.Error(t, checkRPCServerSecurity("127.0.0.1:4321", "", "", "", "secret"))
+}
@@ -3,22 +3,28 @@ package cmd
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/aybabtme/uniplot/histogram"
 	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
-	"github.com/jmoiron/sqlx"
 	"github.com/negrel/assert"
 	"github.com/rodaine/table"
 	"github.com/urfave/cli/v3"
 	"golang.org/x/term"
 
 	"github.com/zperf/fusestream/histo"
+	"github.com/zperf/fusestream/v1"
 )
 
 var statCommand = &cli.Command{
@@ -26,6 +32,8 @@ var statCommand = &cli.Command{
 	Commands: []*cli.Command{
 		statSummaryCommand,
 		statExportCsvCommand,
+		statDiffCommand,
+		statTimelineCommand,
 	},
 }
 
@@ -46,6 +54,64 @@ func printLine() {
 	fmt.Println(strings.Repeat("-", consoleWidth))
 }
 
+var flagStatInput = &cli.StringFlag{
+	Name:    "input",
+	Aliases: []string{"i"},
+	Usage:   "Read records from the parquet file, or a directory or glob of rotated segments, all loaded in memory",
+}
+
+// parquetSegments expands a file, a directory or a glob to parquet files in time order,
+// rotated segments are named by timestamp so they sort by name
+func parquetSegments(input string) ([]string, error) {
+	st, err := os.Stat(input)
+	if err == nil && st.IsDir() {
+		input = filepath.Join(input, "*.parquet")
+	}
+
+	files, err := filepath.Glob(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no parquet file matches %s", input)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadRecords reads the records from --input in start time order
+func loadRecords(command *cli.Command) ([]fusestream.IORecord, error) {
+	input := command.String("input")
+	if input == "" {
+		return nil, errors.New("--input is required")
+	}
+	return readRecords(input)
+}
+
+// readRecords reads the records from the parquet file, directory or glob, in start time order. The records of all
+// segments are held in memory at once, a glob of some segments bounds it for traces of long-running mounts
+func readRecords(input string) ([]fusestream.IORecord, error) {
+	files, err := parquetSegments(input)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := fusestream.ReadIORecords(files...)
+	if err != nil {
+		return nil, err
+	}
+
+	sortRecords(records)
+	return records, nil
+}
+
+func sortRecords(records []fusestream.IORecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].StartTimeNs < records[j].StartTimeNs
+	})
+}
+
 type printOp struct {
 	Name string
 	Fn   func() error
@@ -53,16 +119,25 @@ type printOp struct {
 
 var zeroTime = time.Time{}
 
+// operations of both FUSE and NBD records
+var (
+	readOps  = map[string]bool{"fuse.Read": true, "nbd.ReadAt": true}
+	writeOps = map[string]bool{"fuse.Write": true, "nbd.WriteAt": true}
+	syncOps  = map[string]bool{"fuse.Fsync": true, "fuse.Fsyncdir": true, "nbd.Sync": true}
+)
+
+func isIOOp(name string) bool {
+	return readOps[name] || writeOps[name]
+}
+
 var statExportCsvCommand = &cli.Command{
 	Name: "export-csv",
 	Flags: []cli.Flag{
+		flagStatInput,
 		&cli.StringFlag{
-			Name:     "dsn",
-			Required: true,
-		},
-		&cli.StringFlag{
-			Name:     "output",
-			Aliases:  []string{"o"},
+			Name:     "file",
+			Aliases:  []string{"f"},
+			Usage:    "CSV file to write",
 			Required: true},
 		&cli.BoolFlag{
 			Name:    "humanize",
@@ -78,8 +153,7 @@ var statExportCsvCommand = &cli.Command{
 		},
 	},
 	Action: func(ctx context.Context, command *cli.Command) error {
-		outputPath := command.String("output")
-		dsn := command.String("dsn")
+		outputPath := command.String("file")
 		isHumanize := command.Bool("humanize")
 
 		startTs, err := time.Parse(time.RFC3339, command.String("start"))
@@ -92,134 +166,118 @@ var statExportCsvCommand = &cli.Command{
 			return err
 		}
 
-		db, err := sqlx.Open("duckdb", dsn)
+		records, err := loadRecords(command)
 		if err != nil {
 			return err
 		}
-		defer func() { _ = db.Close() }()
-
-		if !isHumanize {
-			startTimeNs := startTs.UnixNano()
-			endTimeNs := endTs.UnixNano()
-
-			if startTs != zeroTime && endTs != zeroTime {
-				_, err = db.Exec(fmt.Sprintf(
-					`COPY (SELECT start_time_ns, name, elapsed_ns, "offset", length, path FROM slowio_records
-WHERE %v <= start_time_ns AND start_time_ns <= %v)
-TO '%s' (HEADER, DELIMITER ',')`, startTimeNs, endTimeNs, outputPath))
-			} else if startTs != zeroTime && endTs == zeroTime {
-				_, err = db.Exec(fmt.Sprintf(
-					`COPY (SELECT start_time_ns, name, elapsed_ns, "offset", length, path FROM slowio_records
-WHERE %v <= start_time_ns)
-TO '%s' (HEADER, DELIMITER ',')`, startTimeNs, outputPath))
-			} else if startTs == zeroTime && endTs != zeroTime {
-				_, err = db.Exec(fmt.Sprintf(
-					`COPY (SELECT start_time_ns, name, elapsed_ns, "offset", length, path FROM slowio_records
-WHERE start_time_ns <= %v)
-TO '%s' (HEADER, DELIMITER ',')`, endTimeNs, outputPath))
-			} else if startTs == zeroTime && endTs == zeroTime {
-				_, err = db.Exec(fmt.Sprintf(
-					`COPY (SELECT start_time_ns, name, elapsed_ns, "offset", length, path FROM slowio_records)
-TO '%s' (HEADER, DELIMITER ',')`, outputPath))
-			}
-			if err != nil {
-				return err
-			}
-		} else {
-			f, err := os.Create(outputPath)
-			if err != nil {
-				return err
-			}
-			w := csv.NewWriter(f)
-			defer func() {
-				w.Flush()
-				_ = f.Close()
-			}()
 
+		f, err := os.Create(outputPath)
+		if err != nil {
+			return err
+		}
+		w := csv.NewWriter(f)
+		defer func() {
+			w.Flush()
+			_ = f.Close()
+		}()
+
+		if isHumanize {
 			err = w.Write([]string{"start", "name", "elapsed", "offset", "length", "path"})
-			if err != nil {
-				return err
-			}
+		} else {
+			err = w.Write([]string{"start_time_ns", "name", "elapsed_ns", "offset", "length", "path"})
+		}
+		if err != nil {
+			return err
+		}
 
-			rows, err := db.Query(`SELECT name, start_time_ns, elapsed_ns, "offset", length, path FROM slowio_records`)
-			if err != nil {
-				return err
+		for _, r := range records {
+			startTime := time.Unix(0, r.StartTimeNs).Local()
+			if startTs != zeroTime && startTime.Before(startTs) {
+				continue
+			}
+			if endTs != zeroTime && startTime.After(endTs) {
+				continue
 			}
 
-			for rows.Next() {
-				var name, path string
-				var startTimeNs, elapsedNs, offset int64
-				var length int32
-				err = rows.Scan(&name, &startTimeNs, &elapsedNs, &offset, &length, &path)
-				if err != nil {
-					return err
-				}
-
-				startTime := time.Unix(0, startTimeNs).Local()
-
-				writeFn := func() error {
-					return w.Write([]string{
-						startTime.Format(time.RFC3339Nano),
-						name,
-						(time.Duration(elapsedNs) * time.Nanosecond).String(),
-						fmt.Sprintf("%d", offset),
-						fmt.Sprintf("%d", length),
-						path,
-					})
-				}
-
-				err = nil
-				if startTs != zeroTime && endTs != zeroTime {
-					if startTime.After(startTs) && startTime.Before(endTs) {
-						err = writeFn()
-					}
-				} else if startTs != zeroTime && startTime.After(startTs) {
-					err = writeFn()
-				} else if endTs != zeroTime && startTime.Before(endTs) {
-					err = writeFn()
-				} else if startTs == zeroTime && endTs == zeroTime {
-					err = writeFn()
-				}
-
-				if err != nil {
-					return err
-				}
+			if isHumanize {
+				err = w.Write([]string{
+					startTime.Format(time.RFC3339Nano),
+					r.Name,
+					(time.Duration(r.ElapsedNs) * time.Nanosecond).String(),
+					fmt.Sprintf("%d", r.Offset),
+					fmt.Sprintf("%d", r.Length),
+					r.Path,
+				})
+			} else {
+				err = w.Write([]string{
+					fmt.Sprintf("%d", r.StartTimeNs),
+					r.Name,
+					fmt.Sprintf("%d", r.ElapsedNs),
+					fmt.Sprintf("%d", r.Offset),
+					fmt.Sprintf("%d", r.Length),
+					r.Path,
+				})
 			}
-			err = rows.Err()
 			if err != nil {
 				return err
 			}
 		}
 
-		return nil
+		w.Flush()
+		return w.Error()
 	},
 }
 
 var statSummaryCommand = &cli.Command{
 	Name: "summary",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:     "dsn",
-			Required: true,
+		flagStatInput,
+		flagOutputFormat,
+		&cli.IntFlag{
+			Name:  "path-depth",
+			Usage: "Group latency by the path prefix of the depth, 0 to disable",
+			Value: 1,
+		},
+		&cli.DurationFlag{
+			Name:  "window",
+			Usage: "Split latency percentiles into time windows",
 		},
 	},
 	Action: func(ctx context.Context, command *cli.Command) error {
-		dsn := command.String("dsn")
-		db, err := sqlx.Open("duckdb", dsn)
+		format, err := outputFormat(command)
 		if err != nil {
 			return err
 		}
-		defer func() { _ = db.Close() }()
 
-		s := NewFuseStat(db, os.Stdout)
+		records, err := loadRecords(command)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return errors.New("no records")
+		}
+
+		s := NewFuseStat(records, os.Stdout)
+		s.PathDepth = int(command.Int("path-depth"))
+		s.Window = command.Duration("window")
+
+		if format != formatTable {
+			return printOutput(os.Stdout, format, s.Summary())
+		}
 
 		printFuncTable := []printOp{
 			{"Summary", s.PrintSummary},
-			{"Random/Sequential ratio", s.PrintRandomSequentialRatioTable},
-			{"File system operation count histogram", s.PrintFileSystemOperationHistogram},
-			{"File system operation durations (unit: ms)", s.PrintFileSystemOperationElapseBarChart},
-			{"I/O size histogram", s.PrintIOSizeHistogram},
+			{"Latency by operation", s.PrintLatencyByOp},
+		}
+		if s.PathDepth > 0 {
+			printFuncTable = append(printFuncTable, printOp{"Latency by path", s.PrintLatencyByPath})
 		}
+		printFuncTable = append(printFuncTable,
+			printOp{"Random/Sequential ratio", s.PrintRandomSequentialRatioTable},
+			printOp{"Operation count histogram", s.PrintFileSystemOperationHistogram},
+			printOp{"Operation durations (unit: ms)", s.PrintFileSystemOperationElapseBarChart},
+			printOp{"I/O size histogram", s.PrintIOSizeHistogram},
+		)
 
 		for _, p := range printFuncTable {
 			if p.Name != "Summary" {
@@ -239,86 +297,132 @@ var statSummaryCommand = &cli.Command{
 }
 
 type FuseStat struct {
-	w  io.Writer
-	db *sqlx.DB
+	w io.Writer
+	// records are in start time order
+	records []fusestream.IORecord
+
+	// PathDepth is the number of path components grouped by in latency by path, 0 disables it
+	PathDepth int
+	// Window splits latency percentiles into time windows, 0 for the whole run
+	Window time.Duration
 }
 
-func NewFuseStat(db *sqlx.DB, w io.Writer) *FuseStat {
+func NewFuseStat(records []fusestream.IORecord, w io.Writer) *FuseStat {
 	return &FuseStat{
-		w:  w,
-		db: db,
+		w:       w,
+		records: records,
 	}
 }
 
-func (s *FuseStat) PrintSummary() error {
-	var meanElapsed, maxElapsed, minElapsed float64
-	err := s.db.QueryRow("SELECT MEAN(elapsed_ns), MAX(elapsed_ns), MIN(elapsed_ns) FROM slowio_records;").
-		Scan(&meanElapsed, &maxElapsed, &minElapsed)
-	if err != nil {
-		return err
-	}
+// Summary is the summary of the whole run, durations are in nanoseconds and sizes in bytes
+type Summary struct {
+	Records     int64   `json:"records"`
+	RuntimeNs   int64   `json:"runtime_ns"`
+	Bandwidth   float64 `json:"bandwidth"`
+	IOPS        float64 `json:"iops"`
+	RWRatio     float64 `json:"rw_ratio"`
+	Reads       int64   `json:"reads"`
+	Writes      int64   `json:"writes"`
+	Syncs       int64   `json:"syncs"`
+	Errors      int64   `json:"errors"`
+	ErrorRate   float64 `json:"error_rate"`
+	MeanIOSize  float64 `json:"mean_io_size"`
+	MinIOSize   int64   `json:"min_io_size"`
+	MaxIOSize   int64   `json:"max_io_size"`
+	MeanElapsed float64 `json:"mean_elapsed_ns"`
+	MinElapsed  int64   `json:"min_elapsed_ns"`
+	MaxElapsed  int64   `json:"max_elapsed_ns"`
+
+	LatencyByOp   []LatencyRow `json:"latency_by_op"`
+	LatencyByPath []LatencyRow `json:"latency_by_path,omitempty"`
+}
 
-	var runtime float64
-	err = s.db.QueryRow(`SELECT (MAX(start_time_ns) - MIN(start_time_ns) +
-        (SELECT elapsed_ns FROM slowio_records ORDER BY start_time_ns DESC LIMIT 1)) / 1000000 / 1000
-	FROM slowio_records;`).Scan(&runtime)
-	if err != nil {
-		return err
+func (s *FuseStat) Summary() Summary {
+	var sumElapsed, totalBytes, ioCount int64
+	sum := Summary{
+		Records:    int64(len(s.records)),
+		MinElapsed: math.MaxInt64,
+		MinIOSize:  math.MaxInt64,
 	}
 
-	var reads, writes int64
-	err = s.db.QueryRow(`SELECT COUNT(*) FROM slowio_records WHERE name = 'fuse.Read';`).Scan(&reads)
-	if err != nil {
-		return err
-	}
+	for _, r := range s.records {
+		sumElapsed += r.ElapsedNs
+		sum.MaxElapsed = max(sum.MaxElapsed, r.ElapsedNs)
+		sum.MinElapsed = min(sum.MinElapsed, r.ElapsedNs)
 
-	err = s.db.QueryRow(`SELECT COUNT(*) FROM slowio_records WHERE name = 'fuse.Write';`).Scan(&writes)
-	if err != nil {
-		return err
-	}
+		if readOps[r.Name] {
+			sum.Reads++
+		}
+		if writeOps[r.Name] {
+			sum.Writes++
+		}
+		if syncOps[r.Name] {
+			sum.Syncs++
+		}
+		if r.Errc < 0 {
+			sum.Errors++
+		}
 
-	rwRatio := 1.0
-	if writes > 0 {
-		rwRatio = float64(reads) / float64(writes)
+		if isIOOp(r.Name) {
+			length := int64(r.Length)
+			totalBytes += length
+			ioCount++
+			sum.MaxIOSize = max(sum.MaxIOSize, length)
+			sum.MinIOSize = min(sum.MinIOSize, length)
+		}
+	}
+	if ioCount == 0 {
+		sum.MinIOSize = 0
+	}
+	if len(s.records) == 0 {
+		sum.MinElapsed = 0
+		return sum
 	}
 
-	var totalBytes int64
-	err = s.db.QueryRow(`SELECT SUM(length) FROM slowio_records
-                   WHERE name = 'fuse.Read' OR name = 'fuse.Write';`).Scan(&totalBytes)
-	if err != nil {
-		return err
+	sum.MeanElapsed = float64(sumElapsed) / float64(len(s.records))
+	sum.RuntimeNs = runtimeNs(s.records)
+	if sum.RuntimeNs > 0 {
+		runtime := float64(sum.RuntimeNs) / 1000 / 1000 / 1000
+		sum.Bandwidth = float64(totalBytes) / runtime
+		sum.IOPS = float64(ioCount) / runtime
 	}
 
-	var ioCount int64
-	err = s.db.QueryRow(`SELECT COUNT(*) FROM slowio_records
-                   WHERE name = 'fuse.Read' OR name = 'fuse.Write';`).Scan(&ioCount)
-	if err != nil {
-		return err
+	sum.RWRatio = 1.0
+	if sum.Writes > 0 {
+		sum.RWRatio = float64(sum.Reads) / float64(sum.Writes)
 	}
 
-	var meanIOSize float64
-	var maxIOSize, minIOSize int64
-	err = s.db.QueryRow(`SELECT MEAN(length), MAX(length), MIN(length) FROM slowio_records
-                    WHERE name = 'fuse.Read' OR name = 'fuse.Write';`).Scan(&meanIOSize, &maxIOSize, &minIOSize)
-	if err != nil {
-		return err
+	if ioCount > 0 {
+		sum.MeanIOSize = float64(totalBytes) / float64(ioCount)
 	}
 
+	sum.ErrorRate = float64(sum.Errors) / float64(len(s.records))
+
+	sum.LatencyByOp = s.LatencyByOp()
+	sum.LatencyByPath = s.LatencyByPath()
+	return sum
+}
+
+func (s *FuseStat) PrintSummary() error {
+	sum := s.Summary()
+
 	tbl := table.New("Property", "Mean", "Min", "Max").WithWriter(s.w).
 		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
-	tbl.AddRow("Runtime", fmt.Sprintf("%.3fs", runtime))
-	tbl.AddRow("Bandwidth", fmt.Sprintf("%s/s", humanize.Bytes(uint64(float64(totalBytes)/runtime))))
-	tbl.AddRow("IOPS", fmt.Sprintf("%.3f", float64(ioCount)/runtime))
-	tbl.AddRow("R/W ratio", fmt.Sprintf("%.3f", rwRatio))
+	tbl.AddRow("Runtime", fmt.Sprintf("%.3fs", float64(sum.RuntimeNs)/1000/1000/1000))
+	tbl.AddRow("Bandwidth", fmt.Sprintf("%s/s", humanize.Bytes(uint64(sum.Bandwidth))))
+	tbl.AddRow("IOPS", fmt.Sprintf("%.3f", sum.IOPS))
+	tbl.AddRow("R/W ratio", fmt.Sprintf("%.3f", sum.RWRatio))
+	tbl.AddRow("Syncs", fmt.Sprintf("%d", sum.Syncs))
+	tbl.AddRow("Error rate", fmt.Sprintf("%.3f%%", sum.ErrorRate*100))
 	tbl.AddRow("I/O size",
-		fmt.Sprintf("%v", humanize.Bytes(uint64(meanIOSize))),
-		fmt.Sprintf("%v", humanize.Bytes(uint64(minIOSize))),
-		fmt.Sprintf("%v", humanize.Bytes(uint64(maxIOSize))),
+		fmt.Sprintf("%v", humanize.Bytes(uint64(sum.MeanIOSize))),
+		fmt.Sprintf("%v", humanize.Bytes(uint64(sum.MinIOSize))),
+		fmt.Sprintf("%v", humanize.Bytes(uint64(sum.MaxIOSize))),
 	)
 	tbl.AddRow("I/O elapsed",
-		fmt.Sprintf("%.3fms", meanElapsed/1000/1000),
-		fmt.Sprintf("%.3fms", minElapsed/1000/1000),
-		fmt.Sprintf("%.3fms", maxElapsed/1000/1000),
+		fmt.Sprintf("%.3fms", sum.MeanElapsed/1000/1000),
+		fmt.Sprintf("%.3fms", float64(sum.MinElapsed)/1000/1000),
+		fmt.Sprintf("%.3fms", float64(sum.MaxElapsed)/1000/1000),
 	)
 
 	tbl.Print()
@@ -327,78 +431,24 @@ func (s *FuseStat) PrintSummary() error {
 
 func (s *FuseStat) PrintFileSystemOperationElapseBarChart() error {
 	data := make(map[string]float64)
-
-	rows, err := s.db.Query("SELECT name, elapsed_ns FROM slowio_records;")
-	if err != nil {
-		return err
-	}
-	defer func() { _ = rows.Close() }()
-
-	for rows.Next() {
-		var name string
-		var elapsedNs int64
-		err = rows.Scan(&name, &elapsedNs)
-		if err != nil {
-			return err
-		}
-		data[name] += float64(elapsedNs) / 1000 / 1000 // ms
-	}
-
-	err = rows.Err()
-	if err != nil {
-		return err
+	for _, r := range s.records {
+		data[r.Name] += float64(r.ElapsedNs) / 1000 / 1000 // ms
 	}
-
 	return histo.PrintBarChart(data, s.w)
 }
 
 func (s *FuseStat) PrintFileSystemOperationHistogram() error {
-	names := make([]string, 0)
-
-	rows, err := s.db.Query(`SELECT name FROM slowio_records;`)
-	if err != nil {
-		return err
+	names := make([]string, 0, len(s.records))
+	for _, r := range s.records {
+		names = append(names, r.Name)
 	}
-	defer func() { _ = rows.Close() }()
-
-	for rows.Next() {
-		var name string
-		err = rows.Scan(&name)
-		if err != nil {
-			return err
-		}
-		names = append(names, name)
-	}
-
-	err = rows.Err()
-	if err != nil {
-		return err
-	}
-
 	return histo.PrintHistogram(names, s.w)
 }
 
 func (s *FuseStat) PrintIOSizeHistogram() error {
-	data := make([]float64, 0)
-
-	rows, err := s.db.Query("SELECT length FROM slowio_records;")
-	if err != nil {
-		return err
-	}
-	defer func() { _ = rows.Close() }()
-
-	for rows.Next() {
-		var length int64
-		err = rows.Scan(&length)
-		if err != nil {
-			return err
-		}
-		data = append(data, float64(length))
-	}
-
-	err = rows.Err()
-	if err != nil {
-		return err
+	data := make([]float64, 0, len(s.records))
+	for _, r := range s.records {
+		data = append(data, float64(r.Length))
 	}
 
 	h := histogram.Hist(256/16, data)
@@ -413,30 +463,10 @@ func (s *FuseStat) PrintRandomSequentialRatioTable() error {
 		WithHeaderFormatter(tableHeaderFmt).
 		WithFirstColumnFormatter(tableColumnFmt)
 
-	files := make([]string, 0)
-	rows, err := s.db.Query(`SELECT DISTINCT path FROM slowio_records
-		WHERE name = 'fuse.Read' OR name = 'fuse.Write'
-		ORDER BY path;`)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = rows.Close() }()
-
-	for rows.Next() {
-		var path string
-		err = rows.Scan(&path)
-		if err != nil {
-			return err
-		}
-		files = append(files, path)
-	}
-
+	ios := s.ioByPath()
+	files := slices.Sorted(maps.Keys(ios))
 	for _, path := range files {
-		var rnd, seq int64
-		rnd, seq, err = s.countRandomIOs(path)
-		if err != nil {
-			return err
-		}
+		rnd, seq := countRandomIOs(ios[path])
 		cnt := rnd + seq
 
 		tbl.AddRow(path,
@@ -449,6 +479,31 @@ func (s *FuseStat) PrintRandomSequentialRatioTable() error {
 	return nil
 }
 
+// ioByPath groups the I/Os by path, in start time order
+func (s *FuseStat) ioByPath() map[string][]fusestream.IORecord {
+	ios := make(map[string][]fusestream.IORecord)
+	for _, r := range s.records {
+		if isIOOp(r.Name) {
+			ios[r.Path] = append(ios[r.Path], r)
+		}
+	}
+	return ios
+}
+
+// RandomRatio is the ratio of random I/Os of all files
+func (s *FuseStat) RandomRatio() float64 {
+	var rnd, cnt int64
+	for _, records := range s.ioByPath() {
+		r, q := countRandomIOs(records)
+		rnd += r
+		cnt += r + q
+	}
+	if cnt == 0 {
+		return 0
+	}
+	return float64(rnd) / float64(cnt)
+}
+
 type ioOperation struct {
 	Op         string
 	LastOffset int64
@@ -475,37 +530,17 @@ func (op *ioOperation) Advance(name string, offset int64, length int64) bool {
 	return isSeq
 }
 
-func (s *FuseStat) countRandomIOs(path string) (rnd int64, seq int64, err error) {
-	rnd = 0
-	seq = 0
-	err = nil
-
-	rows, err := s.db.Query(`SELECT name, "offset", length FROM slowio_records
-         WHERE path = ? AND (name = 'fuse.Read' OR name = 'fuse.Write')
-         ORDER BY start_time_ns;`, path)
-	if err != nil {
-		return
-	}
-	defer func() { _ = rows.Close() }()
-
+// countRandomIOs counts the I/Os of a file, records are in start time order
+func countRandomIOs(records []fusestream.IORecord) (rnd int64, seq int64) {
 	ioState := ioOperation{}
 	cnt := int64(0)
 
-	for rows.Next() {
-		var name string
-		var offset int64
-		var length int32
-
-		err = rows.Scan(&name, &offset, &length)
-		if err != nil {
-			return
-		}
-
+	for _, r := range records {
 		if ioState.Empty() {
-			ioState = ioOperation{name, offset + int64(length)}
+			ioState = ioOperation{r.Name, r.Offset + int64(r.Length)}
 			// I don't think the first I/O is sequential
 		} else {
-			if ioState.Advance(name, offset, int64(length)) {
+			if ioState.Advance(r.Name, r.Offset, int64(r.Length)) {
 				seq++
 			} else {
 				rnd++
@@ -514,11 +549,6 @@ func (s *FuseStat) countRandomIOs(path string) (rnd int64, seq int64, err error)
 		}
 	}
 
-	err = rows.Err()
-	if err != nil {
-		return
-	}
-
 	assert.True(cnt == seq+rnd)
 	return
 }
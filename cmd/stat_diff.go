@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+	"github.com/urfave/cli/v3"
+
+	"github.com/zperf/fusestream/histo"
+	"github.com/zperf/fusestream/v1"
+)
+
+var statDiffCommand = &cli.Command{
+	Name:  "diff",
+	Usage: "Compare the trace of two runs",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "base",
+			Usage:    "The parquet file, directory or glob of the base run",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "target",
+			Usage:    "The parquet file, directory or glob of the target run",
+			Required: true,
+		},
+		&cli.Float64Flag{
+			Name:  "alpha",
+			Usage: "The significance level",
+			Value: 0.01,
+		},
+		&cli.Float64Flag{
+			Name:  "min-change",
+			Usage: "The minimal relative latency change of a regression",
+			Value: 0.05,
+		},
+		&cli.BoolFlag{
+			Name:  "fail-on-regression",
+			Usage: "Exit with an error if any operation regressed",
+		},
+		flagOutputFormat,
+	},
+	Action: func(ctx context.Context, command *cli.Command) error {
+		format, err := outputFormat(command)
+		if err != nil {
+			return err
+		}
+
+		base, err := readRecords(command.String("base"))
+		if err != nil {
+			return err
+		}
+
+		target, err := readRecords(command.String("target"))
+		if err != nil {
+			return err
+		}
+
+		d := NewTraceDiff(base, target, command.Float64("alpha"), command.Float64("min-change"))
+		if format != formatTable {
+			err = printOutput(os.Stdout, format, d)
+		} else {
+			d.Print(os.Stdout)
+		}
+		if err != nil {
+			return err
+		}
+
+		if command.Bool("fail-on-regression") && d.Regressed() {
+			return errors.New("regression detected")
+		}
+		return nil
+	},
+}
+
+const (
+	diffRegressed = "regressed"
+	diffImproved  = "improved"
+)
+
+// OpStat is the throughput and latency of an operation in a run, latencies are in nanoseconds
+type OpStat struct {
+	Count int     `json:"count"`
+	Rate  float64 `json:"rate"`
+	P50   int64   `json:"p50_ns"`
+	P90   int64   `json:"p90_ns"`
+	P99   int64   `json:"p99_ns"`
+	P999  int64   `json:"p99_9_ns"`
+	Max   int64   `json:"max_ns"`
+}
+
+// OpDiff compares the latency distributions of an operation with the Mann-Whitney U test
+type OpDiff struct {
+	Op     string  `json:"op"`
+	Base   OpStat  `json:"base"`
+	Target OpStat  `json:"target"`
+	PValue float64 `json:"p_value"`
+	// Status is regressed or improved if the change is significant, empty otherwise
+	Status string `json:"status,omitempty"`
+}
+
+// IOSizeStat is the I/O size distribution of a run, in bytes
+type IOSizeStat struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   int64   `json:"p50"`
+	P99   int64   `json:"p99"`
+}
+
+// IOSizeDiff compares the I/O size distributions with the two-sample Kolmogorov-Smirnov test
+type IOSizeDiff struct {
+	Base      IOSizeStat `json:"base"`
+	Target    IOSizeStat `json:"target"`
+	Statistic float64    `json:"ks_statistic"`
+	PValue    float64    `json:"p_value"`
+	Shifted   bool       `json:"shifted"`
+}
+
+type TraceDiff struct {
+	BaseRuntimeNs     int64      `json:"base_runtime_ns"`
+	TargetRuntimeNs   int64      `json:"target_runtime_ns"`
+	Ops               []OpDiff   `json:"ops"`
+	IOSize            IOSizeDiff `json:"io_size"`
+	BaseRandomRatio   float64    `json:"base_random_ratio"`
+	TargetRandomRatio float64    `json:"target_random_ratio"`
+}
+
+// NewTraceDiff compares two runs, a change is significant if its p-value is below alpha and
+// the median or p99 latency changes by more than minChange
+func NewTraceDiff(base, target []fusestream.IORecord, alpha, minChange float64) *TraceDiff {
+	d := &TraceDiff{
+		BaseRuntimeNs:     runtimeNs(base),
+		TargetRuntimeNs:   runtimeNs(target),
+		BaseRandomRatio:   NewFuseStat(base, nil).RandomRatio(),
+		TargetRandomRatio: NewFuseStat(target, nil).RandomRatio(),
+	}
+
+	baseElapsed := elapsedByOp(base)
+	targetElapsed := elapsedByOp(target)
+	ops := make(map[string]bool)
+	for op := range baseElapsed {
+		ops[op] = true
+	}
+	for op := range targetElapsed {
+		ops[op] = true
+	}
+
+	for op := range ops {
+		od := OpDiff{
+			Op:     op,
+			Base:   newOpStat(baseElapsed[op], d.BaseRuntimeNs),
+			Target: newOpStat(targetElapsed[op], d.TargetRuntimeNs),
+			PValue: mannWhitneyU(baseElapsed[op], targetElapsed[op]),
+		}
+		if od.PValue < alpha && od.Base.Count > 0 && od.Target.Count > 0 {
+			if changed(od.Base.P50, od.Target.P50, minChange) > 0 || changed(od.Base.P99, od.Target.P99, minChange) > 0 {
+				od.Status = diffRegressed
+			} else if changed(od.Base.P50, od.Target.P50, minChange) < 0 {
+				od.Status = diffImproved
+			}
+		}
+		d.Ops = append(d.Ops, od)
+	}
+	slices.SortFunc(d.Ops, func(a, b OpDiff) int { return cmp.Compare(a.Op, b.Op) })
+
+	baseSizes := ioSizes(base)
+	targetSizes := ioSizes(target)
+	d.IOSize = IOSizeDiff{
+		Base:   newIOSizeStat(baseSizes),
+		Target: newIOSizeStat(targetSizes),
+	}
+	d.IOSize.Statistic, d.IOSize.PValue = ksTest(baseSizes, targetSizes)
+	d.IOSize.Shifted = d.IOSize.PValue < alpha
+
+	return d
+}
+
+func (d *TraceDiff) Regressed() bool {
+	for _, op := range d.Ops {
+		if op.Status == diffRegressed {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *TraceDiff) Print(w io.Writer) {
+	tbl := table.New("Op", "Count", "Rate(/s)", "p50", "p99", "p99.9", "p-value", "Status").WithWriter(w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	for _, op := range d.Ops {
+		status := op.Status
+		switch status {
+		case diffRegressed:
+			status = color.RedString(status)
+		case diffImproved:
+			status = color.GreenString(status)
+		}
+
+		tbl.AddRow(op.Op,
+			fmt.Sprintf("%d → %d", op.Base.Count, op.Target.Count),
+			diffCell(op.Base.Rate, op.Target.Rate, "%.1f"),
+			diffMs(op.Base.P50, op.Target.P50),
+			diffMs(op.Base.P99, op.Target.P99),
+			diffMs(op.Base.P999, op.Target.P999),
+			fmt.Sprintf("%.3g", op.PValue),
+			status,
+		)
+	}
+	tbl.Print()
+	_, _ = fmt.Fprintln(w)
+
+	shifted := ""
+	if d.IOSize.Shifted {
+		shifted = color.YellowString("shifted")
+	}
+	tbl = table.New("Property", "Base", "Target", "Change").WithWriter(w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	tbl.AddRow("Runtime",
+		fmt.Sprintf("%.3fs", float64(d.BaseRuntimeNs)/1e9),
+		fmt.Sprintf("%.3fs", float64(d.TargetRuntimeNs)/1e9),
+		relChange(float64(d.BaseRuntimeNs), float64(d.TargetRuntimeNs)))
+	tbl.AddRow("Mean I/O size",
+		humanize.Bytes(uint64(d.IOSize.Base.Mean)),
+		humanize.Bytes(uint64(d.IOSize.Target.Mean)),
+		relChange(d.IOSize.Base.Mean, d.IOSize.Target.Mean))
+	tbl.AddRow("p50 I/O size",
+		humanize.Bytes(uint64(d.IOSize.Base.P50)),
+		humanize.Bytes(uint64(d.IOSize.Target.P50)),
+		relChange(float64(d.IOSize.Base.P50), float64(d.IOSize.Target.P50)))
+	tbl.AddRow("I/O size KS test",
+		"", "", fmt.Sprintf("D=%.3f p=%.3g %s", d.IOSize.Statistic, d.IOSize.PValue, shifted))
+	tbl.AddRow("Random(%)",
+		fmt.Sprintf("%.3f", d.BaseRandomRatio*100),
+		fmt.Sprintf("%.3f", d.TargetRandomRatio*100),
+		fmt.Sprintf("%+.3f", (d.TargetRandomRatio-d.BaseRandomRatio)*100))
+	tbl.Print()
+}
+
+// changed returns 1 if target is larger than base by more than minChange, -1 if smaller, 0 otherwise
+func changed(base, target int64, minChange float64) int {
+	if base == 0 {
+		return 0
+	}
+	delta := float64(target-base) / float64(base)
+	if delta > minChange {
+		return 1
+	} else if delta < -minChange {
+		return -1
+	}
+	return 0
+}
+
+func relChange(base, target float64) string {
+	if base == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", (target-base)/base*100)
+}
+
+func diffCell(base, target float64, format string) string {
+	return fmt.Sprintf(format+" → "+format+" (%s)", base, target, relChange(base, target))
+}
+
+func diffMs(base, target int64) string {
+	return fmt.Sprintf("%s → %s (%s)", formatMs(base), formatMs(target), relChange(float64(base), float64(target)))
+}
+
+func runtimeNs(records []fusestream.IORecord) int64 {
+	if len(records) == 0 {
+		return 0
+	}
+	first, last := records[0], records[len(records)-1]
+	return last.StartTimeNs - first.StartTimeNs + last.ElapsedNs
+}
+
+func elapsedByOp(records []fusestream.IORecord) map[string][]int64 {
+	elapsed := make(map[string][]int64)
+	for _, r := range records {
+		elapsed[r.Name] = append(elapsed[r.Name], r.ElapsedNs)
+	}
+	return elapsed
+}
+
+func ioSizes(records []fusestream.IORecord) []int64 {
+	sizes := make([]int64, 0)
+	for _, r := range records {
+		if isIOOp(r.Name) {
+			sizes = append(sizes, int64(r.Length))
+		}
+	}
+	return sizes
+}
+
+func newOpStat(elapsed []int64, runtimeNs int64) OpStat {
+	if len(elapsed) == 0 {
+		return OpStat{}
+	}
+
+	sorted := slices.Clone(elapsed)
+	p := histo.Percentiles(sorted, latencyPercentiles...)
+	stat := OpStat{
+		Count: len(elapsed),
+		P50:   p[0],
+		P90:   p[1],
+		P99:   p[2],
+		P999:  p[3],
+		Max:   sorted[len(sorted)-1],
+	}
+	if runtimeNs > 0 {
+		stat.Rate = float64(len(elapsed)) / (float64(runtimeNs) / 1e9)
+	}
+	return stat
+}
+
+func newIOSizeStat(sizes []int64) IOSizeStat {
+	if len(sizes) == 0 {
+		return IOSizeStat{}
+	}
+
+	sorted := slices.Clone(sizes)
+	p := histo.Percentiles(sorted, 50, 99)
+	var sum int64
+	for _, v := range sorted {
+		sum += v
+	}
+	return IOSizeStat{
+		Count: len(sizes),
+		Mean:  float64(sum) / float64(len(sizes)),
+		P50:   p[0],
+		P99:   p[1],
+	}
+}
+
+// mannWhitneyU returns the two-sided p-value of the Mann-Whitney U test,
+// with the normal approximation and the tie correction
+func mannWhitneyU(a, b []int64) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type sample struct {
+		v     int64
+		fromA bool
+	}
+	all := make([]sample, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, sample{v, true})
+	}
+	for _, v := range b {
+		all = append(all, sample{v, false})
+	}
+	slices.SortFunc(all, func(x, y sample) int { return cmp.Compare(x.v, y.v) })
+
+	// ties get the average rank
+	var rankSumA, ties float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromA {
+				rankSumA += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	fn1, fn2 := float64(n1), float64(n2)
+	n := fn1 + fn2
+	u := rankSumA - fn1*(fn1+1)/2
+	mu := fn1 * fn2 / 2
+	sigma := math.Sqrt(fn1 * fn2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+
+	// continuity correction
+	z := max(math.Abs(u-mu)-0.5, 0) / sigma
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// ksTest returns the two-sample Kolmogorov-Smirnov statistic and its asymptotic p-value
+func ksTest(a, b []int64) (d float64, p float64) {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+
+	x := slices.Clone(a)
+	y := slices.Clone(b)
+	slices.Sort(x)
+	slices.Sort(y)
+
+	i, j := 0, 0
+	for i < n1 && j < n2 {
+		v := min(x[i], y[j])
+		for i < n1 && x[i] == v {
+			i++
+		}
+		for j < n2 && y[j] == v {
+			j++
+		}
+		d = max(d, math.Abs(float64(i)/float64(n1)-float64(j)/float64(n2)))
+	}
+
+	en := math.Sqrt(float64(n1) * float64(n2) / float64(n1+n2))
+	lambda := (en + 0.12 + 0.11/en) * d
+	return d, kolmogorovQ(lambda)
+}
+
+// kolmogorovQ is the complementary CDF of the Kolmogorov distribution
+func kolmogorovQ(lambda float64) float64 {
+	if lambda < 1e-3 {
+		return 1
+	}
+
+	sum := 0.0
+	sign := 1.0
+	for k := 1; k <= 100; k++ {
+		term := sign * 2 * math.Exp(-2*float64(k*k)*lambda*lambda)
+		sum += term
+		if math.Abs(term) < 1e-10 {
+			break
+		}
+		sign = -sign
+	}
+	return min(max(sum, 0), 1)
+}
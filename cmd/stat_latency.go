@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rodaine/table"
+
+	"github.com/zperf/fusestream/histo"
+	"github.com/zperf/fusestream/v1"
+)
+
+// latencyPercentiles are reported per group, in percent
+var latencyPercentiles = []float64{50, 90, 99, 99.9}
+
+// LatencyRow is the latency percentiles of a group in a time window, in nanoseconds
+type LatencyRow struct {
+	// Window is the start of the time window, zero if not split into windows
+	Window time.Time `json:"window,omitzero"`
+	Group  string    `json:"group"`
+	Count  int       `json:"count"`
+	P50    int64     `json:"p50_ns"`
+	P90    int64     `json:"p90_ns"`
+	P99    int64     `json:"p99_ns"`
+	P999   int64     `json:"p99_9_ns"`
+	Max    int64     `json:"max_ns"`
+}
+
+// pathPrefix keeps the first depth components of path
+func pathPrefix(path string, depth int) string {
+	rooted := strings.HasPrefix(path, "/")
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+
+	prefix := strings.Join(parts, "/")
+	if rooted {
+		prefix = "/" + prefix
+	}
+	return prefix
+}
+
+func (s *FuseStat) LatencyByOp() []LatencyRow {
+	return s.latencyBy(func(r *fusestream.IORecord) string { return r.Name })
+}
+
+// LatencyByPath groups I/Os by path prefix, nil if PathDepth is 0
+func (s *FuseStat) LatencyByPath() []LatencyRow {
+	if s.PathDepth <= 0 {
+		return nil
+	}
+	return s.latencyBy(func(r *fusestream.IORecord) string {
+		if !isIOOp(r.Name) {
+			return ""
+		}
+		return pathPrefix(r.Path, s.PathDepth)
+	})
+}
+
+// latencyBy groups records by key and Window, records with an empty key are skipped
+func (s *FuseStat) latencyBy(key func(r *fusestream.IORecord) string) []LatencyRow {
+	type groupKey struct {
+		window int64
+		group  string
+	}
+
+	groups := make(map[groupKey][]int64)
+	for i := range s.records {
+		r := &s.records[i]
+		k := groupKey{group: key(r)}
+		if k.group == "" {
+			continue
+		}
+		if s.Window > 0 {
+			k.window = (r.StartTimeNs - s.records[0].StartTimeNs) / s.Window.Nanoseconds()
+		}
+		groups[k] = append(groups[k], r.ElapsedNs)
+	}
+
+	rows := make([]LatencyRow, 0, len(groups))
+	for k, elapsed := range groups {
+		p := histo.Percentiles(elapsed, latencyPercentiles...)
+		row := LatencyRow{
+			Group: k.group,
+			Count: len(elapsed),
+			P50:   p[0],
+			P90:   p[1],
+			P99:   p[2],
+			P999:  p[3],
+			Max:   elapsed[len(elapsed)-1],
+		}
+		if s.Window > 0 {
+			row.Window = time.Unix(0, s.records[0].StartTimeNs+k.window*s.Window.Nanoseconds())
+		}
+		rows = append(rows, row)
+	}
+
+	slices.SortFunc(rows, func(a, b LatencyRow) int {
+		return cmp.Or(a.Window.Compare(b.Window), cmp.Compare(a.Group, b.Group))
+	})
+	return rows
+}
+
+func (s *FuseStat) printLatency(name string, rows []LatencyRow) {
+	header := []any{name, "Count", "p50", "p90", "p99", "p99.9", "Max"}
+	if s.Window > 0 {
+		header = append([]any{"Window"}, header...)
+	}
+
+	tbl := table.New(header...).WithWriter(s.w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	for _, r := range rows {
+		row := []any{r.Group, r.Count, formatMs(r.P50), formatMs(r.P90), formatMs(r.P99), formatMs(r.P999), formatMs(r.Max)}
+		if s.Window > 0 {
+			row = append([]any{r.Window.Local().Format(time.TimeOnly)}, row...)
+		}
+		tbl.AddRow(row...)
+	}
+	tbl.Print()
+}
+
+func (s *FuseStat) PrintLatencyByOp() error {
+	s.printLatency("Op", s.LatencyByOp())
+	return nil
+}
+
+func (s *FuseStat) PrintLatencyByPath() error {
+	s.printLatency("Path", s.LatencyByPath())
+	return nil
+}
+
+func formatMs(ns int64) string {
+	return fmt.Sprintf("%.3fms", float64(ns)/1000/1000)
+}
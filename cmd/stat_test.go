@@ -1,6 +1,18 @@
 package cmd
 
-import "testing"
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zperf/fusestream/histo"
+	"github.com/zperf/fusestream/v1"
+)
 
 func TestStat(t *testing.T) {
 	rnd := int64(0)
@@ -48,3 +60,178 @@ func TestStat(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestParquetSegments(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"fs-20250102T150405.000002Z.parquet",
+		"fs-20250102T150405.000001Z.parquet",
+		"fs-20250102T150405.000003Z.parquet.inprogress",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	expected := []string{
+		filepath.Join(dir, "fs-20250102T150405.000001Z.parquet"),
+		filepath.Join(dir, "fs-20250102T150405.000002Z.parquet"),
+	}
+
+	files, err := parquetSegments(dir)
+	require.NoError(t, err)
+	require.Equal(t, expected, files)
+
+	files, err = parquetSegments(filepath.Join(dir, "fs-*.parquet"))
+	require.NoError(t, err)
+	require.Equal(t, expected, files)
+
+	files, err = parquetSegments(expected[0])
+	require.NoError(t, err)
+	require.Equal(t, expected[:1], files)
+
+	_, err = parquetSegments(filepath.Join(dir, "*.csv"))
+	require.Error(t, err)
+}
+
+func TestFuseStat(t *testing.T) {
+	records := []fusestream.IORecord{
+		{Name: "nbd.ReadAt", StartTimeNs: 0, ElapsedNs: 1000, Offset: 0, Length: 4096, Path: "export"},
+		{Name: "nbd.ReadAt", StartTimeNs: 1000, ElapsedNs: 1000, Offset: 4096, Length: 4096, Path: "export"},
+		{Name: "nbd.WriteAt", StartTimeNs: 2000, ElapsedNs: 1000, Offset: 0, Length: 4096, Path: "export"},
+		{Name: "nbd.Sync", StartTimeNs: 3000, ElapsedNs: 1000, Path: "export", Errc: -5},
+	}
+
+	rnd, seq := countRandomIOs(records[:3])
+	require.Equal(t, int64(1), rnd)
+	require.Equal(t, int64(1), seq)
+
+	var buf bytes.Buffer
+	s := NewFuseStat(records, &buf)
+	require.NoError(t, s.PrintSummary())
+	require.Contains(t, buf.String(), "R/W ratio    2.000")
+	require.Contains(t, buf.String(), "Syncs        1")
+	require.Contains(t, buf.String(), "Error rate   25.000%")
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	records := make([]fusestream.IORecord, 0)
+	for i := int64(1); i <= 1000; i++ {
+		records = append(records,
+			fusestream.IORecord{Name: "fuse.Read", StartTimeNs: i * 1000, ElapsedNs: i, Path: "/data/a"},
+			fusestream.IORecord{Name: "fuse.Fsync", StartTimeNs: i * 1000, ElapsedNs: 2 * i, Path: "/wal/b"},
+		)
+	}
+
+	s := NewFuseStat(records, io.Discard)
+	s.PathDepth = 1
+
+	byOp := s.LatencyByOp()
+	require.Len(t, byOp, 2)
+	require.Equal(t, LatencyRow{Group: "fuse.Fsync", Count: 1000, P50: 1000, P90: 1800, P99: 1980, P999: 1998, Max: 2000}, byOp[0])
+	require.Equal(t, LatencyRow{Group: "fuse.Read", Count: 1000, P50: 500, P90: 900, P99: 990, P999: 999, Max: 1000}, byOp[1])
+
+	// fsync isn't an I/O
+	byPath := s.LatencyByPath()
+	require.Len(t, byPath, 1)
+	require.Equal(t, "/data", byPath[0].Group)
+
+	s.Window = 500 * time.Microsecond
+	byOp = s.LatencyByOp()
+	require.Len(t, byOp, 4)
+	require.Equal(t, time.Unix(0, 1000), byOp[0].Window)
+	require.Equal(t, "fuse.Fsync", byOp[0].Group)
+	require.Equal(t, 500, byOp[0].Count)
+	require.Equal(t, int64(500), byOp[1].Max)
+	require.Equal(t, time.Unix(0, 501000), byOp[3].Window)
+	require.Equal(t, int64(750), byOp[3].P50)
+}
+
+func TestPathPrefix(t *testing.T) {
+	require.Equal(t, "/a", pathPrefix("/a/b/c", 1))
+	require.Equal(t, "/a/b", pathPrefix("/a/b/c", 2))
+	require.Equal(t, "/a/b/c", pathPrefix("/a/b/c", 5))
+	require.Equal(t, "export", pathPrefix("export", 1))
+}
+
+func TestTraceDiff(t *testing.T) {
+	newRun := func(readNs int64, size int32) []fusestream.IORecord {
+		records := make([]fusestream.IORecord, 0)
+		for i := int64(0); i < 200; i++ {
+			records = append(records,
+				fusestream.IORecord{Name: "fuse.Read", StartTimeNs: i * 1000, ElapsedNs: readNs + i%10, Length: size},
+				fusestream.IORecord{Name: "fuse.Fsync", StartTimeNs: i * 1000, ElapsedNs: 500 + i%10},
+			)
+		}
+		return records
+	}
+
+	base := newRun(100, 4096)
+	d := NewTraceDiff(base, newRun(100, 4096), 0.01, 0.05)
+	require.False(t, d.Regressed())
+	require.False(t, d.IOSize.Shifted)
+	for _, op := range d.Ops {
+		require.Empty(t, op.Status)
+	}
+
+	d = NewTraceDiff(base, newRun(200, 8192), 0.01, 0.05)
+	require.True(t, d.Regressed())
+	require.True(t, d.IOSize.Shifted)
+	require.Len(t, d.Ops, 2)
+	require.Equal(t, "fuse.Fsync", d.Ops[0].Op)
+	require.Empty(t, d.Ops[0].Status)
+	require.Equal(t, "fuse.Read", d.Ops[1].Op)
+	require.Equal(t, diffRegressed, d.Ops[1].Status)
+	require.Less(t, d.Ops[1].PValue, 0.01)
+
+	d = NewTraceDiff(newRun(200, 4096), base, 0.01, 0.05)
+	require.False(t, d.Regressed())
+	require.Equal(t, diffImproved, d.Ops[1].Status)
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	a := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	require.InDelta(t, 1, mannWhitneyU(a, a), 1e-9)
+	require.Less(t, mannWhitneyU(a, []int64{11, 12, 13, 14, 15, 16, 17, 18, 19, 20}), 0.001)
+	require.Equal(t, 1.0, mannWhitneyU(a, nil))
+
+	d, p := ksTest(a, a)
+	require.Zero(t, d)
+	require.InDelta(t, 1, p, 1e-9)
+	d, _ = ksTest(a, []int64{11, 12})
+	require.Equal(t, 1.0, d)
+}
+
+func TestTimeline(t *testing.T) {
+	faultID := int32(1)
+	second := time.Second.Nanoseconds()
+	records := []fusestream.IORecord{
+		{Name: "fuse.Write", StartTimeNs: 0, ElapsedNs: 1000, Length: 4096},
+		{Name: "fuse.Write", StartTimeNs: second / 2, ElapsedNs: 3000, Length: 4096},
+		{Name: "fuse.Fsync", StartTimeNs: 2 * second, ElapsedNs: 5000, Errc: -5, FaultID: &faultID},
+	}
+
+	buckets, err := NewTimeline(records, time.Second)
+	require.NoError(t, err)
+	require.Len(t, buckets, 3)
+	require.Equal(t, TimelineBucket{Start: time.Unix(0, 0), Ops: 2, IOPS: 2, Bandwidth: 8192, P99: 3000}, buckets[0])
+	require.Equal(t, TimelineBucket{Start: time.Unix(1, 0)}, buckets[1])
+	require.Equal(t, TimelineBucket{Start: time.Unix(2, 0), Ops: 1, P99: 5000, Errors: 1, Faults: 1}, buckets[2])
+
+	var buf bytes.Buffer
+	require.NoError(t, writeTimelineCSV(&buf, buckets))
+	require.Equal(t, `start_time_ns,ops,iops,bandwidth,p99_ns,errors,faults
+0,2,2.000,8192.000,3000,0,0
+1000000000,0,0.000,0.000,0,0,0
+2000000000,1,0.000,0.000,5000,1,1
+`, buf.String())
+
+	require.Equal(t, "▁▅█", histo.Sparkline([]float64{0, 3000, 5000}, 80))
+	require.Equal(t, "▁█", histo.Sparkline([]float64{0, 0, 1, 0}, 2))
+
+	// a long trace in short intervals is rejected instead of allocating a bucket per interval
+	records[2].StartTimeNs = 24 * time.Hour.Nanoseconds()
+	_, err = NewTimeline(records, time.Millisecond)
+	require.ErrorContains(t, err, "use a larger --interval")
+	buckets, err = NewTimeline(records, time.Second)
+	require.NoError(t, err)
+	require.Len(t, buckets, 24*60*60+1)
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/rodaine/table"
+	"github.com/urfave/cli/v3"
+
+	"github.com/zperf/fusestream/histo"
+	"github.com/zperf/fusestream/v1"
+)
+
+var statTimelineCommand = &cli.Command{
+	Name:  "timeline",
+	Usage: "Show throughput and latency over time, --output csv also writes the buckets as CSV",
+	Flags: []cli.Flag{
+		flagStatInput,
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "The bucket interval",
+			Value: time.Second,
+		},
+		&cli.StringSliceFlag{
+			Name:  "op",
+			Usage: "Only count the operations, e.g. fuse.Write, all operations if not set",
+		},
+		flagOutputFormat,
+	},
+	Action: func(ctx context.Context, command *cli.Command) error {
+		format, err := outputFormat(command, formatCSV)
+		if err != nil {
+			return err
+		}
+
+		interval := command.Duration("interval")
+		if interval <= 0 {
+			return errors.New("interval must be positive")
+		}
+
+		records, err := loadRecords(command)
+		if err != nil {
+			return err
+		}
+
+		if ops := command.StringSlice("op"); len(ops) > 0 {
+			records = slices.DeleteFunc(records, func(r fusestream.IORecord) bool {
+				return !slices.Contains(ops, r.Name)
+			})
+		}
+		if len(records) == 0 {
+			return errors.New("no records")
+		}
+
+		buckets, err := NewTimeline(records, interval)
+		if err != nil {
+			return err
+		}
+		switch format {
+		case formatJSON, formatYAML:
+			return printOutput(os.Stdout, format, buckets)
+		case formatCSV:
+			return writeTimelineCSV(os.Stdout, buckets)
+		default:
+			printTimeline(os.Stdout, buckets)
+			return nil
+		}
+	},
+}
+
+// TimelineBucket is the throughput and latency of an interval
+type TimelineBucket struct {
+	Start     time.Time `json:"start"`
+	Ops       int64     `json:"ops"`
+	IOPS      float64   `json:"iops"`
+	Bandwidth float64   `json:"bandwidth"`
+	P99       int64     `json:"p99_ns"`
+	Errors    int64     `json:"errors"`
+	// Faults is the number of operations a fault is injected to
+	Faults int64 `json:"faults"`
+}
+
+// maxTimelineBuckets bounds the buckets of a timeline, a day in 1s intervals fits
+const maxTimelineBuckets = 100_000
+
+// NewTimeline buckets the records by interval from the first record, records are in start time order.
+// Empty intervals are kept so that the buckets are continuous, so it fails if the records span more than
+// maxTimelineBuckets intervals
+func NewTimeline(records []fusestream.IORecord, interval time.Duration) ([]TimelineBucket, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	start := records[0].StartTimeNs
+	span := records[len(records)-1].StartTimeNs - start
+	n := span/interval.Nanoseconds() + 1
+	if n > maxTimelineBuckets {
+		return nil, fmt.Errorf("records span %v, more than %d intervals of %v, use a larger --interval",
+			time.Duration(span), maxTimelineBuckets, interval)
+	}
+	buckets := make([]TimelineBucket, n)
+	elapsed := make([][]int64, n)
+	seconds := interval.Seconds()
+
+	for i := range buckets {
+		buckets[i].Start = time.Unix(0, start+int64(i)*interval.Nanoseconds())
+	}
+
+	for _, r := range records {
+		i := (r.StartTimeNs - start) / interval.Nanoseconds()
+		b := &buckets[i]
+		b.Ops++
+		if isIOOp(r.Name) {
+			b.IOPS += 1 / seconds
+			b.Bandwidth += float64(r.Length) / seconds
+		}
+		if r.Errc < 0 {
+			b.Errors++
+		}
+		if r.FaultID != nil {
+			b.Faults++
+		}
+		elapsed[i] = append(elapsed[i], r.ElapsedNs)
+	}
+
+	for i := range buckets {
+		buckets[i].P99 = histo.Percentiles(elapsed[i], 99)[0]
+	}
+	return buckets, nil
+}
+
+func printTimeline(w io.Writer, buckets []TimelineBucket) {
+	iops := make([]float64, len(buckets))
+	bandwidth := make([]float64, len(buckets))
+	p99 := make([]float64, len(buckets))
+	for i, b := range buckets {
+		iops[i] = b.IOPS
+		bandwidth[i] = b.Bandwidth
+		p99[i] = float64(b.P99)
+	}
+
+	_, _ = fmt.Fprintf(w, "%s - %s, %d buckets\n",
+		buckets[0].Start.Local().Format(time.DateTime),
+		buckets[len(buckets)-1].Start.Local().Format(time.DateTime), len(buckets))
+
+	tbl := table.New("Series", "Max", "Timeline").WithWriter(w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	tbl.AddRow("IOPS", fmt.Sprintf("%.1f", slices.Max(iops)), histo.Sparkline(iops, histo.MaxWidth))
+	tbl.AddRow("Bandwidth", humanize.Bytes(uint64(slices.Max(bandwidth)))+"/s",
+		histo.Sparkline(bandwidth, histo.MaxWidth))
+	tbl.AddRow("p99", formatMs(int64(slices.Max(p99))), histo.Sparkline(p99, histo.MaxWidth))
+	tbl.Print()
+	_, _ = fmt.Fprintln(w)
+
+	tbl = table.New("Time", "Ops", "IOPS", "Bandwidth", "p99", "Errors", "Faults").WithWriter(w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	for _, b := range buckets {
+		tbl.AddRow(b.Start.Local().Format("15:04:05.000"), b.Ops, fmt.Sprintf("%.1f", b.IOPS),
+			humanize.Bytes(uint64(b.Bandwidth))+"/s", formatMs(b.P99), b.Errors, b.Faults)
+	}
+	tbl.Print()
+}
+
+func writeTimelineCSV(w io.Writer, buckets []TimelineBucket) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"start_time_ns", "ops", "iops", "bandwidth", "p99_ns", "errors", "faults"})
+	if err != nil {
+		return err
+	}
+
+	for _, b := range buckets {
+		err = cw.Write([]string{
+			strconv.FormatInt(b.Start.UnixNano(), 10),
+			strconv.FormatInt(b.Ops, 10),
+			strconv.FormatFloat(b.IOPS, 'f', 3, 64),
+			strconv.FormatFloat(b.Bandwidth, 'f', 3, 64),
+			strconv.FormatInt(b.P99, 10),
+			strconv.FormatInt(b.Errors, 10),
+			strconv.FormatInt(b.Faults, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rodaine/table"
+	"github.com/urfave/cli/v3"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+const (
+	// statusPollInterval is the interval of the health checks of status --wait
+	statusPollInterval = 100 * time.Millisecond
+	// statusCheckTimeout bounds a health check past the wait, e.g. the only check without --wait, and GetServerInfo
+	statusCheckTimeout = time.Second
+)
+
+var statusCommand = &cli.Command{
+	Name:  "status",
+	Usage: "Show the server info, exit with an error unless the mount or the export is live",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		&cli.DurationFlag{
+			Name:  "wait",
+			Usage: "Wait up to the duration for the server to be live",
+		},
+		flagOutputFormat,
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		format, err := outputFormat(command)
+		if err != nil {
+			return err
+		}
+
+		conn, err := dialRPC(command)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close() }()
+
+		liveErr := waitLive(ctx, healthpb.NewHealthClient(conn), command.Duration("wait"))
+		infoCtx, cancel := context.WithTimeout(ctx, statusCheckTimeout)
+		defer cancel()
+		info, err := pb.NewFuseStreamClient(conn).GetServerInfo(infoCtx, &pb.Void{})
+		if err != nil {
+			return errors.Join(liveErr, err)
+		}
+
+		view := NewStatusView(info)
+		if format != formatTable {
+			err = printOutput(os.Stdout, format, view)
+		} else {
+			view.Print(os.Stdout)
+		}
+		return errors.Join(err, liveErr)
+	},
+}
+
+// waitLive checks the health service until the server is SERVING, for up to wait. A check is bounded by the wait, or
+// by statusCheckTimeout if less of the wait is left, so a server that accepts but never answers doesn't block it.
+// Checks wait for the connection while it's down instead of failing fast, so a server starting up is seen as soon as
+// it's connected
+func waitLive(ctx context.Context, client healthpb.HealthClient, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	for {
+		checkDeadline := deadline
+		if time.Until(deadline) < statusCheckTimeout {
+			checkDeadline = time.Now().Add(statusCheckTimeout)
+		}
+		checkCtx, cancel := context.WithDeadline(ctx, checkDeadline)
+		rsp, err := client.Check(checkCtx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true))
+		cancel()
+		if err == nil && rsp.Status == healthpb.HealthCheckResponse_SERVING {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("server is %s", rsp.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(statusPollInterval):
+		}
+	}
+}
+
+// StatusView is the server info, Mode is fuse, nbd or daemon
+type StatusView struct {
+	Version     string             `json:"version"`
+	Mode        string             `json:"mode"`
+	BaseDir     string             `json:"base_dir,omitempty"`
+	Mountpoint  string             `json:"mountpoint,omitempty"`
+	BackendFile string             `json:"backend_file,omitempty"`
+	Export      string             `json:"export,omitempty"`
+	Pid         int32              `json:"pid"`
+	UptimeNs    int64              `json:"uptime_ns"`
+	Live        bool               `json:"live"`
+	Exporter    StatusExporterView `json:"exporter"`
+}
+
+type StatusExporterView struct {
+	ExportPath    string  `json:"export_path,omitempty"`
+	OTLPEndpoint  string  `json:"otlp_endpoint,omitempty"`
+	OTLPProtocol  string  `json:"otlp_protocol,omitempty"`
+	SampleRatio   float64 `json:"sample_ratio"`
+	MetricsListen string  `json:"metrics_listen,omitempty"`
+}
+
+func NewStatusView(info *pb.ServerInfo) *StatusView {
+	v := &StatusView{
+		Version:     info.Version,
+		Mode:        strings.ToLower(strings.TrimPrefix(info.Mode.String(), "SERVER_MODE_")),
+		BaseDir:     info.BaseDir,
+		Mountpoint:  info.Mountpoint,
+		BackendFile: info.BackendFile,
+		Export:      info.Export,
+		Pid:         info.Pid,
+		UptimeNs:    info.UptimeNs,
+		Live:        info.Live,
+	}
+	if e := info.Exporter; e != nil {
+		v.Exporter = StatusExporterView{
+			ExportPath:    e.ExportPath,
+			OTLPEndpoint:  e.OtlpEndpoint,
+			OTLPProtocol:  e.OtlpProtocol,
+			SampleRatio:   e.SampleRatio,
+			MetricsListen: e.MetricsListen,
+		}
+	}
+	return v
+}
+
+func (v *StatusView) Print(w io.Writer) {
+	rows := [][2]any{
+		{"Version", v.Version},
+		{"Mode", v.Mode},
+		{"Base dir", v.BaseDir},
+		{"Mountpoint", v.Mountpoint},
+		{"Backend file", v.BackendFile},
+		{"Export", v.Export},
+		{"Export path", v.Exporter.ExportPath},
+		{"OTLP endpoint", v.Exporter.OTLPEndpoint},
+	}
+	if v.Exporter.OTLPEndpoint != "" {
+		rows = append(rows, [2]any{"OTLP protocol", v.Exporter.OTLPProtocol})
+	}
+	if v.Exporter.ExportPath != "" || v.Exporter.OTLPEndpoint != "" {
+		rows = append(rows, [2]any{"Sample ratio", v.Exporter.SampleRatio})
+	}
+	rows = append(rows,
+		[2]any{"Metrics listen", v.Exporter.MetricsListen},
+		[2]any{"Pid", v.Pid},
+		[2]any{"Uptime", time.Duration(v.UptimeNs).Round(time.Second)},
+		[2]any{"Live", v.Live},
+	)
+
+	tbl := table.New("Field", "Value").WithWriter(w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	for _, row := range rows {
+		if row[1] != "" {
+			tbl.AddRow(row[0], row[1])
+		}
+	}
+	tbl.Print()
+}
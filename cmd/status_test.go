@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+func TestStatusView(t *testing.T) {
+	view := NewStatusView(&pb.ServerInfo{
+		Version:     "v1.0.0",
+		Mode:        pb.ServerMode_SERVER_MODE_NBD,
+		BackendFile: "/data/disk.img",
+		Export:      "disk",
+		UptimeNs:    int64(90 * time.Second),
+		Live:        true,
+		Pid:         42,
+		Exporter:    &pb.ExporterInfo{OtlpEndpoint: "http://localhost:4317", OtlpProtocol: "grpc", SampleRatio: 0.5},
+	})
+	require.Equal(t, "nbd", view.Mode)
+	require.Equal(t, "http://localhost:4317", view.Exporter.OTLPEndpoint)
+
+	var buf bytes.Buffer
+	view.Print(&buf)
+	out := buf.String()
+	require.Contains(t, out, "/data/disk.img")
+	require.Contains(t, out, "1m30s")
+	require.Contains(t, out, "OTLP protocol")
+	require.NotContains(t, out, "Mountpoint")
+}
+
+// hangingHealthClient answers the checks only when their context is done, like a server that never responds
+type hangingHealthClient struct {
+	healthpb.HealthClient
+}
+
+func (hangingHealthClient) Check(
+	ctx context.Context, _ *healthpb.HealthCheckRequest, _ ...grpc.CallOption,
+) (*healthpb.HealthCheckResponse, error) {
+	<-ctx.Done()
+	return nil, status.FromContextError(ctx.Err()).Err()
+}
+
+func TestWaitLiveTimeout(t *testing.T) {
+	start := time.Now()
+	err := waitLive(context.Background(), hangingHealthClient{}, 0)
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	require.Less(t, time.Since(start), statusCheckTimeout+time.Second)
+
+	start = time.Now()
+	err = waitLive(context.Background(), hangingHealthClient{}, 2*statusCheckTimeout)
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	require.Less(t, time.Since(start), 3*statusCheckTimeout+time.Second)
+}
+
+func TestWaitLiveStarting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	// the server starts listening after the first check failed to connect
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	defer server.Stop()
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		listener, err := net.Listen("tcp", address)
+		if err == nil {
+			_ = server.Serve(listener)
+		}
+	}()
+
+	require.NoError(t, waitLive(context.Background(), healthpb.NewHealthClient(conn), 10*time.Second))
+}
@@ -3,10 +3,14 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"regexp"
+	"time"
 
 	"github.com/d5/tengo/v2"
 	"github.com/urfave/cli/v3"
+
+	"github.com/zperf/fusestream/v1"
 )
 
 var toolCommand = &cli.Command{
@@ -15,6 +19,7 @@ var toolCommand = &cli.Command{
 	Commands: []*cli.Command{
 		regexCommand,
 		evalNbdPreCond,
+		genCertsCommand,
 	},
 }
 
@@ -87,3 +92,41 @@ var evalNbdPreCond = &cli.Command{
 		return nil
 	},
 }
+
+var genCertsCommand = &cli.Command{
+	Name:  "gen-certs",
+	Usage: "Generate a CA, server and client certificates for testing TLS and mTLS",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "dir",
+			Usage: "The output directory",
+			Value: "certs",
+		},
+		&cli.StringSliceFlag{
+			Name:  "host",
+			Usage: "IP addresses and DNS names of the server certificate",
+			Value: []string{"127.0.0.1", "localhost"},
+		},
+		&cli.DurationFlag{
+			Name:  "valid-for",
+			Usage: "The validity of the certificates",
+			Value: 365 * 24 * time.Hour,
+		},
+	},
+	Action: func(ctx context.Context, command *cli.Command) error {
+		dir := command.String("dir")
+		err := fusestream.GenerateCerts(dir, command.StringSlice("host"), command.Duration("valid-for"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Certificates written to %s\n", dir)
+		fmt.Printf("Server: --tls-cert %s --tls-key %s --tls-ca %s\n",
+			filepath.Join(dir, fusestream.ServerCertFile), filepath.Join(dir, fusestream.ServerKeyFile),
+			filepath.Join(dir, fusestream.CACertFile))
+		fmt.Printf("Client: --tls-cert %s --tls-key %s --tls-ca %s\n",
+			filepath.Join(dir, fusestream.ClientCertFile), filepath.Join(dir, fusestream.ClientKeyFile),
+			filepath.Join(dir, fusestream.CACertFile))
+		return nil
+	},
+}
@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/mattn/go-isatty"
+	"github.com/rodaine/table"
+	"github.com/urfave/cli/v3"
+
+	"github.com/zperf/fusestream/histo"
+	"github.com/zperf/fusestream/pb"
+)
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+var topCommand = &cli.Command{
+	Name:  "top",
+	Usage: "Show live operation rates, latencies, hottest paths and faults of a running server",
+	Flags: append([]cli.Flag{
+		flagAddress,
+		flagTarget,
+		&cli.DurationFlag{
+			Name:    "interval",
+			Aliases: []string{"n"},
+			Usage:   "The refresh interval",
+			Value:   time.Second,
+		},
+		&cli.IntFlag{
+			Name:  "paths",
+			Usage: "Number of the hottest paths shown",
+			Value: 10,
+		},
+		&cli.IntFlag{
+			Name:  "iterations",
+			Usage: "Exit after the number of refreshes, 0 runs until interrupted",
+		},
+	}, rpcClientFlags...),
+	Action: func(ctx context.Context, command *cli.Command) error {
+		interval := command.Duration("interval")
+		if interval <= 0 {
+			return errors.New("interval must be positive")
+		}
+
+		conn, err := dialRPC(command)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close() }()
+		client := pb.NewFuseStreamClient(conn)
+
+		prev, err := takeTopSample(ctx, client, command.String("target"))
+		if err != nil {
+			return err
+		}
+
+		tty := isatty.IsTerminal(os.Stdout.Fd())
+		iterations := command.Int("iterations")
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for i := 0; iterations <= 0 || i < iterations; i++ {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+
+			cur, err := takeTopSample(ctx, client, command.String("target"))
+			if err != nil {
+				return err
+			}
+
+			var buf bytes.Buffer
+			if tty {
+				buf.WriteString(clearScreen)
+			}
+			fmt.Fprintf(&buf, "fusestream top - %s, %s, every %v\n\n", command.String("address"), time.Now().Format(time.TimeOnly), interval)
+			NewTopView(prev, cur, command.Int("paths")).Print(&buf)
+			if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			prev = cur
+		}
+		return nil
+	},
+}
+
+type topSample struct {
+	stats    *pb.GetStatsResponse
+	faults   *pb.ListFaultsResponse
+	inflight *pb.ListInflightResponse
+}
+
+func takeTopSample(ctx context.Context, client pb.FuseStreamClient, target string) (*topSample, error) {
+	req := &pb.TargetRequest{Target: target}
+	stats, err := client.GetStats(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	faults, err := client.ListFaults(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	inflight, err := client.ListInflight(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &topSample{stats: stats, faults: faults, inflight: inflight}, nil
+}
+
+// TopOpRow is the rates and latencies of an operation in the interval, latencies are in nanoseconds
+type TopOpRow struct {
+	Op        string
+	Rate      float64
+	ErrorRate float64
+	Bandwidth float64
+	Avg       int64
+	P50       int64
+	P99       int64
+}
+
+type TopPathRow struct {
+	Path      string
+	Rate      float64
+	Bandwidth float64
+	Avg       int64
+}
+
+type TopFaultRow struct {
+	ID       int32
+	Type     string
+	Target   string
+	Op       string
+	Enabled  bool
+	Hits     int64
+	HitRate  float64
+	Describe string
+}
+
+// TopView is the difference of two samples of a running server, Delayed are the in-flight operations sleeping in an
+// injected delay, the longest running first
+type TopView struct {
+	Interval time.Duration
+	Ops      []TopOpRow
+	Paths    []TopPathRow
+	Faults   []TopFaultRow
+	Paused   bool
+	Delaying int64
+	Delayed  []InflightRow
+}
+
+// NewTopView computes the rates from prev to cur, only the given number of the hottest paths are kept
+func NewTopView(prev, cur *topSample, paths int) *TopView {
+	v := &TopView{
+		Interval: time.Duration(cur.stats.TimeNs - prev.stats.TimeNs),
+		Paused:   cur.faults.GetPaused(),
+		Delaying: cur.stats.GetDelaying(),
+	}
+	seconds := v.Interval.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+
+	prevOps := make(map[string]*pb.OpStats)
+	for _, op := range prev.stats.Ops {
+		prevOps[op.Op] = op
+	}
+	for _, op := range cur.stats.Ops {
+		p := prevOps[op.Op]
+		count := op.Count - p.GetCount()
+		if count <= 0 {
+			continue
+		}
+
+		buckets := slices.Clone(op.LatencyBuckets)
+		for i := range min(len(buckets), len(p.GetLatencyBuckets())) {
+			buckets[i] -= p.LatencyBuckets[i]
+		}
+		pcts := histo.BucketPercentiles(cur.stats.LatencyBoundsNs, buckets, 50, 99)
+		v.Ops = append(v.Ops, TopOpRow{
+			Op:        op.Op,
+			Rate:      float64(count) / seconds,
+			ErrorRate: float64(op.Errors-p.GetErrors()) / seconds,
+			Bandwidth: float64(op.Bytes-p.GetBytes()) / seconds,
+			Avg:       (op.ElapsedNs - p.GetElapsedNs()) / count,
+			P50:       pcts[0],
+			P99:       pcts[1],
+		})
+	}
+
+	prevPaths := make(map[string]*pb.PathStats)
+	for _, path := range prev.stats.Paths {
+		prevPaths[path.Path] = path
+	}
+	for _, path := range cur.stats.Paths {
+		p := prevPaths[path.Path]
+		count := path.Count - p.GetCount()
+		if count <= 0 {
+			continue
+		}
+
+		name := path.Path
+		if name == "" {
+			name = "(other)"
+		}
+		v.Paths = append(v.Paths, TopPathRow{
+			Path:      name,
+			Rate:      float64(count) / seconds,
+			Bandwidth: float64(path.Bytes-p.GetBytes()) / seconds,
+			Avg:       (path.ElapsedNs - p.GetElapsedNs()) / count,
+		})
+	}
+	slices.SortFunc(v.Paths, func(a, b TopPathRow) int {
+		return cmp.Or(cmp.Compare(b.Rate, a.Rate), cmp.Compare(a.Path, b.Path))
+	})
+	if len(v.Paths) > paths {
+		v.Paths = v.Paths[:max(paths, 0)]
+	}
+
+	prevHits := make(map[int32]int64)
+	for _, f := range prev.faults.FuseFaults {
+		prevHits[f.Id] = f.Hits
+	}
+	for _, f := range prev.faults.NbdFaults {
+		prevHits[f.Id] = f.Hits
+	}
+	for _, f := range cur.faults.FuseFaults {
+		v.Faults = append(v.Faults, TopFaultRow{
+			ID: f.Id, Type: "fs", Target: f.PathRe, Op: f.Op.String(), Enabled: f.GetEnabled(),
+			Hits: f.Hits, HitRate: float64(f.Hits-prevHits[f.Id]) / seconds, Describe: describeFuseFault(f),
+		})
+	}
+	for _, f := range cur.faults.NbdFaults {
+		v.Faults = append(v.Faults, TopFaultRow{
+			ID: f.Id, Type: "nbd", Target: "/", Op: f.Op.String(), Enabled: f.GetEnabled(),
+			Hits: f.Hits, HitRate: float64(f.Hits-prevHits[f.Id]) / seconds, Describe: describeNbdFault(f),
+		})
+	}
+	slices.SortFunc(v.Faults, func(a, b TopFaultRow) int { return cmp.Compare(a.ID, b.ID) })
+
+	for _, r := range NewInflightRows(cur.inflight, 0) {
+		if r.State == inflightStateDelaying {
+			v.Delayed = append(v.Delayed, r)
+		}
+	}
+
+	return v
+}
+
+func (v *TopView) Print(w io.Writer) {
+	fmt.Fprintf(w, "Delayed in-flight: %d\n", v.Delaying)
+	if len(v.Delayed) > 0 {
+		tbl := table.New("Path", "Op", "Fault", "Elapsed").WithWriter(w).
+			WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+		for _, r := range v.Delayed {
+			tbl.AddRow(r.Path, r.Op, r.describeFault(), time.Duration(r.ElapsedNs))
+		}
+		tbl.Print()
+	}
+	fmt.Fprintln(w)
+
+	tbl := table.New("Op", "Ops/s", "Errors/s", "Bandwidth/s", "Avg", "p50", "p99").WithWriter(w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	for _, r := range v.Ops {
+		tbl.AddRow(r.Op, fmt.Sprintf("%.1f", r.Rate), fmt.Sprintf("%.1f", r.ErrorRate),
+			humanize.IBytes(uint64(r.Bandwidth)), formatMs(r.Avg), formatMs(r.P50), formatMs(r.P99))
+	}
+	tbl.Print()
+	fmt.Fprintln(w)
+
+	tbl = table.New("Path", "Ops/s", "Bandwidth/s", "Avg").WithWriter(w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	for _, r := range v.Paths {
+		tbl.AddRow(r.Path, fmt.Sprintf("%.1f", r.Rate), humanize.IBytes(uint64(r.Bandwidth)), formatMs(r.Avg))
+	}
+	tbl.Print()
+	fmt.Fprintln(w)
+
+	if v.Paused {
+		fmt.Fprintln(w, "All faults are paused")
+	}
+	tbl = table.New("ID", "Type", "Path", "Op", "Enabled", "Hits", "Hits/s", "Fault").WithWriter(w).
+		WithHeaderFormatter(tableHeaderFmt).WithFirstColumnFormatter(tableColumnFmt)
+	for _, r := range v.Faults {
+		tbl.AddRow(r.ID, r.Type, r.Target, r.Op, r.Enabled, r.Hits, fmt.Sprintf("%.1f", r.HitRate), r.Describe)
+	}
+	tbl.Print()
+}
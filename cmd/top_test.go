@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zperf/fusestream/histo"
+	"github.com/zperf/fusestream/pb"
+)
+
+func TestTopView(t *testing.T) {
+	bounds := []int64{1000, 2000, 4000}
+	prev := &topSample{
+		stats: &pb.GetStatsResponse{
+			TimeNs:          0,
+			LatencyBoundsNs: bounds,
+			Ops: []*pb.OpStats{
+				{Op: "fuse.Read", Count: 10, Bytes: 4096, ElapsedNs: 10000, LatencyBuckets: []int64{10, 0, 0, 0}},
+				{Op: "fuse.Getattr", Count: 5, ElapsedNs: 5000, LatencyBuckets: []int64{5, 0, 0, 0}},
+			},
+			Paths: []*pb.PathStats{{Path: "/a", Count: 10, Bytes: 4096, ElapsedNs: 10000}},
+		},
+		faults: &pb.ListFaultsResponse{
+			FuseFaults: []*pb.FuseFault{{Id: 1, PathRe: ".*", Op: pb.FuseOp_FUSE_READ, Hits: 2}},
+		},
+	}
+	cur := &topSample{
+		stats: &pb.GetStatsResponse{
+			TimeNs:          2 * time.Second.Nanoseconds(),
+			LatencyBoundsNs: bounds,
+			Ops: []*pb.OpStats{
+				{Op: "fuse.Read", Count: 110, Errors: 4, Bytes: 4096 + 2048*100, ElapsedNs: 10000 + 300000,
+					LatencyBuckets: []int64{10, 0, 98, 2}},
+				{Op: "fuse.Getattr", Count: 5, ElapsedNs: 5000, LatencyBuckets: []int64{5, 0, 0, 0}},
+			},
+			Paths: []*pb.PathStats{
+				{Path: "/a", Count: 30, Bytes: 4096 + 2048*20, ElapsedNs: 70000},
+				{Path: "/b", Count: 80, Bytes: 2048 * 80, ElapsedNs: 240000},
+				{Path: "", Count: 2},
+			},
+			Delaying: 3,
+		},
+		faults: &pb.ListFaultsResponse{
+			FuseFaults: []*pb.FuseFault{{Id: 1, PathRe: ".*", Op: pb.FuseOp_FUSE_READ, Hits: 42}},
+			NbdFaults:  []*pb.NbdFault{{Id: 0, Op: pb.NbdOp_NBD_READAT, Hits: 1}},
+		},
+		inflight: &pb.ListInflightResponse{
+			TimeNs: 2 * time.Second.Nanoseconds(),
+			Ops: []*pb.InflightOp{
+				{Id: 7, Op: "fuse.Read", Path: "/slow", StartTimeNs: 500 * time.Millisecond.Nanoseconds(),
+					FaultIds: []int32{1}, DelayNs: 5 * time.Second.Nanoseconds(), Delaying: true},
+				{Id: 8, Op: "fuse.Getattr", Path: "/a", StartTimeNs: time.Second.Nanoseconds()},
+			},
+		},
+	}
+
+	v := NewTopView(prev, cur, 2)
+	require.Equal(t, 2*time.Second, v.Interval)
+	require.Equal(t, int64(3), v.Delaying)
+
+	// idle ops are hidden
+	require.Equal(t, []TopOpRow{{
+		Op: "fuse.Read", Rate: 50, ErrorRate: 2, Bandwidth: 102400, Avg: 3000, P50: 4000, P99: 4000,
+	}}, v.Ops)
+
+	require.Len(t, v.Paths, 2)
+	require.Equal(t, "/b", v.Paths[0].Path)
+	require.Equal(t, 40.0, v.Paths[0].Rate)
+	require.Equal(t, "/a", v.Paths[1].Path)
+	require.Equal(t, int64(3000), v.Paths[1].Avg)
+
+	require.Len(t, v.Faults, 2)
+	require.Equal(t, int32(0), v.Faults[0].ID)
+	require.Equal(t, 0.5, v.Faults[0].HitRate)
+	require.Equal(t, int64(42), v.Faults[1].Hits)
+	require.Equal(t, 20.0, v.Faults[1].HitRate)
+
+	// only the operations sleeping in a delay are listed
+	require.Len(t, v.Delayed, 1)
+	require.Equal(t, "/slow", v.Delayed[0].Path)
+	require.Equal(t, 1500*time.Millisecond.Nanoseconds(), v.Delayed[0].ElapsedNs)
+
+	var buf bytes.Buffer
+	v.Print(&buf)
+	require.Contains(t, buf.String(), "Delayed in-flight: 3")
+	require.Regexp(t, `/slow\s+fuse\.Read\s+ids=\[1\],delay=5s\s+1\.5s`, buf.String())
+	require.Contains(t, buf.String(), "fuse.Read")
+}
+
+func TestBucketPercentiles(t *testing.T) {
+	bounds := []int64{1, 2, 4}
+	require.Equal(t, []int64{0, 0}, histo.BucketPercentiles(bounds, []int64{0, 0, 0, 0}, 50, 99))
+	require.Equal(t, []int64{2, 4}, histo.BucketPercentiles(bounds, []int64{1, 1, 1, 0}, 50, 99))
+	// the unbounded bucket reports the last bound
+	require.Equal(t, []int64{1, 4}, histo.BucketPercentiles(bounds, []int64{9, 0, 0, 1}, 50, 99.9))
+}
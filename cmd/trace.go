@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"github.com/zperf/fusestream/pb"
+	"github.com/zperf/fusestream/v1"
+)
+
+// setupTracing configures span exporting from tracingFlags, instance identifies the mount or the export.
+// The returned function flushes pending spans
+func setupTracing(ctx context.Context, command *cli.Command, instance string) (func(), error) {
+	ratio := command.Float64("trace-sample-ratio")
+	if ratio < 0 || ratio > 1 {
+		return nil, fmt.Errorf("trace sample ratio must be in [0, 1], got %v", ratio)
+	}
+
+	attrs := make(map[string]string)
+	for _, kv := range command.StringSlice("resource-attr") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid resource attribute: %s", kv)
+		}
+		attrs[k] = v
+	}
+
+	var rotateSize uint64
+	if v := command.String("export-rotate-size"); v != "" {
+		var err error
+		rotateSize, err = humanize.ParseBytes(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid export rotate size: %w", err)
+		}
+	}
+
+	opts := fusestream.OTelOptions{
+		ExportPath: command.String("export-path"),
+		ExportRotate: fusestream.SpanExporterOptions{
+			RotateSize:     int64(rotateSize),
+			RotateInterval: command.Duration("export-rotate-interval"),
+		},
+		OTLPEndpoint:       command.String("otlp-endpoint"),
+		OTLPProtocol:       command.String("otlp-protocol"),
+		SampleRatio:        ratio,
+		ServiceNamespace:   command.String("service-namespace"),
+		ServiceName:        command.String("service-name"),
+		Instance:           instance,
+		ResourceAttributes: attrs,
+	}
+	if opts.ExportPath == "" && opts.OTLPEndpoint == "" {
+		log.Info().Msg("Neither export path nor OTLP endpoint set, spans won't be exported")
+	}
+
+	shutdown, err := fusestream.SetupOTelSDK(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if err := shutdown(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Shutdown tracer provider failed")
+		}
+	}, nil
+}
+
+// exporterInfo reports the span exporting and metrics configuration for GetServerInfo
+func exporterInfo(command *cli.Command) *pb.ExporterInfo {
+	return &pb.ExporterInfo{
+		ExportPath:    command.String("export-path"),
+		OtlpEndpoint:  command.String("otlp-endpoint"),
+		OtlpProtocol:  command.String("otlp-protocol"),
+		SampleRatio:   command.Float64("trace-sample-ratio"),
+		MetricsListen: command.String("metrics-listen"),
+	}
+}
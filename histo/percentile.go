@@ -0,0 +1,49 @@
+package histo
+
+import (
+	"math"
+	"slices"
+)
+
+// Percentiles returns the nearest-rank percentiles of values, ps are in percent. values is sorted in place
+func Percentiles(values []int64, ps ...float64) []int64 {
+	result := make([]int64, len(ps))
+	if len(values) == 0 {
+		return result
+	}
+
+	slices.Sort(values)
+	for i, p := range ps {
+		// the epsilon keeps e.g. 99.9% of 1000 at rank 999
+		rank := int(math.Ceil(p/100*float64(len(values)) - 1e-9))
+		result[i] = values[min(max(rank-1, 0), len(values)-1)]
+	}
+	return result
+}
+
+// BucketPercentiles returns the percentiles of a bucketed distribution as the upper bound of the bucket holding the
+// nearest rank. counts has one more bucket than bounds, values in the last bucket are reported as the last bound
+func BucketPercentiles(bounds []int64, counts []int64, ps ...float64) []int64 {
+	result := make([]int64, len(ps))
+	var total int64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 || len(bounds) == 0 {
+		return result
+	}
+
+	for i, p := range ps {
+		rank := int64(math.Ceil(p/100*float64(total) - 1e-9))
+		var seen int64
+		result[i] = bounds[len(bounds)-1]
+		for j, c := range counts {
+			seen += c
+			if seen >= max(rank, 1) {
+				result[i] = bounds[min(j, len(bounds)-1)]
+				break
+			}
+		}
+	}
+	return result
+}
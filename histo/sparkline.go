@@ -0,0 +1,39 @@
+package histo
+
+import (
+	"strings"
+)
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values in one line of at most width runes, adjacent values are merged by max
+// so that spikes are kept
+func Sparkline(values []float64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+
+	merged := values
+	if len(values) > width {
+		merged = make([]float64, width)
+		for i, v := range values {
+			j := i * width / len(values)
+			merged[j] = max(merged[j], v)
+		}
+	}
+
+	hi := 0.0
+	for _, v := range merged {
+		hi = max(hi, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range merged {
+		idx := 0
+		if hi > 0 {
+			idx = min(int(v/hi*float64(len(sparks)-1)+0.5), len(sparks)-1)
+		}
+		sb.WriteRune(sparks[max(idx, 0)])
+	}
+	return sb.String()
+}
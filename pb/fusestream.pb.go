@@ -21,6 +21,58 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ServerMode int32
+
+const (
+	ServerMode_SERVER_MODE_UNKNOWN ServerMode = 0
+	ServerMode_SERVER_MODE_FUSE    ServerMode = 1
+	ServerMode_SERVER_MODE_NBD     ServerMode = 2
+	ServerMode_SERVER_MODE_DAEMON  ServerMode = 3
+)
+
+// Enum value maps for ServerMode.
+var (
+	ServerMode_name = map[int32]string{
+		0: "SERVER_MODE_UNKNOWN",
+		1: "SERVER_MODE_FUSE",
+		2: "SERVER_MODE_NBD",
+		3: "SERVER_MODE_DAEMON",
+	}
+	ServerMode_value = map[string]int32{
+		"SERVER_MODE_UNKNOWN": 0,
+		"SERVER_MODE_FUSE":    1,
+		"SERVER_MODE_NBD":     2,
+		"SERVER_MODE_DAEMON":  3,
+	}
+)
+
+func (x ServerMode) Enum() *ServerMode {
+	p := new(ServerMode)
+	*p = x
+	return p
+}
+
+func (x ServerMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ServerMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_fusestream_proto_enumTypes[0].Descriptor()
+}
+
+func (ServerMode) Type() protoreflect.EnumType {
+	return &file_fusestream_proto_enumTypes[0]
+}
+
+func (x ServerMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ServerMode.Descriptor instead.
+func (ServerMode) EnumDescriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{0}
+}
+
 type FuseOp int32
 
 const (
@@ -117,11 +169,11 @@ func (x FuseOp) String() string {
 }
 
 func (FuseOp) Descriptor() protoreflect.EnumDescriptor {
-	return file_fusestream_proto_enumTypes[0].Descriptor()
+	return file_fusestream_proto_enumTypes[1].Descriptor()
 }
 
 func (FuseOp) Type() protoreflect.EnumType {
-	return &file_fusestream_proto_enumTypes[0]
+	return &file_fusestream_proto_enumTypes[1]
 }
 
 func (x FuseOp) Number() protoreflect.EnumNumber {
@@ -130,7 +182,113 @@ func (x FuseOp) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use FuseOp.Descriptor instead.
 func (FuseOp) EnumDescriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{0}
+	return file_fusestream_proto_rawDescGZIP(), []int{1}
+}
+
+type OpenFlag int32
+
+const (
+	OpenFlag_OPEN_FLAG_UNKNOWN OpenFlag = 0
+	OpenFlag_OPEN_FLAG_DIRECT  OpenFlag = 1
+	OpenFlag_OPEN_FLAG_SYNC    OpenFlag = 2
+	OpenFlag_OPEN_FLAG_DSYNC   OpenFlag = 3
+	OpenFlag_OPEN_FLAG_APPEND  OpenFlag = 4
+	OpenFlag_OPEN_FLAG_TRUNC   OpenFlag = 5
+)
+
+// Enum value maps for OpenFlag.
+var (
+	OpenFlag_name = map[int32]string{
+		0: "OPEN_FLAG_UNKNOWN",
+		1: "OPEN_FLAG_DIRECT",
+		2: "OPEN_FLAG_SYNC",
+		3: "OPEN_FLAG_DSYNC",
+		4: "OPEN_FLAG_APPEND",
+		5: "OPEN_FLAG_TRUNC",
+	}
+	OpenFlag_value = map[string]int32{
+		"OPEN_FLAG_UNKNOWN": 0,
+		"OPEN_FLAG_DIRECT":  1,
+		"OPEN_FLAG_SYNC":    2,
+		"OPEN_FLAG_DSYNC":   3,
+		"OPEN_FLAG_APPEND":  4,
+		"OPEN_FLAG_TRUNC":   5,
+	}
+)
+
+func (x OpenFlag) Enum() *OpenFlag {
+	p := new(OpenFlag)
+	*p = x
+	return p
+}
+
+func (x OpenFlag) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OpenFlag) Descriptor() protoreflect.EnumDescriptor {
+	return file_fusestream_proto_enumTypes[2].Descriptor()
+}
+
+func (OpenFlag) Type() protoreflect.EnumType {
+	return &file_fusestream_proto_enumTypes[2]
+}
+
+func (x OpenFlag) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OpenFlag.Descriptor instead.
+func (OpenFlag) EnumDescriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{2}
+}
+
+type NbdMatchPolicy int32
+
+const (
+	// stop evaluating lower priority faults once this fault matched
+	NbdMatchPolicy_NBD_MATCH_FIRST NbdMatchPolicy = 0
+	// keep evaluating lower priority faults after this fault matched
+	NbdMatchPolicy_NBD_MATCH_ALL NbdMatchPolicy = 1
+)
+
+// Enum value maps for NbdMatchPolicy.
+var (
+	NbdMatchPolicy_name = map[int32]string{
+		0: "NBD_MATCH_FIRST",
+		1: "NBD_MATCH_ALL",
+	}
+	NbdMatchPolicy_value = map[string]int32{
+		"NBD_MATCH_FIRST": 0,
+		"NBD_MATCH_ALL":   1,
+	}
+)
+
+func (x NbdMatchPolicy) Enum() *NbdMatchPolicy {
+	p := new(NbdMatchPolicy)
+	*p = x
+	return p
+}
+
+func (x NbdMatchPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (NbdMatchPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_fusestream_proto_enumTypes[3].Descriptor()
+}
+
+func (NbdMatchPolicy) Type() protoreflect.EnumType {
+	return &file_fusestream_proto_enumTypes[3]
+}
+
+func (x NbdMatchPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use NbdMatchPolicy.Descriptor instead.
+func (NbdMatchPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{3}
 }
 
 type NbdOp int32
@@ -172,11 +330,11 @@ func (x NbdOp) String() string {
 }
 
 func (NbdOp) Descriptor() protoreflect.EnumDescriptor {
-	return file_fusestream_proto_enumTypes[1].Descriptor()
+	return file_fusestream_proto_enumTypes[4].Descriptor()
 }
 
 func (NbdOp) Type() protoreflect.EnumType {
-	return &file_fusestream_proto_enumTypes[1]
+	return &file_fusestream_proto_enumTypes[4]
 }
 
 func (x NbdOp) Number() protoreflect.EnumNumber {
@@ -185,7 +343,7 @@ func (x NbdOp) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use NbdOp.Descriptor instead.
 func (NbdOp) EnumDescriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{1}
+	return file_fusestream_proto_rawDescGZIP(), []int{4}
 }
 
 type ReturnValueFault struct {
@@ -304,7 +462,17 @@ type FuseFault struct {
 	// Types that are valid to be assigned to Delay:
 	//
 	//	*FuseFault_DelayFault
-	Delay         isFuseFault_Delay `protobuf_oneof:"delay"`
+	Delay isFuseFault_Delay `protobuf_oneof:"delay"`
+	// unset means enabled
+	Enabled *bool `protobuf:"varint,6,opt,name=enabled,proto3,oneof" json:"enabled,omitempty"`
+	// unset means any calling process
+	Process *ProcessFilter `protobuf:"bytes,7,opt,name=process,proto3" json:"process,omitempty"`
+	// only match files opened with all of the flags
+	OpenFlags []OpenFlag `protobuf:"varint,8,rep,packed,name=open_flags,json=openFlags,proto3,enum=slowio.proto.OpenFlag" json:"open_flags,omitempty"`
+	// only match the file handles
+	Fhs []uint64 `protobuf:"varint,9,rep,packed,name=fhs,proto3" json:"fhs,omitempty"`
+	// number of times the fault was triggered, output only
+	Hits          int64 `protobuf:"varint,10,opt,name=hits,proto3" json:"hits,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -392,6 +560,41 @@ func (x *FuseFault) GetDelayFault() *DelayFault {
 	return nil
 }
 
+func (x *FuseFault) GetEnabled() bool {
+	if x != nil && x.Enabled != nil {
+		return *x.Enabled
+	}
+	return false
+}
+
+func (x *FuseFault) GetProcess() *ProcessFilter {
+	if x != nil {
+		return x.Process
+	}
+	return nil
+}
+
+func (x *FuseFault) GetOpenFlags() []OpenFlag {
+	if x != nil {
+		return x.OpenFlags
+	}
+	return nil
+}
+
+func (x *FuseFault) GetFhs() []uint64 {
+	if x != nil {
+		return x.Fhs
+	}
+	return nil
+}
+
+func (x *FuseFault) GetHits() int64 {
+	if x != nil {
+		return x.Hits
+	}
+	return 0
+}
+
 type isFuseFault_ReturnValue interface {
 	isFuseFault_ReturnValue()
 }
@@ -412,6 +615,84 @@ type FuseFault_DelayFault struct {
 
 func (*FuseFault_DelayFault) isFuseFault_Delay() {}
 
+type ProcessFilter struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// match the calling process, or its descendants too if include_children is set
+	Pid             *int32  `protobuf:"varint,1,opt,name=pid,proto3,oneof" json:"pid,omitempty"`
+	IncludeChildren bool    `protobuf:"varint,2,opt,name=include_children,json=includeChildren,proto3" json:"include_children,omitempty"`
+	Uid             *uint32 `protobuf:"varint,3,opt,name=uid,proto3,oneof" json:"uid,omitempty"`
+	Gid             *uint32 `protobuf:"varint,4,opt,name=gid,proto3,oneof" json:"gid,omitempty"`
+	// regex matched against /proc/<pid>/comm
+	CommRe        string `protobuf:"bytes,5,opt,name=comm_re,json=commRe,proto3" json:"comm_re,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessFilter) Reset() {
+	*x = ProcessFilter{}
+	mi := &file_fusestream_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessFilter) ProtoMessage() {}
+
+func (x *ProcessFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessFilter.ProtoReflect.Descriptor instead.
+func (*ProcessFilter) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProcessFilter) GetPid() int32 {
+	if x != nil && x.Pid != nil {
+		return *x.Pid
+	}
+	return 0
+}
+
+func (x *ProcessFilter) GetIncludeChildren() bool {
+	if x != nil {
+		return x.IncludeChildren
+	}
+	return false
+}
+
+func (x *ProcessFilter) GetUid() uint32 {
+	if x != nil && x.Uid != nil {
+		return *x.Uid
+	}
+	return 0
+}
+
+func (x *ProcessFilter) GetGid() uint32 {
+	if x != nil && x.Gid != nil {
+		return *x.Gid
+	}
+	return 0
+}
+
+func (x *ProcessFilter) GetCommRe() string {
+	if x != nil {
+		return x.CommRe
+	}
+	return ""
+}
+
 type ErrorFault struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Possibility   float32                `protobuf:"fixed32,1,opt,name=possibility,proto3" json:"possibility,omitempty"`
@@ -422,7 +703,7 @@ type ErrorFault struct {
 
 func (x *ErrorFault) Reset() {
 	*x = ErrorFault{}
-	mi := &file_fusestream_proto_msgTypes[3]
+	mi := &file_fusestream_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -434,7 +715,7 @@ func (x *ErrorFault) String() string {
 func (*ErrorFault) ProtoMessage() {}
 
 func (x *ErrorFault) ProtoReflect() protoreflect.Message {
-	mi := &file_fusestream_proto_msgTypes[3]
+	mi := &file_fusestream_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -447,7 +728,7 @@ func (x *ErrorFault) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ErrorFault.ProtoReflect.Descriptor instead.
 func (*ErrorFault) Descriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{3}
+	return file_fusestream_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ErrorFault) GetPossibility() float32 {
@@ -483,14 +764,24 @@ type NbdFault struct {
 	// Types that are valid to be assigned to Delay:
 	//
 	//	*NbdFault_DelayFault
-	Delay         isNbdFault_Delay `protobuf_oneof:"delay"`
+	Delay isNbdFault_Delay `protobuf_oneof:"delay"`
+	// unset means enabled
+	Enabled *bool `protobuf:"varint,7,opt,name=enabled,proto3,oneof" json:"enabled,omitempty"`
+	// faults of the same op are evaluated from the highest priority
+	Priority int32 `protobuf:"varint,8,opt,name=priority,proto3" json:"priority,omitempty"`
+	// only I/Os overlapping [offset_start, offset_end) match, offset_end = 0 means unbounded
+	OffsetStart int64          `protobuf:"varint,9,opt,name=offset_start,json=offsetStart,proto3" json:"offset_start,omitempty"`
+	OffsetEnd   int64          `protobuf:"varint,10,opt,name=offset_end,json=offsetEnd,proto3" json:"offset_end,omitempty"`
+	MatchPolicy NbdMatchPolicy `protobuf:"varint,11,opt,name=match_policy,json=matchPolicy,proto3,enum=slowio.proto.NbdMatchPolicy" json:"match_policy,omitempty"`
+	// number of times the fault was triggered, output only
+	Hits          int64 `protobuf:"varint,12,opt,name=hits,proto3" json:"hits,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *NbdFault) Reset() {
 	*x = NbdFault{}
-	mi := &file_fusestream_proto_msgTypes[4]
+	mi := &file_fusestream_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -502,7 +793,7 @@ func (x *NbdFault) String() string {
 func (*NbdFault) ProtoMessage() {}
 
 func (x *NbdFault) ProtoReflect() protoreflect.Message {
-	mi := &file_fusestream_proto_msgTypes[4]
+	mi := &file_fusestream_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -515,7 +806,7 @@ func (x *NbdFault) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NbdFault.ProtoReflect.Descriptor instead.
 func (*NbdFault) Descriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{4}
+	return file_fusestream_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *NbdFault) GetId() int32 {
@@ -596,6 +887,48 @@ func (x *NbdFault) GetDelayFault() *DelayFault {
 	return nil
 }
 
+func (x *NbdFault) GetEnabled() bool {
+	if x != nil && x.Enabled != nil {
+		return *x.Enabled
+	}
+	return false
+}
+
+func (x *NbdFault) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *NbdFault) GetOffsetStart() int64 {
+	if x != nil {
+		return x.OffsetStart
+	}
+	return 0
+}
+
+func (x *NbdFault) GetOffsetEnd() int64 {
+	if x != nil {
+		return x.OffsetEnd
+	}
+	return 0
+}
+
+func (x *NbdFault) GetMatchPolicy() NbdMatchPolicy {
+	if x != nil {
+		return x.MatchPolicy
+	}
+	return NbdMatchPolicy_NBD_MATCH_FIRST
+}
+
+func (x *NbdFault) GetHits() int64 {
+	if x != nil {
+		return x.Hits
+	}
+	return 0
+}
+
 type isNbdFault_PreCond interface {
 	isNbdFault_PreCond()
 }
@@ -639,13 +972,14 @@ func (*NbdFault_DelayFault) isNbdFault_Delay() {}
 type InjectFuseFaultRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Fault         *FuseFault             `protobuf:"bytes,1,opt,name=fault,proto3" json:"fault,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InjectFuseFaultRequest) Reset() {
 	*x = InjectFuseFaultRequest{}
-	mi := &file_fusestream_proto_msgTypes[5]
+	mi := &file_fusestream_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -657,7 +991,7 @@ func (x *InjectFuseFaultRequest) String() string {
 func (*InjectFuseFaultRequest) ProtoMessage() {}
 
 func (x *InjectFuseFaultRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_fusestream_proto_msgTypes[5]
+	mi := &file_fusestream_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -670,7 +1004,7 @@ func (x *InjectFuseFaultRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InjectFuseFaultRequest.ProtoReflect.Descriptor instead.
 func (*InjectFuseFaultRequest) Descriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{5}
+	return file_fusestream_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *InjectFuseFaultRequest) GetFault() *FuseFault {
@@ -680,16 +1014,25 @@ func (x *InjectFuseFaultRequest) GetFault() *FuseFault {
 	return nil
 }
 
+func (x *InjectFuseFaultRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
 type InjectFuseFaultResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// the definition of the injected fault
+	Fault         *FuseFault `protobuf:"bytes,2,opt,name=fault,proto3" json:"fault,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InjectFuseFaultResponse) Reset() {
 	*x = InjectFuseFaultResponse{}
-	mi := &file_fusestream_proto_msgTypes[6]
+	mi := &file_fusestream_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -701,7 +1044,7 @@ func (x *InjectFuseFaultResponse) String() string {
 func (*InjectFuseFaultResponse) ProtoMessage() {}
 
 func (x *InjectFuseFaultResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_fusestream_proto_msgTypes[6]
+	mi := &file_fusestream_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -714,7 +1057,7 @@ func (x *InjectFuseFaultResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InjectFuseFaultResponse.ProtoReflect.Descriptor instead.
 func (*InjectFuseFaultResponse) Descriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{6}
+	return file_fusestream_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *InjectFuseFaultResponse) GetId() int32 {
@@ -724,16 +1067,24 @@ func (x *InjectFuseFaultResponse) GetId() int32 {
 	return 0
 }
 
+func (x *InjectFuseFaultResponse) GetFault() *FuseFault {
+	if x != nil {
+		return x.Fault
+	}
+	return nil
+}
+
 type InjectNbdFaultRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Fault         *NbdFault              `protobuf:"bytes,1,opt,name=fault,proto3" json:"fault,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InjectNbdFaultRequest) Reset() {
 	*x = InjectNbdFaultRequest{}
-	mi := &file_fusestream_proto_msgTypes[7]
+	mi := &file_fusestream_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -745,7 +1096,7 @@ func (x *InjectNbdFaultRequest) String() string {
 func (*InjectNbdFaultRequest) ProtoMessage() {}
 
 func (x *InjectNbdFaultRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_fusestream_proto_msgTypes[7]
+	mi := &file_fusestream_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -758,7 +1109,7 @@ func (x *InjectNbdFaultRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InjectNbdFaultRequest.ProtoReflect.Descriptor instead.
 func (*InjectNbdFaultRequest) Descriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{7}
+	return file_fusestream_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *InjectNbdFaultRequest) GetFault() *NbdFault {
@@ -768,16 +1119,25 @@ func (x *InjectNbdFaultRequest) GetFault() *NbdFault {
 	return nil
 }
 
+func (x *InjectNbdFaultRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
 type InjectNbdFaultResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// the definition of the injected fault
+	Fault         *NbdFault `protobuf:"bytes,2,opt,name=fault,proto3" json:"fault,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InjectNbdFaultResponse) Reset() {
 	*x = InjectNbdFaultResponse{}
-	mi := &file_fusestream_proto_msgTypes[8]
+	mi := &file_fusestream_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -789,7 +1149,7 @@ func (x *InjectNbdFaultResponse) String() string {
 func (*InjectNbdFaultResponse) ProtoMessage() {}
 
 func (x *InjectNbdFaultResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_fusestream_proto_msgTypes[8]
+	mi := &file_fusestream_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -802,7 +1162,7 @@ func (x *InjectNbdFaultResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InjectNbdFaultResponse.ProtoReflect.Descriptor instead.
 func (*InjectNbdFaultResponse) Descriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{8}
+	return file_fusestream_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *InjectNbdFaultResponse) GetId() int32 {
@@ -812,30 +1172,1057 @@ func (x *InjectNbdFaultResponse) GetId() int32 {
 	return 0
 }
 
+func (x *InjectNbdFaultResponse) GetFault() *NbdFault {
+	if x != nil {
+		return x.Fault
+	}
+	return nil
+}
+
 type DeleteFaultRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            []int32                `protobuf:"varint,1,rep,packed,name=id,proto3" json:"id,omitempty"`
 	PathRe        string                 `protobuf:"bytes,2,opt,name=path_re,json=pathRe,proto3" json:"path_re,omitempty"`
 	All           bool                   `protobuf:"varint,3,opt,name=all,proto3" json:"all,omitempty"`
+	FuseOp        FuseOp                 `protobuf:"varint,4,opt,name=fuse_op,json=fuseOp,proto3,enum=slowio.proto.FuseOp" json:"fuse_op,omitempty"`
+	NbdOp         NbdOp                  `protobuf:"varint,5,opt,name=nbd_op,json=nbdOp,proto3,enum=slowio.proto.NbdOp" json:"nbd_op,omitempty"`
+	Target        string                 `protobuf:"bytes,6,opt,name=target,proto3" json:"target,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeleteFaultRequest) Reset() {
 	*x = DeleteFaultRequest{}
-	mi := &file_fusestream_proto_msgTypes[9]
+	mi := &file_fusestream_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFaultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFaultRequest) ProtoMessage() {}
+
+func (x *DeleteFaultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFaultRequest.ProtoReflect.Descriptor instead.
+func (*DeleteFaultRequest) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteFaultRequest) GetId() []int32 {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *DeleteFaultRequest) GetPathRe() string {
+	if x != nil {
+		return x.PathRe
+	}
+	return ""
+}
+
+func (x *DeleteFaultRequest) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+func (x *DeleteFaultRequest) GetFuseOp() FuseOp {
+	if x != nil {
+		return x.FuseOp
+	}
+	return FuseOp_FUSE_UNKNOWN
+}
+
+func (x *DeleteFaultRequest) GetNbdOp() NbdOp {
+	if x != nil {
+		return x.NbdOp
+	}
+	return NbdOp_NBD_UNKNOWN
+}
+
+func (x *DeleteFaultRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type DeleteFaultResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeletedIds    []int32                `protobuf:"varint,1,rep,packed,name=deleted_ids,json=deletedIds,proto3" json:"deleted_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFaultResponse) Reset() {
+	*x = DeleteFaultResponse{}
+	mi := &file_fusestream_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFaultResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFaultResponse) ProtoMessage() {}
+
+func (x *DeleteFaultResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFaultResponse.ProtoReflect.Descriptor instead.
+func (*DeleteFaultResponse) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteFaultResponse) GetDeletedIds() []int32 {
+	if x != nil {
+		return x.DeletedIds
+	}
+	return nil
+}
+
+type SetFaultEnabledRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []int32                `protobuf:"varint,1,rep,packed,name=id,proto3" json:"id,omitempty"`
+	Enabled       bool                   `protobuf:"varint,2,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Target        string                 `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetFaultEnabledRequest) Reset() {
+	*x = SetFaultEnabledRequest{}
+	mi := &file_fusestream_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetFaultEnabledRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetFaultEnabledRequest) ProtoMessage() {}
+
+func (x *SetFaultEnabledRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetFaultEnabledRequest.ProtoReflect.Descriptor instead.
+func (*SetFaultEnabledRequest) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SetFaultEnabledRequest) GetId() []int32 {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *SetFaultEnabledRequest) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *SetFaultEnabledRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type SetFaultEnabledResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UpdatedIds    []int32                `protobuf:"varint,1,rep,packed,name=updated_ids,json=updatedIds,proto3" json:"updated_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetFaultEnabledResponse) Reset() {
+	*x = SetFaultEnabledResponse{}
+	mi := &file_fusestream_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetFaultEnabledResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetFaultEnabledResponse) ProtoMessage() {}
+
+func (x *SetFaultEnabledResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetFaultEnabledResponse.ProtoReflect.Descriptor instead.
+func (*SetFaultEnabledResponse) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SetFaultEnabledResponse) GetUpdatedIds() []int32 {
+	if x != nil {
+		return x.UpdatedIds
+	}
+	return nil
+}
+
+type Void struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Void) Reset() {
+	*x = Void{}
+	mi := &file_fusestream_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Void) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Void) ProtoMessage() {}
+
+func (x *Void) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Void.ProtoReflect.Descriptor instead.
+func (*Void) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{14}
+}
+
+// TargetRequest names the target of the daemon, empty means the only target
+type TargetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TargetRequest) Reset() {
+	*x = TargetRequest{}
+	mi := &file_fusestream_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TargetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TargetRequest) ProtoMessage() {}
+
+func (x *TargetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TargetRequest.ProtoReflect.Descriptor instead.
+func (*TargetRequest) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TargetRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type ListFaultsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FuseFaults    []*FuseFault           `protobuf:"bytes,1,rep,name=fuse_faults,json=fuseFaults,proto3" json:"fuse_faults,omitempty"`
+	NbdFaults     []*NbdFault            `protobuf:"bytes,2,rep,name=nbd_faults,json=nbdFaults,proto3" json:"nbd_faults,omitempty"`
+	Paused        bool                   `protobuf:"varint,3,opt,name=paused,proto3" json:"paused,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFaultsResponse) Reset() {
+	*x = ListFaultsResponse{}
+	mi := &file_fusestream_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFaultsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFaultsResponse) ProtoMessage() {}
+
+func (x *ListFaultsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFaultsResponse.ProtoReflect.Descriptor instead.
+func (*ListFaultsResponse) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListFaultsResponse) GetFuseFaults() []*FuseFault {
+	if x != nil {
+		return x.FuseFaults
+	}
+	return nil
+}
+
+func (x *ListFaultsResponse) GetNbdFaults() []*NbdFault {
+	if x != nil {
+		return x.NbdFaults
+	}
+	return nil
+}
+
+func (x *ListFaultsResponse) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+// OpStats are cumulative since the server started, clients compute rates from the difference of two samples
+type OpStats struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Op        string                 `protobuf:"bytes,1,opt,name=op,proto3" json:"op,omitempty"`
+	Count     int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Errors    int64                  `protobuf:"varint,3,opt,name=errors,proto3" json:"errors,omitempty"`
+	Bytes     int64                  `protobuf:"varint,4,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	ElapsedNs int64                  `protobuf:"varint,5,opt,name=elapsed_ns,json=elapsedNs,proto3" json:"elapsed_ns,omitempty"`
+	// counts of the latency buckets bounded by GetStatsResponse.latency_bounds_ns, the last bucket is unbounded
+	LatencyBuckets []int64 `protobuf:"varint,6,rep,packed,name=latency_buckets,json=latencyBuckets,proto3" json:"latency_buckets,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *OpStats) Reset() {
+	*x = OpStats{}
+	mi := &file_fusestream_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpStats) ProtoMessage() {}
+
+func (x *OpStats) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpStats.ProtoReflect.Descriptor instead.
+func (*OpStats) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *OpStats) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *OpStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *OpStats) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *OpStats) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *OpStats) GetElapsedNs() int64 {
+	if x != nil {
+		return x.ElapsedNs
+	}
+	return 0
+}
+
+func (x *OpStats) GetLatencyBuckets() []int64 {
+	if x != nil {
+		return x.LatencyBuckets
+	}
+	return nil
+}
+
+type PathStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Bytes         int64                  `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	ElapsedNs     int64                  `protobuf:"varint,4,opt,name=elapsed_ns,json=elapsedNs,proto3" json:"elapsed_ns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PathStats) Reset() {
+	*x = PathStats{}
+	mi := &file_fusestream_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PathStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PathStats) ProtoMessage() {}
+
+func (x *PathStats) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PathStats.ProtoReflect.Descriptor instead.
+func (*PathStats) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PathStats) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *PathStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *PathStats) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *PathStats) GetElapsedNs() int64 {
+	if x != nil {
+		return x.ElapsedNs
+	}
+	return 0
+}
+
+type GetStatsResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	TimeNs          int64                  `protobuf:"varint,1,opt,name=time_ns,json=timeNs,proto3" json:"time_ns,omitempty"`
+	LatencyBoundsNs []int64                `protobuf:"varint,2,rep,packed,name=latency_bounds_ns,json=latencyBoundsNs,proto3" json:"latency_bounds_ns,omitempty"`
+	Ops             []*OpStats             `protobuf:"bytes,3,rep,name=ops,proto3" json:"ops,omitempty"`
+	Paths           []*PathStats           `protobuf:"bytes,4,rep,name=paths,proto3" json:"paths,omitempty"`
+	// number of operations sleeping in injected delays
+	Delaying      int64 `protobuf:"varint,5,opt,name=delaying,proto3" json:"delaying,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_fusestream_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetStatsResponse) GetTimeNs() int64 {
+	if x != nil {
+		return x.TimeNs
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetLatencyBoundsNs() []int64 {
+	if x != nil {
+		return x.LatencyBoundsNs
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetOps() []*OpStats {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetPaths() []*PathStats {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetDelaying() int64 {
+	if x != nil {
+		return x.Delaying
+	}
+	return 0
+}
+
+type InflightOp struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Op    string                 `protobuf:"bytes,2,opt,name=op,proto3" json:"op,omitempty"`
+	// the file path, or the export of NBD operations
+	Path string `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	// offset and length of NBD I/Os
+	Offset int64 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length int64 `protobuf:"varint,5,opt,name=length,proto3" json:"length,omitempty"`
+	// the calling process, 0 if unknown
+	Pid         int32 `protobuf:"varint,6,opt,name=pid,proto3" json:"pid,omitempty"`
+	StartTimeNs int64 `protobuf:"varint,7,opt,name=start_time_ns,json=startTimeNs,proto3" json:"start_time_ns,omitempty"`
+	// the fault being applied
+	FaultIds []int32 `protobuf:"varint,8,rep,packed,name=fault_ids,json=faultIds,proto3" json:"fault_ids,omitempty"`
+	DelayNs  int64   `protobuf:"varint,9,opt,name=delay_ns,json=delayNs,proto3" json:"delay_ns,omitempty"`
+	// true while sleeping in the injected delay
+	Delaying      bool `protobuf:"varint,10,opt,name=delaying,proto3" json:"delaying,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InflightOp) Reset() {
+	*x = InflightOp{}
+	mi := &file_fusestream_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InflightOp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InflightOp) ProtoMessage() {}
+
+func (x *InflightOp) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InflightOp.ProtoReflect.Descriptor instead.
+func (*InflightOp) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *InflightOp) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *InflightOp) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *InflightOp) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *InflightOp) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *InflightOp) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *InflightOp) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *InflightOp) GetStartTimeNs() int64 {
+	if x != nil {
+		return x.StartTimeNs
+	}
+	return 0
+}
+
+func (x *InflightOp) GetFaultIds() []int32 {
+	if x != nil {
+		return x.FaultIds
+	}
+	return nil
+}
+
+func (x *InflightOp) GetDelayNs() int64 {
+	if x != nil {
+		return x.DelayNs
+	}
+	return 0
+}
+
+func (x *InflightOp) GetDelaying() bool {
+	if x != nil {
+		return x.Delaying
+	}
+	return false
+}
+
+type ListInflightResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	TimeNs int64                  `protobuf:"varint,1,opt,name=time_ns,json=timeNs,proto3" json:"time_ns,omitempty"`
+	// the longest running first
+	Ops           []*InflightOp `protobuf:"bytes,2,rep,name=ops,proto3" json:"ops,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInflightResponse) Reset() {
+	*x = ListInflightResponse{}
+	mi := &file_fusestream_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInflightResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInflightResponse) ProtoMessage() {}
+
+func (x *ListInflightResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInflightResponse.ProtoReflect.Descriptor instead.
+func (*ListInflightResponse) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListInflightResponse) GetTimeNs() int64 {
+	if x != nil {
+		return x.TimeNs
+	}
+	return 0
+}
+
+func (x *ListInflightResponse) GetOps() []*InflightOp {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+type FuseTarget struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	BaseDir      string                 `protobuf:"bytes,1,opt,name=base_dir,json=baseDir,proto3" json:"base_dir,omitempty"`
+	Mountpoint   string                 `protobuf:"bytes,2,opt,name=mountpoint,proto3" json:"mountpoint,omitempty"`
+	MountOptions []string               `protobuf:"bytes,3,rep,name=mount_options,json=mountOptions,proto3" json:"mount_options,omitempty"`
+	UseIno       bool                   `protobuf:"varint,4,opt,name=use_ino,json=useIno,proto3" json:"use_ino,omitempty"`
+	// wait for in-flight operations on unmount, 0 uses the default
+	DrainTimeoutMs int64 `protobuf:"varint,5,opt,name=drain_timeout_ms,json=drainTimeoutMs,proto3" json:"drain_timeout_ms,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *FuseTarget) Reset() {
+	*x = FuseTarget{}
+	mi := &file_fusestream_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FuseTarget) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FuseTarget) ProtoMessage() {}
+
+func (x *FuseTarget) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FuseTarget.ProtoReflect.Descriptor instead.
+func (*FuseTarget) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *FuseTarget) GetBaseDir() string {
+	if x != nil {
+		return x.BaseDir
+	}
+	return ""
+}
+
+func (x *FuseTarget) GetMountpoint() string {
+	if x != nil {
+		return x.Mountpoint
+	}
+	return ""
+}
+
+func (x *FuseTarget) GetMountOptions() []string {
+	if x != nil {
+		return x.MountOptions
+	}
+	return nil
+}
+
+func (x *FuseTarget) GetUseIno() bool {
+	if x != nil {
+		return x.UseIno
+	}
+	return false
+}
+
+func (x *FuseTarget) GetDrainTimeoutMs() int64 {
+	if x != nil {
+		return x.DrainTimeoutMs
+	}
+	return 0
+}
+
+type NbdTarget struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	BackendFile string                 `protobuf:"bytes,1,opt,name=backend_file,json=backendFile,proto3" json:"backend_file,omitempty"`
+	Export      string                 `protobuf:"bytes,2,opt,name=export,proto3" json:"export,omitempty"`
+	// network and address the NBD server listens on, network defaults to tcp
+	Network       string `protobuf:"bytes,3,opt,name=network,proto3" json:"network,omitempty"`
+	Listen        string `protobuf:"bytes,4,opt,name=listen,proto3" json:"listen,omitempty"`
+	ReadOnly      bool   `protobuf:"varint,5,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NbdTarget) Reset() {
+	*x = NbdTarget{}
+	mi := &file_fusestream_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NbdTarget) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NbdTarget) ProtoMessage() {}
+
+func (x *NbdTarget) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NbdTarget.ProtoReflect.Descriptor instead.
+func (*NbdTarget) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *NbdTarget) GetBackendFile() string {
+	if x != nil {
+		return x.BackendFile
+	}
+	return ""
+}
+
+func (x *NbdTarget) GetExport() string {
+	if x != nil {
+		return x.Export
+	}
+	return ""
+}
+
+func (x *NbdTarget) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *NbdTarget) GetListen() string {
+	if x != nil {
+		return x.Listen
+	}
+	return ""
+}
+
+func (x *NbdTarget) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+type MountRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Types that are valid to be assigned to Spec:
+	//
+	//	*MountRequest_Fuse
+	//	*MountRequest_Nbd
+	Spec          isMountRequest_Spec `protobuf_oneof:"spec"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MountRequest) Reset() {
+	*x = MountRequest{}
+	mi := &file_fusestream_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MountRequest) ProtoMessage() {}
+
+func (x *MountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MountRequest.ProtoReflect.Descriptor instead.
+func (*MountRequest) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *MountRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MountRequest) GetSpec() isMountRequest_Spec {
+	if x != nil {
+		return x.Spec
+	}
+	return nil
+}
+
+func (x *MountRequest) GetFuse() *FuseTarget {
+	if x != nil {
+		if x, ok := x.Spec.(*MountRequest_Fuse); ok {
+			return x.Fuse
+		}
+	}
+	return nil
+}
+
+func (x *MountRequest) GetNbd() *NbdTarget {
+	if x != nil {
+		if x, ok := x.Spec.(*MountRequest_Nbd); ok {
+			return x.Nbd
+		}
+	}
+	return nil
+}
+
+type isMountRequest_Spec interface {
+	isMountRequest_Spec()
+}
+
+type MountRequest_Fuse struct {
+	Fuse *FuseTarget `protobuf:"bytes,2,opt,name=fuse,proto3,oneof"`
+}
+
+type MountRequest_Nbd struct {
+	Nbd *NbdTarget `protobuf:"bytes,3,opt,name=nbd,proto3,oneof"`
+}
+
+func (*MountRequest_Fuse) isMountRequest_Spec() {}
+
+func (*MountRequest_Nbd) isMountRequest_Spec() {}
+
+type UnmountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnmountRequest) Reset() {
+	*x = UnmountRequest{}
+	mi := &file_fusestream_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteFaultRequest) String() string {
+func (x *UnmountRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteFaultRequest) ProtoMessage() {}
+func (*UnmountRequest) ProtoMessage() {}
 
-func (x *DeleteFaultRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_fusestream_proto_msgTypes[9]
+func (x *UnmountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -846,54 +2233,171 @@ func (x *DeleteFaultRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteFaultRequest.ProtoReflect.Descriptor instead.
-func (*DeleteFaultRequest) Descriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use UnmountRequest.ProtoReflect.Descriptor instead.
+func (*UnmountRequest) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{25}
 }
 
-func (x *DeleteFaultRequest) GetId() []int32 {
+func (x *UnmountRequest) GetName() string {
 	if x != nil {
-		return x.Id
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-func (x *DeleteFaultRequest) GetPathRe() string {
+type TargetStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Types that are valid to be assigned to Spec:
+	//
+	//	*TargetStatus_Fuse
+	//	*TargetStatus_Nbd
+	Spec        isTargetStatus_Spec `protobuf_oneof:"spec"`
+	StartTimeNs int64               `protobuf:"varint,4,opt,name=start_time_ns,json=startTimeNs,proto3" json:"start_time_ns,omitempty"`
+	Faults      int32               `protobuf:"varint,5,opt,name=faults,proto3" json:"faults,omitempty"`
+	Paused      bool                `protobuf:"varint,6,opt,name=paused,proto3" json:"paused,omitempty"`
+	// number of operations finished and in-flight
+	Ops           int64 `protobuf:"varint,7,opt,name=ops,proto3" json:"ops,omitempty"`
+	Inflight      int32 `protobuf:"varint,8,opt,name=inflight,proto3" json:"inflight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TargetStatus) Reset() {
+	*x = TargetStatus{}
+	mi := &file_fusestream_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TargetStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TargetStatus) ProtoMessage() {}
+
+func (x *TargetStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[26]
 	if x != nil {
-		return x.PathRe
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TargetStatus.ProtoReflect.Descriptor instead.
+func (*TargetStatus) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *TargetStatus) GetName() string {
+	if x != nil {
+		return x.Name
 	}
 	return ""
 }
 
-func (x *DeleteFaultRequest) GetAll() bool {
+func (x *TargetStatus) GetSpec() isTargetStatus_Spec {
 	if x != nil {
-		return x.All
+		return x.Spec
+	}
+	return nil
+}
+
+func (x *TargetStatus) GetFuse() *FuseTarget {
+	if x != nil {
+		if x, ok := x.Spec.(*TargetStatus_Fuse); ok {
+			return x.Fuse
+		}
+	}
+	return nil
+}
+
+func (x *TargetStatus) GetNbd() *NbdTarget {
+	if x != nil {
+		if x, ok := x.Spec.(*TargetStatus_Nbd); ok {
+			return x.Nbd
+		}
+	}
+	return nil
+}
+
+func (x *TargetStatus) GetStartTimeNs() int64 {
+	if x != nil {
+		return x.StartTimeNs
+	}
+	return 0
+}
+
+func (x *TargetStatus) GetFaults() int32 {
+	if x != nil {
+		return x.Faults
+	}
+	return 0
+}
+
+func (x *TargetStatus) GetPaused() bool {
+	if x != nil {
+		return x.Paused
 	}
 	return false
 }
 
-type DeleteFaultResponse struct {
+func (x *TargetStatus) GetOps() int64 {
+	if x != nil {
+		return x.Ops
+	}
+	return 0
+}
+
+func (x *TargetStatus) GetInflight() int32 {
+	if x != nil {
+		return x.Inflight
+	}
+	return 0
+}
+
+type isTargetStatus_Spec interface {
+	isTargetStatus_Spec()
+}
+
+type TargetStatus_Fuse struct {
+	Fuse *FuseTarget `protobuf:"bytes,2,opt,name=fuse,proto3,oneof"`
+}
+
+type TargetStatus_Nbd struct {
+	Nbd *NbdTarget `protobuf:"bytes,3,opt,name=nbd,proto3,oneof"`
+}
+
+func (*TargetStatus_Fuse) isTargetStatus_Spec() {}
+
+func (*TargetStatus_Nbd) isTargetStatus_Spec() {}
+
+type ListTargetsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	DeletedIds    []int32                `protobuf:"varint,1,rep,packed,name=deleted_ids,json=deletedIds,proto3" json:"deleted_ids,omitempty"`
+	Targets       []*TargetStatus        `protobuf:"bytes,1,rep,name=targets,proto3" json:"targets,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteFaultResponse) Reset() {
-	*x = DeleteFaultResponse{}
-	mi := &file_fusestream_proto_msgTypes[10]
+func (x *ListTargetsResponse) Reset() {
+	*x = ListTargetsResponse{}
+	mi := &file_fusestream_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteFaultResponse) String() string {
+func (x *ListTargetsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteFaultResponse) ProtoMessage() {}
+func (*ListTargetsResponse) ProtoMessage() {}
 
-func (x *DeleteFaultResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_fusestream_proto_msgTypes[10]
+func (x *ListTargetsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -904,39 +2408,44 @@ func (x *DeleteFaultResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteFaultResponse.ProtoReflect.Descriptor instead.
-func (*DeleteFaultResponse) Descriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use ListTargetsResponse.ProtoReflect.Descriptor instead.
+func (*ListTargetsResponse) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *DeleteFaultResponse) GetDeletedIds() []int32 {
+func (x *ListTargetsResponse) GetTargets() []*TargetStatus {
 	if x != nil {
-		return x.DeletedIds
+		return x.Targets
 	}
 	return nil
 }
 
-type Void struct {
+type ExporterInfo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExportPath    string                 `protobuf:"bytes,1,opt,name=export_path,json=exportPath,proto3" json:"export_path,omitempty"`
+	OtlpEndpoint  string                 `protobuf:"bytes,2,opt,name=otlp_endpoint,json=otlpEndpoint,proto3" json:"otlp_endpoint,omitempty"`
+	OtlpProtocol  string                 `protobuf:"bytes,3,opt,name=otlp_protocol,json=otlpProtocol,proto3" json:"otlp_protocol,omitempty"`
+	SampleRatio   float64                `protobuf:"fixed64,4,opt,name=sample_ratio,json=sampleRatio,proto3" json:"sample_ratio,omitempty"`
+	MetricsListen string                 `protobuf:"bytes,5,opt,name=metrics_listen,json=metricsListen,proto3" json:"metrics_listen,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Void) Reset() {
-	*x = Void{}
-	mi := &file_fusestream_proto_msgTypes[11]
+func (x *ExporterInfo) Reset() {
+	*x = ExporterInfo{}
+	mi := &file_fusestream_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Void) String() string {
+func (x *ExporterInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Void) ProtoMessage() {}
+func (*ExporterInfo) ProtoMessage() {}
 
-func (x *Void) ProtoReflect() protoreflect.Message {
-	mi := &file_fusestream_proto_msgTypes[11]
+func (x *ExporterInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -947,34 +2456,80 @@ func (x *Void) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Void.ProtoReflect.Descriptor instead.
-func (*Void) Descriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use ExporterInfo.ProtoReflect.Descriptor instead.
+func (*ExporterInfo) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{28}
 }
 
-type ListFaultsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	FuseFaults    []*FuseFault           `protobuf:"bytes,1,rep,name=fuse_faults,json=fuseFaults,proto3" json:"fuse_faults,omitempty"`
-	NbdFaults     []*NbdFault            `protobuf:"bytes,2,rep,name=nbd_faults,json=nbdFaults,proto3" json:"nbd_faults,omitempty"`
+func (x *ExporterInfo) GetExportPath() string {
+	if x != nil {
+		return x.ExportPath
+	}
+	return ""
+}
+
+func (x *ExporterInfo) GetOtlpEndpoint() string {
+	if x != nil {
+		return x.OtlpEndpoint
+	}
+	return ""
+}
+
+func (x *ExporterInfo) GetOtlpProtocol() string {
+	if x != nil {
+		return x.OtlpProtocol
+	}
+	return ""
+}
+
+func (x *ExporterInfo) GetSampleRatio() float64 {
+	if x != nil {
+		return x.SampleRatio
+	}
+	return 0
+}
+
+func (x *ExporterInfo) GetMetricsListen() string {
+	if x != nil {
+		return x.MetricsListen
+	}
+	return ""
+}
+
+type ServerInfo struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Version string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Mode    ServerMode             `protobuf:"varint,2,opt,name=mode,proto3,enum=slowio.proto.ServerMode" json:"mode,omitempty"`
+	// base_dir and mountpoint of FUSE mounts, backend_file and export of NBD exports
+	BaseDir     string `protobuf:"bytes,3,opt,name=base_dir,json=baseDir,proto3" json:"base_dir,omitempty"`
+	Mountpoint  string `protobuf:"bytes,4,opt,name=mountpoint,proto3" json:"mountpoint,omitempty"`
+	BackendFile string `protobuf:"bytes,5,opt,name=backend_file,json=backendFile,proto3" json:"backend_file,omitempty"`
+	Export      string `protobuf:"bytes,6,opt,name=export,proto3" json:"export,omitempty"`
+	StartTimeNs int64  `protobuf:"varint,7,opt,name=start_time_ns,json=startTimeNs,proto3" json:"start_time_ns,omitempty"`
+	UptimeNs    int64  `protobuf:"varint,8,opt,name=uptime_ns,json=uptimeNs,proto3" json:"uptime_ns,omitempty"`
+	// true while the mount or the export is serving, the gRPC health service reports SERVING then
+	Live          bool          `protobuf:"varint,9,opt,name=live,proto3" json:"live,omitempty"`
+	Exporter      *ExporterInfo `protobuf:"bytes,10,opt,name=exporter,proto3" json:"exporter,omitempty"`
+	Pid           int32         `protobuf:"varint,11,opt,name=pid,proto3" json:"pid,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListFaultsResponse) Reset() {
-	*x = ListFaultsResponse{}
-	mi := &file_fusestream_proto_msgTypes[12]
+func (x *ServerInfo) Reset() {
+	*x = ServerInfo{}
+	mi := &file_fusestream_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListFaultsResponse) String() string {
+func (x *ServerInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListFaultsResponse) ProtoMessage() {}
+func (*ServerInfo) ProtoMessage() {}
 
-func (x *ListFaultsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_fusestream_proto_msgTypes[12]
+func (x *ServerInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_fusestream_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -985,25 +2540,88 @@ func (x *ListFaultsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListFaultsResponse.ProtoReflect.Descriptor instead.
-func (*ListFaultsResponse) Descriptor() ([]byte, []int) {
-	return file_fusestream_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use ServerInfo.ProtoReflect.Descriptor instead.
+func (*ServerInfo) Descriptor() ([]byte, []int) {
+	return file_fusestream_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *ListFaultsResponse) GetFuseFaults() []*FuseFault {
+func (x *ServerInfo) GetVersion() string {
 	if x != nil {
-		return x.FuseFaults
+		return x.Version
 	}
-	return nil
+	return ""
 }
 
-func (x *ListFaultsResponse) GetNbdFaults() []*NbdFault {
+func (x *ServerInfo) GetMode() ServerMode {
 	if x != nil {
-		return x.NbdFaults
+		return x.Mode
+	}
+	return ServerMode_SERVER_MODE_UNKNOWN
+}
+
+func (x *ServerInfo) GetBaseDir() string {
+	if x != nil {
+		return x.BaseDir
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetMountpoint() string {
+	if x != nil {
+		return x.Mountpoint
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetBackendFile() string {
+	if x != nil {
+		return x.BackendFile
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetExport() string {
+	if x != nil {
+		return x.Export
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetStartTimeNs() int64 {
+	if x != nil {
+		return x.StartTimeNs
+	}
+	return 0
+}
+
+func (x *ServerInfo) GetUptimeNs() int64 {
+	if x != nil {
+		return x.UptimeNs
+	}
+	return 0
+}
+
+func (x *ServerInfo) GetLive() bool {
+	if x != nil {
+		return x.Live
+	}
+	return false
+}
+
+func (x *ServerInfo) GetExporter() *ExporterInfo {
+	if x != nil {
+		return x.Exporter
 	}
 	return nil
 }
 
+func (x *ServerInfo) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
 var File_fusestream_proto protoreflect.FileDescriptor
 
 const file_fusestream_proto_rawDesc = "" +
@@ -1015,20 +2633,38 @@ const file_fusestream_proto_rawDesc = "" +
 	"\n" +
 	"DelayFault\x12 \n" +
 	"\vpossibility\x18\x01 \x01(\x02R\vpossibility\x12\x19\n" +
-	"\bdelay_ms\x18\x02 \x01(\x03R\adelayMs\"\x80\x02\n" +
+	"\bdelay_ms\x18\x02 \x01(\x03R\adelayMs\"\xbf\x03\n" +
 	"\tFuseFault\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x17\n" +
 	"\apath_re\x18\x02 \x01(\tR\x06pathRe\x12$\n" +
 	"\x02op\x18\x03 \x01(\x0e2\x14.slowio.proto.FuseOpR\x02op\x12N\n" +
 	"\x12return_value_fault\x18\x04 \x01(\v2\x1e.slowio.proto.ReturnValueFaultH\x00R\x10returnValueFault\x12;\n" +
 	"\vdelay_fault\x18\x05 \x01(\v2\x18.slowio.proto.DelayFaultH\x01R\n" +
-	"delayFaultB\x0e\n" +
+	"delayFault\x12\x1d\n" +
+	"\aenabled\x18\x06 \x01(\bH\x02R\aenabled\x88\x01\x01\x125\n" +
+	"\aprocess\x18\a \x01(\v2\x1b.slowio.proto.ProcessFilterR\aprocess\x125\n" +
+	"\n" +
+	"open_flags\x18\b \x03(\x0e2\x16.slowio.proto.OpenFlagR\topenFlags\x12\x10\n" +
+	"\x03fhs\x18\t \x03(\x04R\x03fhs\x12\x12\n" +
+	"\x04hits\x18\n" +
+	" \x01(\x03R\x04hitsB\x0e\n" +
 	"\freturn_valueB\a\n" +
-	"\x05delay\"@\n" +
+	"\x05delayB\n" +
+	"\n" +
+	"\b_enabled\"\xb0\x01\n" +
+	"\rProcessFilter\x12\x15\n" +
+	"\x03pid\x18\x01 \x01(\x05H\x00R\x03pid\x88\x01\x01\x12)\n" +
+	"\x10include_children\x18\x02 \x01(\bR\x0fincludeChildren\x12\x15\n" +
+	"\x03uid\x18\x03 \x01(\rH\x01R\x03uid\x88\x01\x01\x12\x15\n" +
+	"\x03gid\x18\x04 \x01(\rH\x02R\x03gid\x88\x01\x01\x12\x17\n" +
+	"\acomm_re\x18\x05 \x01(\tR\x06commReB\x06\n" +
+	"\x04_pidB\x06\n" +
+	"\x04_uidB\x06\n" +
+	"\x04_gid\"@\n" +
 	"\n" +
 	"ErrorFault\x12 \n" +
 	"\vpossibility\x18\x01 \x01(\x02R\vpossibility\x12\x10\n" +
-	"\x03err\x18\x02 \x01(\tR\x03err\"\xd7\x02\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"\xb5\x04\n" +
 	"\bNbdFault\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12#\n" +
 	"\x02op\x18\x02 \x01(\x0e2\x13.slowio.proto.NbdOpR\x02op\x12 \n" +
@@ -1039,33 +2675,159 @@ const file_fusestream_proto_rawDesc = "" +
 	"\verror_fault\x18\x04 \x01(\v2\x18.slowio.proto.ErrorFaultH\x02R\n" +
 	"errorFault\x12;\n" +
 	"\vdelay_fault\x18\x05 \x01(\v2\x18.slowio.proto.DelayFaultH\x03R\n" +
-	"delayFaultB\n" +
+	"delayFault\x12\x1d\n" +
+	"\aenabled\x18\a \x01(\bH\x04R\aenabled\x88\x01\x01\x12\x1a\n" +
+	"\bpriority\x18\b \x01(\x05R\bpriority\x12!\n" +
+	"\foffset_start\x18\t \x01(\x03R\voffsetStart\x12\x1d\n" +
+	"\n" +
+	"offset_end\x18\n" +
+	" \x01(\x03R\toffsetEnd\x12?\n" +
+	"\fmatch_policy\x18\v \x01(\x0e2\x1c.slowio.proto.NbdMatchPolicyR\vmatchPolicy\x12\x12\n" +
+	"\x04hits\x18\f \x01(\x03R\x04hitsB\n" +
 	"\n" +
 	"\bpre_condB\x0e\n" +
 	"\freturn_valueB\x05\n" +
 	"\x03errB\a\n" +
-	"\x05delay\"G\n" +
+	"\x05delayB\n" +
+	"\n" +
+	"\b_enabled\"_\n" +
 	"\x16InjectFuseFaultRequest\x12-\n" +
-	"\x05fault\x18\x01 \x01(\v2\x17.slowio.proto.FuseFaultR\x05fault\")\n" +
+	"\x05fault\x18\x01 \x01(\v2\x17.slowio.proto.FuseFaultR\x05fault\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\"X\n" +
 	"\x17InjectFuseFaultResponse\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x05R\x02id\"E\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12-\n" +
+	"\x05fault\x18\x02 \x01(\v2\x17.slowio.proto.FuseFaultR\x05fault\"]\n" +
 	"\x15InjectNbdFaultRequest\x12,\n" +
-	"\x05fault\x18\x01 \x01(\v2\x16.slowio.proto.NbdFaultR\x05fault\"(\n" +
+	"\x05fault\x18\x01 \x01(\v2\x16.slowio.proto.NbdFaultR\x05fault\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\"V\n" +
 	"\x16InjectNbdFaultResponse\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x05R\x02id\"O\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12,\n" +
+	"\x05fault\x18\x02 \x01(\v2\x16.slowio.proto.NbdFaultR\x05fault\"\xc2\x01\n" +
 	"\x12DeleteFaultRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x03(\x05R\x02id\x12\x17\n" +
 	"\apath_re\x18\x02 \x01(\tR\x06pathRe\x12\x10\n" +
-	"\x03all\x18\x03 \x01(\bR\x03all\"6\n" +
+	"\x03all\x18\x03 \x01(\bR\x03all\x12-\n" +
+	"\afuse_op\x18\x04 \x01(\x0e2\x14.slowio.proto.FuseOpR\x06fuseOp\x12*\n" +
+	"\x06nbd_op\x18\x05 \x01(\x0e2\x13.slowio.proto.NbdOpR\x05nbdOp\x12\x16\n" +
+	"\x06target\x18\x06 \x01(\tR\x06target\"6\n" +
 	"\x13DeleteFaultResponse\x12\x1f\n" +
 	"\vdeleted_ids\x18\x01 \x03(\x05R\n" +
-	"deletedIds\"\x06\n" +
-	"\x04Void\"\x85\x01\n" +
+	"deletedIds\"Z\n" +
+	"\x16SetFaultEnabledRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x03(\x05R\x02id\x12\x18\n" +
+	"\aenabled\x18\x02 \x01(\bR\aenabled\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\":\n" +
+	"\x17SetFaultEnabledResponse\x12\x1f\n" +
+	"\vupdated_ids\x18\x01 \x03(\x05R\n" +
+	"updatedIds\"\x06\n" +
+	"\x04Void\"'\n" +
+	"\rTargetRequest\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\"\x9d\x01\n" +
 	"\x12ListFaultsResponse\x128\n" +
 	"\vfuse_faults\x18\x01 \x03(\v2\x17.slowio.proto.FuseFaultR\n" +
 	"fuseFaults\x125\n" +
 	"\n" +
-	"nbd_faults\x18\x02 \x03(\v2\x16.slowio.proto.NbdFaultR\tnbdFaults*\xa2\x03\n" +
+	"nbd_faults\x18\x02 \x03(\v2\x16.slowio.proto.NbdFaultR\tnbdFaults\x12\x16\n" +
+	"\x06paused\x18\x03 \x01(\bR\x06paused\"\xa5\x01\n" +
+	"\aOpStats\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\tR\x02op\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x16\n" +
+	"\x06errors\x18\x03 \x01(\x03R\x06errors\x12\x14\n" +
+	"\x05bytes\x18\x04 \x01(\x03R\x05bytes\x12\x1d\n" +
+	"\n" +
+	"elapsed_ns\x18\x05 \x01(\x03R\telapsedNs\x12'\n" +
+	"\x0flatency_buckets\x18\x06 \x03(\x03R\x0elatencyBuckets\"j\n" +
+	"\tPathStats\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x14\n" +
+	"\x05bytes\x18\x03 \x01(\x03R\x05bytes\x12\x1d\n" +
+	"\n" +
+	"elapsed_ns\x18\x04 \x01(\x03R\telapsedNs\"\xcb\x01\n" +
+	"\x10GetStatsResponse\x12\x17\n" +
+	"\atime_ns\x18\x01 \x01(\x03R\x06timeNs\x12*\n" +
+	"\x11latency_bounds_ns\x18\x02 \x03(\x03R\x0flatencyBoundsNs\x12'\n" +
+	"\x03ops\x18\x03 \x03(\v2\x15.slowio.proto.OpStatsR\x03ops\x12-\n" +
+	"\x05paths\x18\x04 \x03(\v2\x17.slowio.proto.PathStatsR\x05paths\x12\x1a\n" +
+	"\bdelaying\x18\x05 \x01(\x03R\bdelaying\"\xfa\x01\n" +
+	"\n" +
+	"InflightOp\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x0e\n" +
+	"\x02op\x18\x02 \x01(\tR\x02op\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06length\x18\x05 \x01(\x03R\x06length\x12\x10\n" +
+	"\x03pid\x18\x06 \x01(\x05R\x03pid\x12\"\n" +
+	"\rstart_time_ns\x18\a \x01(\x03R\vstartTimeNs\x12\x1b\n" +
+	"\tfault_ids\x18\b \x03(\x05R\bfaultIds\x12\x19\n" +
+	"\bdelay_ns\x18\t \x01(\x03R\adelayNs\x12\x1a\n" +
+	"\bdelaying\x18\n" +
+	" \x01(\bR\bdelaying\"[\n" +
+	"\x14ListInflightResponse\x12\x17\n" +
+	"\atime_ns\x18\x01 \x01(\x03R\x06timeNs\x12*\n" +
+	"\x03ops\x18\x02 \x03(\v2\x18.slowio.proto.InflightOpR\x03ops\"\xaf\x01\n" +
+	"\n" +
+	"FuseTarget\x12\x19\n" +
+	"\bbase_dir\x18\x01 \x01(\tR\abaseDir\x12\x1e\n" +
+	"\n" +
+	"mountpoint\x18\x02 \x01(\tR\n" +
+	"mountpoint\x12#\n" +
+	"\rmount_options\x18\x03 \x03(\tR\fmountOptions\x12\x17\n" +
+	"\ause_ino\x18\x04 \x01(\bR\x06useIno\x12(\n" +
+	"\x10drain_timeout_ms\x18\x05 \x01(\x03R\x0edrainTimeoutMs\"\x95\x01\n" +
+	"\tNbdTarget\x12!\n" +
+	"\fbackend_file\x18\x01 \x01(\tR\vbackendFile\x12\x16\n" +
+	"\x06export\x18\x02 \x01(\tR\x06export\x12\x18\n" +
+	"\anetwork\x18\x03 \x01(\tR\anetwork\x12\x16\n" +
+	"\x06listen\x18\x04 \x01(\tR\x06listen\x12\x1b\n" +
+	"\tread_only\x18\x05 \x01(\bR\breadOnly\"\x87\x01\n" +
+	"\fMountRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12.\n" +
+	"\x04fuse\x18\x02 \x01(\v2\x18.slowio.proto.FuseTargetH\x00R\x04fuse\x12+\n" +
+	"\x03nbd\x18\x03 \x01(\v2\x17.slowio.proto.NbdTargetH\x00R\x03nbdB\x06\n" +
+	"\x04spec\"$\n" +
+	"\x0eUnmountRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x89\x02\n" +
+	"\fTargetStatus\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12.\n" +
+	"\x04fuse\x18\x02 \x01(\v2\x18.slowio.proto.FuseTargetH\x00R\x04fuse\x12+\n" +
+	"\x03nbd\x18\x03 \x01(\v2\x17.slowio.proto.NbdTargetH\x00R\x03nbd\x12\"\n" +
+	"\rstart_time_ns\x18\x04 \x01(\x03R\vstartTimeNs\x12\x16\n" +
+	"\x06faults\x18\x05 \x01(\x05R\x06faults\x12\x16\n" +
+	"\x06paused\x18\x06 \x01(\bR\x06paused\x12\x10\n" +
+	"\x03ops\x18\a \x01(\x03R\x03ops\x12\x1a\n" +
+	"\binflight\x18\b \x01(\x05R\binflightB\x06\n" +
+	"\x04spec\"K\n" +
+	"\x13ListTargetsResponse\x124\n" +
+	"\atargets\x18\x01 \x03(\v2\x1a.slowio.proto.TargetStatusR\atargets\"\xc3\x01\n" +
+	"\fExporterInfo\x12\x1f\n" +
+	"\vexport_path\x18\x01 \x01(\tR\n" +
+	"exportPath\x12#\n" +
+	"\rotlp_endpoint\x18\x02 \x01(\tR\fotlpEndpoint\x12#\n" +
+	"\rotlp_protocol\x18\x03 \x01(\tR\fotlpProtocol\x12!\n" +
+	"\fsample_ratio\x18\x04 \x01(\x01R\vsampleRatio\x12%\n" +
+	"\x0emetrics_listen\x18\x05 \x01(\tR\rmetricsListen\"\xe9\x02\n" +
+	"\n" +
+	"ServerInfo\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12,\n" +
+	"\x04mode\x18\x02 \x01(\x0e2\x18.slowio.proto.ServerModeR\x04mode\x12\x19\n" +
+	"\bbase_dir\x18\x03 \x01(\tR\abaseDir\x12\x1e\n" +
+	"\n" +
+	"mountpoint\x18\x04 \x01(\tR\n" +
+	"mountpoint\x12!\n" +
+	"\fbackend_file\x18\x05 \x01(\tR\vbackendFile\x12\x16\n" +
+	"\x06export\x18\x06 \x01(\tR\x06export\x12\"\n" +
+	"\rstart_time_ns\x18\a \x01(\x03R\vstartTimeNs\x12\x1b\n" +
+	"\tuptime_ns\x18\b \x01(\x03R\buptimeNs\x12\x12\n" +
+	"\x04live\x18\t \x01(\bR\x04live\x126\n" +
+	"\bexporter\x18\n" +
+	" \x01(\v2\x1a.slowio.proto.ExporterInfoR\bexporter\x12\x10\n" +
+	"\x03pid\x18\v \x01(\x05R\x03pid*h\n" +
+	"\n" +
+	"ServerMode\x12\x17\n" +
+	"\x13SERVER_MODE_UNKNOWN\x10\x00\x12\x14\n" +
+	"\x10SERVER_MODE_FUSE\x10\x01\x12\x13\n" +
+	"\x0fSERVER_MODE_NBD\x10\x02\x12\x16\n" +
+	"\x12SERVER_MODE_DAEMON\x10\x03*\xa2\x03\n" +
 	"\x06FuseOp\x12\x10\n" +
 	"\fFUSE_UNKNOWN\x10\x00\x12\x0f\n" +
 	"\vFUSE_STATFS\x10\x01\x12\x0e\n" +
@@ -1098,21 +2860,43 @@ const file_fusestream_proto_rawDesc = "" +
 	"FUSE_FSYNC\x10\x14\x12\x10\n" +
 	"\fFUSE_OPENDIR\x10\x15\x12\x10\n" +
 	"\fFUSE_READDIR\x10\x16\x12\x13\n" +
-	"\x0fFUSE_RELEASEDIR\x10\x17*U\n" +
+	"\x0fFUSE_RELEASEDIR\x10\x17*\x8b\x01\n" +
+	"\bOpenFlag\x12\x15\n" +
+	"\x11OPEN_FLAG_UNKNOWN\x10\x00\x12\x14\n" +
+	"\x10OPEN_FLAG_DIRECT\x10\x01\x12\x12\n" +
+	"\x0eOPEN_FLAG_SYNC\x10\x02\x12\x13\n" +
+	"\x0fOPEN_FLAG_DSYNC\x10\x03\x12\x14\n" +
+	"\x10OPEN_FLAG_APPEND\x10\x04\x12\x13\n" +
+	"\x0fOPEN_FLAG_TRUNC\x10\x05*8\n" +
+	"\x0eNbdMatchPolicy\x12\x13\n" +
+	"\x0fNBD_MATCH_FIRST\x10\x00\x12\x11\n" +
+	"\rNBD_MATCH_ALL\x10\x01*U\n" +
 	"\x05NbdOp\x12\x0f\n" +
 	"\vNBD_UNKNOWN\x10\x00\x12\x0e\n" +
 	"\n" +
 	"NBD_READAT\x10\x01\x12\x0f\n" +
 	"\vNBD_WRITEAT\x10\x02\x12\f\n" +
 	"\bNBD_SIZE\x10\x03\x12\f\n" +
-	"\bNBD_SYNC\x10\x042\xe1\x02\n" +
+	"\bNBD_SYNC\x10\x042\xda\x06\n" +
 	"\n" +
-	"FuseStream\x12B\n" +
+	"FuseStream\x12K\n" +
 	"\n" +
-	"ListFaults\x12\x12.slowio.proto.Void\x1a .slowio.proto.ListFaultsResponse\x12R\n" +
+	"ListFaults\x12\x1b.slowio.proto.TargetRequest\x1a .slowio.proto.ListFaultsResponse\x12R\n" +
 	"\vDeleteFault\x12 .slowio.proto.DeleteFaultRequest\x1a!.slowio.proto.DeleteFaultResponse\x12^\n" +
 	"\x0fInjectFuseFault\x12$.slowio.proto.InjectFuseFaultRequest\x1a%.slowio.proto.InjectFuseFaultResponse\x12[\n" +
-	"\x0eInjectNbdFault\x12#.slowio.proto.InjectNbdFaultRequest\x1a$.slowio.proto.InjectNbdFaultResponseB$Z\"github.com/fanyang89/fusestream/pbb\x06proto3"
+	"\x0eInjectNbdFault\x12#.slowio.proto.InjectNbdFaultRequest\x1a$.slowio.proto.InjectNbdFaultResponse\x12^\n" +
+	"\x0fSetFaultEnabled\x12$.slowio.proto.SetFaultEnabledRequest\x1a%.slowio.proto.SetFaultEnabledResponse\x12;\n" +
+	"\bPauseAll\x12\x1b.slowio.proto.TargetRequest\x1a\x12.slowio.proto.Void\x12<\n" +
+	"\tResumeAll\x12\x1b.slowio.proto.TargetRequest\x1a\x12.slowio.proto.Void\x12G\n" +
+	"\bGetStats\x12\x1b.slowio.proto.TargetRequest\x1a\x1e.slowio.proto.GetStatsResponse\x12O\n" +
+	"\fListInflight\x12\x1b.slowio.proto.TargetRequest\x1a\".slowio.proto.ListInflightResponse\x12:\n" +
+	"\aUnmount\x12\x1b.slowio.proto.TargetRequest\x1a\x12.slowio.proto.Void\x12=\n" +
+	"\rGetServerInfo\x12\x12.slowio.proto.Void\x1a\x18.slowio.proto.ServerInfo2\x98\x02\n" +
+	"\x06Daemon\x12?\n" +
+	"\x05Mount\x12\x1a.slowio.proto.MountRequest\x1a\x1a.slowio.proto.TargetStatus\x12;\n" +
+	"\aUnmount\x12\x1c.slowio.proto.UnmountRequest\x1a\x12.slowio.proto.Void\x12D\n" +
+	"\vListTargets\x12\x12.slowio.proto.Void\x1a!.slowio.proto.ListTargetsResponse\x12J\n" +
+	"\x0fGetTargetStatus\x12\x1b.slowio.proto.TargetRequest\x1a\x1a.slowio.proto.TargetStatusB$Z\"github.com/fanyang89/fusestream/pbb\x06proto3"
 
 var (
 	file_fusestream_proto_rawDescOnce sync.Once
@@ -1126,50 +2910,109 @@ func file_fusestream_proto_rawDescGZIP() []byte {
 	return file_fusestream_proto_rawDescData
 }
 
-var file_fusestream_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_fusestream_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_fusestream_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_fusestream_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
 var file_fusestream_proto_goTypes = []any{
-	(FuseOp)(0),                     // 0: slowio.proto.FuseOp
-	(NbdOp)(0),                      // 1: slowio.proto.NbdOp
-	(*ReturnValueFault)(nil),        // 2: slowio.proto.ReturnValueFault
-	(*DelayFault)(nil),              // 3: slowio.proto.DelayFault
-	(*FuseFault)(nil),               // 4: slowio.proto.FuseFault
-	(*ErrorFault)(nil),              // 5: slowio.proto.ErrorFault
-	(*NbdFault)(nil),                // 6: slowio.proto.NbdFault
-	(*InjectFuseFaultRequest)(nil),  // 7: slowio.proto.InjectFuseFaultRequest
-	(*InjectFuseFaultResponse)(nil), // 8: slowio.proto.InjectFuseFaultResponse
-	(*InjectNbdFaultRequest)(nil),   // 9: slowio.proto.InjectNbdFaultRequest
-	(*InjectNbdFaultResponse)(nil),  // 10: slowio.proto.InjectNbdFaultResponse
-	(*DeleteFaultRequest)(nil),      // 11: slowio.proto.DeleteFaultRequest
-	(*DeleteFaultResponse)(nil),     // 12: slowio.proto.DeleteFaultResponse
-	(*Void)(nil),                    // 13: slowio.proto.Void
-	(*ListFaultsResponse)(nil),      // 14: slowio.proto.ListFaultsResponse
+	(ServerMode)(0),                 // 0: slowio.proto.ServerMode
+	(FuseOp)(0),                     // 1: slowio.proto.FuseOp
+	(OpenFlag)(0),                   // 2: slowio.proto.OpenFlag
+	(NbdMatchPolicy)(0),             // 3: slowio.proto.NbdMatchPolicy
+	(NbdOp)(0),                      // 4: slowio.proto.NbdOp
+	(*ReturnValueFault)(nil),        // 5: slowio.proto.ReturnValueFault
+	(*DelayFault)(nil),              // 6: slowio.proto.DelayFault
+	(*FuseFault)(nil),               // 7: slowio.proto.FuseFault
+	(*ProcessFilter)(nil),           // 8: slowio.proto.ProcessFilter
+	(*ErrorFault)(nil),              // 9: slowio.proto.ErrorFault
+	(*NbdFault)(nil),                // 10: slowio.proto.NbdFault
+	(*InjectFuseFaultRequest)(nil),  // 11: slowio.proto.InjectFuseFaultRequest
+	(*InjectFuseFaultResponse)(nil), // 12: slowio.proto.InjectFuseFaultResponse
+	(*InjectNbdFaultRequest)(nil),   // 13: slowio.proto.InjectNbdFaultRequest
+	(*InjectNbdFaultResponse)(nil),  // 14: slowio.proto.InjectNbdFaultResponse
+	(*DeleteFaultRequest)(nil),      // 15: slowio.proto.DeleteFaultRequest
+	(*DeleteFaultResponse)(nil),     // 16: slowio.proto.DeleteFaultResponse
+	(*SetFaultEnabledRequest)(nil),  // 17: slowio.proto.SetFaultEnabledRequest
+	(*SetFaultEnabledResponse)(nil), // 18: slowio.proto.SetFaultEnabledResponse
+	(*Void)(nil),                    // 19: slowio.proto.Void
+	(*TargetRequest)(nil),           // 20: slowio.proto.TargetRequest
+	(*ListFaultsResponse)(nil),      // 21: slowio.proto.ListFaultsResponse
+	(*OpStats)(nil),                 // 22: slowio.proto.OpStats
+	(*PathStats)(nil),               // 23: slowio.proto.PathStats
+	(*GetStatsResponse)(nil),        // 24: slowio.proto.GetStatsResponse
+	(*InflightOp)(nil),              // 25: slowio.proto.InflightOp
+	(*ListInflightResponse)(nil),    // 26: slowio.proto.ListInflightResponse
+	(*FuseTarget)(nil),              // 27: slowio.proto.FuseTarget
+	(*NbdTarget)(nil),               // 28: slowio.proto.NbdTarget
+	(*MountRequest)(nil),            // 29: slowio.proto.MountRequest
+	(*UnmountRequest)(nil),          // 30: slowio.proto.UnmountRequest
+	(*TargetStatus)(nil),            // 31: slowio.proto.TargetStatus
+	(*ListTargetsResponse)(nil),     // 32: slowio.proto.ListTargetsResponse
+	(*ExporterInfo)(nil),            // 33: slowio.proto.ExporterInfo
+	(*ServerInfo)(nil),              // 34: slowio.proto.ServerInfo
 }
 var file_fusestream_proto_depIdxs = []int32{
-	0,  // 0: slowio.proto.FuseFault.op:type_name -> slowio.proto.FuseOp
-	2,  // 1: slowio.proto.FuseFault.return_value_fault:type_name -> slowio.proto.ReturnValueFault
-	3,  // 2: slowio.proto.FuseFault.delay_fault:type_name -> slowio.proto.DelayFault
-	1,  // 3: slowio.proto.NbdFault.op:type_name -> slowio.proto.NbdOp
-	2,  // 4: slowio.proto.NbdFault.return_value_fault:type_name -> slowio.proto.ReturnValueFault
-	5,  // 5: slowio.proto.NbdFault.error_fault:type_name -> slowio.proto.ErrorFault
-	3,  // 6: slowio.proto.NbdFault.delay_fault:type_name -> slowio.proto.DelayFault
-	4,  // 7: slowio.proto.InjectFuseFaultRequest.fault:type_name -> slowio.proto.FuseFault
-	6,  // 8: slowio.proto.InjectNbdFaultRequest.fault:type_name -> slowio.proto.NbdFault
-	4,  // 9: slowio.proto.ListFaultsResponse.fuse_faults:type_name -> slowio.proto.FuseFault
-	6,  // 10: slowio.proto.ListFaultsResponse.nbd_faults:type_name -> slowio.proto.NbdFault
-	13, // 11: slowio.proto.FuseStream.ListFaults:input_type -> slowio.proto.Void
-	11, // 12: slowio.proto.FuseStream.DeleteFault:input_type -> slowio.proto.DeleteFaultRequest
-	7,  // 13: slowio.proto.FuseStream.InjectFuseFault:input_type -> slowio.proto.InjectFuseFaultRequest
-	9,  // 14: slowio.proto.FuseStream.InjectNbdFault:input_type -> slowio.proto.InjectNbdFaultRequest
-	14, // 15: slowio.proto.FuseStream.ListFaults:output_type -> slowio.proto.ListFaultsResponse
-	12, // 16: slowio.proto.FuseStream.DeleteFault:output_type -> slowio.proto.DeleteFaultResponse
-	8,  // 17: slowio.proto.FuseStream.InjectFuseFault:output_type -> slowio.proto.InjectFuseFaultResponse
-	10, // 18: slowio.proto.FuseStream.InjectNbdFault:output_type -> slowio.proto.InjectNbdFaultResponse
-	15, // [15:19] is the sub-list for method output_type
-	11, // [11:15] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	1,  // 0: slowio.proto.FuseFault.op:type_name -> slowio.proto.FuseOp
+	5,  // 1: slowio.proto.FuseFault.return_value_fault:type_name -> slowio.proto.ReturnValueFault
+	6,  // 2: slowio.proto.FuseFault.delay_fault:type_name -> slowio.proto.DelayFault
+	8,  // 3: slowio.proto.FuseFault.process:type_name -> slowio.proto.ProcessFilter
+	2,  // 4: slowio.proto.FuseFault.open_flags:type_name -> slowio.proto.OpenFlag
+	4,  // 5: slowio.proto.NbdFault.op:type_name -> slowio.proto.NbdOp
+	5,  // 6: slowio.proto.NbdFault.return_value_fault:type_name -> slowio.proto.ReturnValueFault
+	9,  // 7: slowio.proto.NbdFault.error_fault:type_name -> slowio.proto.ErrorFault
+	6,  // 8: slowio.proto.NbdFault.delay_fault:type_name -> slowio.proto.DelayFault
+	3,  // 9: slowio.proto.NbdFault.match_policy:type_name -> slowio.proto.NbdMatchPolicy
+	7,  // 10: slowio.proto.InjectFuseFaultRequest.fault:type_name -> slowio.proto.FuseFault
+	7,  // 11: slowio.proto.InjectFuseFaultResponse.fault:type_name -> slowio.proto.FuseFault
+	10, // 12: slowio.proto.InjectNbdFaultRequest.fault:type_name -> slowio.proto.NbdFault
+	10, // 13: slowio.proto.InjectNbdFaultResponse.fault:type_name -> slowio.proto.NbdFault
+	1,  // 14: slowio.proto.DeleteFaultRequest.fuse_op:type_name -> slowio.proto.FuseOp
+	4,  // 15: slowio.proto.DeleteFaultRequest.nbd_op:type_name -> slowio.proto.NbdOp
+	7,  // 16: slowio.proto.ListFaultsResponse.fuse_faults:type_name -> slowio.proto.FuseFault
+	10, // 17: slowio.proto.ListFaultsResponse.nbd_faults:type_name -> slowio.proto.NbdFault
+	22, // 18: slowio.proto.GetStatsResponse.ops:type_name -> slowio.proto.OpStats
+	23, // 19: slowio.proto.GetStatsResponse.paths:type_name -> slowio.proto.PathStats
+	25, // 20: slowio.proto.ListInflightResponse.ops:type_name -> slowio.proto.InflightOp
+	27, // 21: slowio.proto.MountRequest.fuse:type_name -> slowio.proto.FuseTarget
+	28, // 22: slowio.proto.MountRequest.nbd:type_name -> slowio.proto.NbdTarget
+	27, // 23: slowio.proto.TargetStatus.fuse:type_name -> slowio.proto.FuseTarget
+	28, // 24: slowio.proto.TargetStatus.nbd:type_name -> slowio.proto.NbdTarget
+	31, // 25: slowio.proto.ListTargetsResponse.targets:type_name -> slowio.proto.TargetStatus
+	0,  // 26: slowio.proto.ServerInfo.mode:type_name -> slowio.proto.ServerMode
+	33, // 27: slowio.proto.ServerInfo.exporter:type_name -> slowio.proto.ExporterInfo
+	20, // 28: slowio.proto.FuseStream.ListFaults:input_type -> slowio.proto.TargetRequest
+	15, // 29: slowio.proto.FuseStream.DeleteFault:input_type -> slowio.proto.DeleteFaultRequest
+	11, // 30: slowio.proto.FuseStream.InjectFuseFault:input_type -> slowio.proto.InjectFuseFaultRequest
+	13, // 31: slowio.proto.FuseStream.InjectNbdFault:input_type -> slowio.proto.InjectNbdFaultRequest
+	17, // 32: slowio.proto.FuseStream.SetFaultEnabled:input_type -> slowio.proto.SetFaultEnabledRequest
+	20, // 33: slowio.proto.FuseStream.PauseAll:input_type -> slowio.proto.TargetRequest
+	20, // 34: slowio.proto.FuseStream.ResumeAll:input_type -> slowio.proto.TargetRequest
+	20, // 35: slowio.proto.FuseStream.GetStats:input_type -> slowio.proto.TargetRequest
+	20, // 36: slowio.proto.FuseStream.ListInflight:input_type -> slowio.proto.TargetRequest
+	20, // 37: slowio.proto.FuseStream.Unmount:input_type -> slowio.proto.TargetRequest
+	19, // 38: slowio.proto.FuseStream.GetServerInfo:input_type -> slowio.proto.Void
+	29, // 39: slowio.proto.Daemon.Mount:input_type -> slowio.proto.MountRequest
+	30, // 40: slowio.proto.Daemon.Unmount:input_type -> slowio.proto.UnmountRequest
+	19, // 41: slowio.proto.Daemon.ListTargets:input_type -> slowio.proto.Void
+	20, // 42: slowio.proto.Daemon.GetTargetStatus:input_type -> slowio.proto.TargetRequest
+	21, // 43: slowio.proto.FuseStream.ListFaults:output_type -> slowio.proto.ListFaultsResponse
+	16, // 44: slowio.proto.FuseStream.DeleteFault:output_type -> slowio.proto.DeleteFaultResponse
+	12, // 45: slowio.proto.FuseStream.InjectFuseFault:output_type -> slowio.proto.InjectFuseFaultResponse
+	14, // 46: slowio.proto.FuseStream.InjectNbdFault:output_type -> slowio.proto.InjectNbdFaultResponse
+	18, // 47: slowio.proto.FuseStream.SetFaultEnabled:output_type -> slowio.proto.SetFaultEnabledResponse
+	19, // 48: slowio.proto.FuseStream.PauseAll:output_type -> slowio.proto.Void
+	19, // 49: slowio.proto.FuseStream.ResumeAll:output_type -> slowio.proto.Void
+	24, // 50: slowio.proto.FuseStream.GetStats:output_type -> slowio.proto.GetStatsResponse
+	26, // 51: slowio.proto.FuseStream.ListInflight:output_type -> slowio.proto.ListInflightResponse
+	19, // 52: slowio.proto.FuseStream.Unmount:output_type -> slowio.proto.Void
+	34, // 53: slowio.proto.FuseStream.GetServerInfo:output_type -> slowio.proto.ServerInfo
+	31, // 54: slowio.proto.Daemon.Mount:output_type -> slowio.proto.TargetStatus
+	19, // 55: slowio.proto.Daemon.Unmount:output_type -> slowio.proto.Void
+	32, // 56: slowio.proto.Daemon.ListTargets:output_type -> slowio.proto.ListTargetsResponse
+	31, // 57: slowio.proto.Daemon.GetTargetStatus:output_type -> slowio.proto.TargetStatus
+	43, // [43:58] is the sub-list for method output_type
+	28, // [28:43] is the sub-list for method input_type
+	28, // [28:28] is the sub-list for extension type_name
+	28, // [28:28] is the sub-list for extension extendee
+	0,  // [0:28] is the sub-list for field type_name
 }
 
 func init() { file_fusestream_proto_init() }
@@ -1181,21 +3024,30 @@ func file_fusestream_proto_init() {
 		(*FuseFault_ReturnValueFault)(nil),
 		(*FuseFault_DelayFault)(nil),
 	}
-	file_fusestream_proto_msgTypes[4].OneofWrappers = []any{
+	file_fusestream_proto_msgTypes[3].OneofWrappers = []any{}
+	file_fusestream_proto_msgTypes[5].OneofWrappers = []any{
 		(*NbdFault_Expression)(nil),
 		(*NbdFault_ReturnValueFault)(nil),
 		(*NbdFault_ErrorFault)(nil),
 		(*NbdFault_DelayFault)(nil),
 	}
+	file_fusestream_proto_msgTypes[24].OneofWrappers = []any{
+		(*MountRequest_Fuse)(nil),
+		(*MountRequest_Nbd)(nil),
+	}
+	file_fusestream_proto_msgTypes[26].OneofWrappers = []any{
+		(*TargetStatus_Fuse)(nil),
+		(*TargetStatus_Nbd)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_fusestream_proto_rawDesc), len(file_fusestream_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   13,
+			NumEnums:      5,
+			NumMessages:   30,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_fusestream_proto_goTypes,
 		DependencyIndexes: file_fusestream_proto_depIdxs,
@@ -23,16 +23,34 @@ const (
 	FuseStream_DeleteFault_FullMethodName     = "/slowio.proto.FuseStream/DeleteFault"
 	FuseStream_InjectFuseFault_FullMethodName = "/slowio.proto.FuseStream/InjectFuseFault"
 	FuseStream_InjectNbdFault_FullMethodName  = "/slowio.proto.FuseStream/InjectNbdFault"
+	FuseStream_SetFaultEnabled_FullMethodName = "/slowio.proto.FuseStream/SetFaultEnabled"
+	FuseStream_PauseAll_FullMethodName        = "/slowio.proto.FuseStream/PauseAll"
+	FuseStream_ResumeAll_FullMethodName       = "/slowio.proto.FuseStream/ResumeAll"
+	FuseStream_GetStats_FullMethodName        = "/slowio.proto.FuseStream/GetStats"
+	FuseStream_ListInflight_FullMethodName    = "/slowio.proto.FuseStream/ListInflight"
+	FuseStream_Unmount_FullMethodName         = "/slowio.proto.FuseStream/Unmount"
+	FuseStream_GetServerInfo_FullMethodName   = "/slowio.proto.FuseStream/GetServerInfo"
 )
 
 // FuseStreamClient is the client API for FuseStream service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// FuseStream controls the faults of a served mount or export. Requests of the daemon name the target, the single
+// target servers of fuse mount and nbd serve only accept an empty target
 type FuseStreamClient interface {
-	ListFaults(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ListFaultsResponse, error)
+	ListFaults(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*ListFaultsResponse, error)
 	DeleteFault(ctx context.Context, in *DeleteFaultRequest, opts ...grpc.CallOption) (*DeleteFaultResponse, error)
 	InjectFuseFault(ctx context.Context, in *InjectFuseFaultRequest, opts ...grpc.CallOption) (*InjectFuseFaultResponse, error)
 	InjectNbdFault(ctx context.Context, in *InjectNbdFaultRequest, opts ...grpc.CallOption) (*InjectNbdFaultResponse, error)
+	SetFaultEnabled(ctx context.Context, in *SetFaultEnabledRequest, opts ...grpc.CallOption) (*SetFaultEnabledResponse, error)
+	PauseAll(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*Void, error)
+	ResumeAll(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*Void, error)
+	GetStats(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	ListInflight(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*ListInflightResponse, error)
+	// Unmount drains the in-flight operations and unmounts the mount, or stops serving the export
+	Unmount(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*Void, error)
+	GetServerInfo(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ServerInfo, error)
 }
 
 type fuseStreamClient struct {
@@ -43,7 +61,7 @@ func NewFuseStreamClient(cc grpc.ClientConnInterface) FuseStreamClient {
 	return &fuseStreamClient{cc}
 }
 
-func (c *fuseStreamClient) ListFaults(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ListFaultsResponse, error) {
+func (c *fuseStreamClient) ListFaults(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*ListFaultsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListFaultsResponse)
 	err := c.cc.Invoke(ctx, FuseStream_ListFaults_FullMethodName, in, out, cOpts...)
@@ -83,14 +101,95 @@ func (c *fuseStreamClient) InjectNbdFault(ctx context.Context, in *InjectNbdFaul
 	return out, nil
 }
 
+func (c *fuseStreamClient) SetFaultEnabled(ctx context.Context, in *SetFaultEnabledRequest, opts ...grpc.CallOption) (*SetFaultEnabledResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetFaultEnabledResponse)
+	err := c.cc.Invoke(ctx, FuseStream_SetFaultEnabled_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fuseStreamClient) PauseAll(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*Void, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Void)
+	err := c.cc.Invoke(ctx, FuseStream_PauseAll_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fuseStreamClient) ResumeAll(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*Void, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Void)
+	err := c.cc.Invoke(ctx, FuseStream_ResumeAll_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fuseStreamClient) GetStats(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, FuseStream_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fuseStreamClient) ListInflight(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*ListInflightResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListInflightResponse)
+	err := c.cc.Invoke(ctx, FuseStream_ListInflight_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fuseStreamClient) Unmount(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*Void, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Void)
+	err := c.cc.Invoke(ctx, FuseStream_Unmount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fuseStreamClient) GetServerInfo(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ServerInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ServerInfo)
+	err := c.cc.Invoke(ctx, FuseStream_GetServerInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FuseStreamServer is the server API for FuseStream service.
 // All implementations must embed UnimplementedFuseStreamServer
 // for forward compatibility.
+//
+// FuseStream controls the faults of a served mount or export. Requests of the daemon name the target, the single
+// target servers of fuse mount and nbd serve only accept an empty target
 type FuseStreamServer interface {
-	ListFaults(context.Context, *Void) (*ListFaultsResponse, error)
+	ListFaults(context.Context, *TargetRequest) (*ListFaultsResponse, error)
 	DeleteFault(context.Context, *DeleteFaultRequest) (*DeleteFaultResponse, error)
 	InjectFuseFault(context.Context, *InjectFuseFaultRequest) (*InjectFuseFaultResponse, error)
 	InjectNbdFault(context.Context, *InjectNbdFaultRequest) (*InjectNbdFaultResponse, error)
+	SetFaultEnabled(context.Context, *SetFaultEnabledRequest) (*SetFaultEnabledResponse, error)
+	PauseAll(context.Context, *TargetRequest) (*Void, error)
+	ResumeAll(context.Context, *TargetRequest) (*Void, error)
+	GetStats(context.Context, *TargetRequest) (*GetStatsResponse, error)
+	ListInflight(context.Context, *TargetRequest) (*ListInflightResponse, error)
+	// Unmount drains the in-flight operations and unmounts the mount, or stops serving the export
+	Unmount(context.Context, *TargetRequest) (*Void, error)
+	GetServerInfo(context.Context, *Void) (*ServerInfo, error)
 	mustEmbedUnimplementedFuseStreamServer()
 }
 
@@ -101,7 +200,7 @@ type FuseStreamServer interface {
 // pointer dereference when methods are called.
 type UnimplementedFuseStreamServer struct{}
 
-func (UnimplementedFuseStreamServer) ListFaults(context.Context, *Void) (*ListFaultsResponse, error) {
+func (UnimplementedFuseStreamServer) ListFaults(context.Context, *TargetRequest) (*ListFaultsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListFaults not implemented")
 }
 func (UnimplementedFuseStreamServer) DeleteFault(context.Context, *DeleteFaultRequest) (*DeleteFaultResponse, error) {
@@ -113,6 +212,27 @@ func (UnimplementedFuseStreamServer) InjectFuseFault(context.Context, *InjectFus
 func (UnimplementedFuseStreamServer) InjectNbdFault(context.Context, *InjectNbdFaultRequest) (*InjectNbdFaultResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method InjectNbdFault not implemented")
 }
+func (UnimplementedFuseStreamServer) SetFaultEnabled(context.Context, *SetFaultEnabledRequest) (*SetFaultEnabledResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFaultEnabled not implemented")
+}
+func (UnimplementedFuseStreamServer) PauseAll(context.Context, *TargetRequest) (*Void, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseAll not implemented")
+}
+func (UnimplementedFuseStreamServer) ResumeAll(context.Context, *TargetRequest) (*Void, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeAll not implemented")
+}
+func (UnimplementedFuseStreamServer) GetStats(context.Context, *TargetRequest) (*GetStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedFuseStreamServer) ListInflight(context.Context, *TargetRequest) (*ListInflightResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInflight not implemented")
+}
+func (UnimplementedFuseStreamServer) Unmount(context.Context, *TargetRequest) (*Void, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unmount not implemented")
+}
+func (UnimplementedFuseStreamServer) GetServerInfo(context.Context, *Void) (*ServerInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServerInfo not implemented")
+}
 func (UnimplementedFuseStreamServer) mustEmbedUnimplementedFuseStreamServer() {}
 func (UnimplementedFuseStreamServer) testEmbeddedByValue()                    {}
 
@@ -135,7 +255,7 @@ func RegisterFuseStreamServer(s grpc.ServiceRegistrar, srv FuseStreamServer) {
 }
 
 func _FuseStream_ListFaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Void)
+	in := new(TargetRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -147,7 +267,7 @@ func _FuseStream_ListFaults_Handler(srv interface{}, ctx context.Context, dec fu
 		FullMethod: FuseStream_ListFaults_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FuseStreamServer).ListFaults(ctx, req.(*Void))
+		return srv.(FuseStreamServer).ListFaults(ctx, req.(*TargetRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -206,6 +326,132 @@ func _FuseStream_InjectNbdFault_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FuseStream_SetFaultEnabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetFaultEnabledRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FuseStreamServer).SetFaultEnabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FuseStream_SetFaultEnabled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FuseStreamServer).SetFaultEnabled(ctx, req.(*SetFaultEnabledRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FuseStream_PauseAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FuseStreamServer).PauseAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FuseStream_PauseAll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FuseStreamServer).PauseAll(ctx, req.(*TargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FuseStream_ResumeAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FuseStreamServer).ResumeAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FuseStream_ResumeAll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FuseStreamServer).ResumeAll(ctx, req.(*TargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FuseStream_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FuseStreamServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FuseStream_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FuseStreamServer).GetStats(ctx, req.(*TargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FuseStream_ListInflight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FuseStreamServer).ListInflight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FuseStream_ListInflight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FuseStreamServer).ListInflight(ctx, req.(*TargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FuseStream_Unmount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FuseStreamServer).Unmount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FuseStream_Unmount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FuseStreamServer).Unmount(ctx, req.(*TargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FuseStream_GetServerInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Void)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FuseStreamServer).GetServerInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FuseStream_GetServerInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FuseStreamServer).GetServerInfo(ctx, req.(*Void))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // FuseStream_ServiceDesc is the grpc.ServiceDesc for FuseStream service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -229,6 +475,254 @@ var FuseStream_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "InjectNbdFault",
 			Handler:    _FuseStream_InjectNbdFault_Handler,
 		},
+		{
+			MethodName: "SetFaultEnabled",
+			Handler:    _FuseStream_SetFaultEnabled_Handler,
+		},
+		{
+			MethodName: "PauseAll",
+			Handler:    _FuseStream_PauseAll_Handler,
+		},
+		{
+			MethodName: "ResumeAll",
+			Handler:    _FuseStream_ResumeAll_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _FuseStream_GetStats_Handler,
+		},
+		{
+			MethodName: "ListInflight",
+			Handler:    _FuseStream_ListInflight_Handler,
+		},
+		{
+			MethodName: "Unmount",
+			Handler:    _FuseStream_Unmount_Handler,
+		},
+		{
+			MethodName: "GetServerInfo",
+			Handler:    _FuseStream_GetServerInfo_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "fusestream.proto",
+}
+
+const (
+	Daemon_Mount_FullMethodName           = "/slowio.proto.Daemon/Mount"
+	Daemon_Unmount_FullMethodName         = "/slowio.proto.Daemon/Unmount"
+	Daemon_ListTargets_FullMethodName     = "/slowio.proto.Daemon/ListTargets"
+	Daemon_GetTargetStatus_FullMethodName = "/slowio.proto.Daemon/GetTargetStatus"
+)
+
+// DaemonClient is the client API for Daemon service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Daemon manages the FUSE mounts and NBD exports of fusestream daemon, each target has its own fault namespace
+type DaemonClient interface {
+	Mount(ctx context.Context, in *MountRequest, opts ...grpc.CallOption) (*TargetStatus, error)
+	Unmount(ctx context.Context, in *UnmountRequest, opts ...grpc.CallOption) (*Void, error)
+	ListTargets(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ListTargetsResponse, error)
+	GetTargetStatus(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*TargetStatus, error)
+}
+
+type daemonClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDaemonClient(cc grpc.ClientConnInterface) DaemonClient {
+	return &daemonClient{cc}
+}
+
+func (c *daemonClient) Mount(ctx context.Context, in *MountRequest, opts ...grpc.CallOption) (*TargetStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TargetStatus)
+	err := c.cc.Invoke(ctx, Daemon_Mount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) Unmount(ctx context.Context, in *UnmountRequest, opts ...grpc.CallOption) (*Void, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Void)
+	err := c.cc.Invoke(ctx, Daemon_Unmount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) ListTargets(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ListTargetsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTargetsResponse)
+	err := c.cc.Invoke(ctx, Daemon_ListTargets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) GetTargetStatus(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*TargetStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TargetStatus)
+	err := c.cc.Invoke(ctx, Daemon_GetTargetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DaemonServer is the server API for Daemon service.
+// All implementations must embed UnimplementedDaemonServer
+// for forward compatibility.
+//
+// Daemon manages the FUSE mounts and NBD exports of fusestream daemon, each target has its own fault namespace
+type DaemonServer interface {
+	Mount(context.Context, *MountRequest) (*TargetStatus, error)
+	Unmount(context.Context, *UnmountRequest) (*Void, error)
+	ListTargets(context.Context, *Void) (*ListTargetsResponse, error)
+	GetTargetStatus(context.Context, *TargetRequest) (*TargetStatus, error)
+	mustEmbedUnimplementedDaemonServer()
+}
+
+// UnimplementedDaemonServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDaemonServer struct{}
+
+func (UnimplementedDaemonServer) Mount(context.Context, *MountRequest) (*TargetStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Mount not implemented")
+}
+func (UnimplementedDaemonServer) Unmount(context.Context, *UnmountRequest) (*Void, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unmount not implemented")
+}
+func (UnimplementedDaemonServer) ListTargets(context.Context, *Void) (*ListTargetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTargets not implemented")
+}
+func (UnimplementedDaemonServer) GetTargetStatus(context.Context, *TargetRequest) (*TargetStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTargetStatus not implemented")
+}
+func (UnimplementedDaemonServer) mustEmbedUnimplementedDaemonServer() {}
+func (UnimplementedDaemonServer) testEmbeddedByValue()                {}
+
+// UnsafeDaemonServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DaemonServer will
+// result in compilation errors.
+type UnsafeDaemonServer interface {
+	mustEmbedUnimplementedDaemonServer()
+}
+
+func RegisterDaemonServer(s grpc.ServiceRegistrar, srv DaemonServer) {
+	// If the following call pancis, it indicates UnimplementedDaemonServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Daemon_ServiceDesc, srv)
+}
+
+func _Daemon_Mount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).Mount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_Mount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).Mount(ctx, req.(*MountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_Unmount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnmountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).Unmount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_Unmount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).Unmount(ctx, req.(*UnmountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_ListTargets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Void)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).ListTargets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_ListTargets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).ListTargets(ctx, req.(*Void))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_GetTargetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).GetTargetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_GetTargetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).GetTargetStatus(ctx, req.(*TargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Daemon_ServiceDesc is the grpc.ServiceDesc for Daemon service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Daemon_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "slowio.proto.Daemon",
+	HandlerType: (*DaemonServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Mount",
+			Handler:    _Daemon_Mount_Handler,
+		},
+		{
+			MethodName: "Unmount",
+			Handler:    _Daemon_Unmount_Handler,
+		},
+		{
+			MethodName: "ListTargets",
+			Handler:    _Daemon_ListTargets_Handler,
+		},
+		{
+			MethodName: "GetTargetStatus",
+			Handler:    _Daemon_GetTargetStatus_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "fusestream.proto",
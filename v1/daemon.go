@@ -0,0 +1,262 @@
+package fusestream
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+var targetNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Target is a mount or an export, with its own fault namespace
+type Target struct {
+	Name     string
+	Faults   *FaultManager
+	Stats    *Stats
+	Inflight *InflightTable
+
+	// fields below are set by the daemon and guarded by its mutex
+	req      *pb.MountRequest
+	start    time.Time
+	stop     func() error // nil while mounting
+	stopping bool         // the target is kept until stop succeeds, it may be retried
+}
+
+func newTarget(req *pb.MountRequest) *Target {
+	return &Target{
+		Name:     req.Name,
+		Faults:   NewFaultManager(),
+		Stats:    NewStats(),
+		Inflight: NewInflightTable(),
+		req:      req,
+	}
+}
+
+func (t *Target) status() *pb.TargetStatus {
+	fuseFaults, nbdFaults := t.Faults.ListFaults()
+	inflight := t.Inflight.Len()
+	st := &pb.TargetStatus{
+		Name:     t.Name,
+		Faults:   int32(len(fuseFaults) + len(nbdFaults)),
+		Paused:   t.Faults.Paused(),
+		Ops:      t.Stats.Count() + int64(inflight),
+		Inflight: int32(inflight),
+	}
+	if !t.start.IsZero() {
+		st.StartTimeNs = t.start.UnixNano()
+	}
+
+	switch spec := t.req.Spec.(type) {
+	case *pb.MountRequest_Fuse:
+		st.Spec = &pb.TargetStatus_Fuse{Fuse: spec.Fuse}
+	case *pb.MountRequest_Nbd:
+		st.Spec = &pb.TargetStatus_Nbd{Nbd: spec.Nbd}
+	}
+	return st
+}
+
+// Daemon serves several FUSE mounts and NBD exports. It's the Daemon RPC server, and resolves the targets of
+// the FuseStream RPCs
+type Daemon struct {
+	pb.UnimplementedDaemonServer
+	// MountFuse and ServeNbd start serving a target with its faults, stats and in-flight table, the returned
+	// stop function unmounts or closes it. Mounting targets of the kind fails if nil
+	MountFuse func(t *Target, spec *pb.FuseTarget) (stop func() error, err error)
+	ServeNbd  func(t *Target, spec *pb.NbdTarget) (stop func() error, err error)
+
+	mutex    sync.Mutex
+	targets  map[string]*Target // guarded by mutex
+	closed   bool               // guarded by mutex
+	mounting sync.WaitGroup     // Mount calls in progress, added to under mutex
+}
+
+func NewDaemon() *Daemon {
+	return &Daemon{
+		targets: make(map[string]*Target),
+	}
+}
+
+// Target returns the target by name, the empty name resolves to the only target if there is exactly one
+func (d *Daemon) Target(name string) (*Target, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if name == "" {
+		if len(d.targets) == 1 {
+			for _, t := range d.targets {
+				return t, nil
+			}
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "target name required, %d targets served", len(d.targets))
+	}
+
+	t, ok := d.targets[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "target %q not found", name)
+	}
+	return t, nil
+}
+
+func (d *Daemon) Mount(_ context.Context, req *pb.MountRequest) (*pb.TargetStatus, error) {
+	if !targetNameRe.MatchString(req.Name) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid target name %q", req.Name)
+	}
+
+	var start func(t *Target) (func() error, error)
+	switch spec := req.Spec.(type) {
+	case *pb.MountRequest_Fuse:
+		if d.MountFuse == nil {
+			return nil, status.Error(codes.Unimplemented, "FUSE mounts are not supported")
+		}
+		if spec.Fuse.BaseDir == "" || spec.Fuse.Mountpoint == "" {
+			return nil, status.Error(codes.InvalidArgument, "base dir and mountpoint are required")
+		}
+		start = func(t *Target) (func() error, error) { return d.MountFuse(t, spec.Fuse) }
+	case *pb.MountRequest_Nbd:
+		if d.ServeNbd == nil {
+			return nil, status.Error(codes.Unimplemented, "NBD exports are not supported")
+		}
+		if spec.Nbd.BackendFile == "" || spec.Nbd.Export == "" || spec.Nbd.Listen == "" {
+			return nil, status.Error(codes.InvalidArgument, "backend file, export and listen address are required")
+		}
+		start = func(t *Target) (func() error, error) { return d.ServeNbd(t, spec.Nbd) }
+	default:
+		return nil, status.Error(codes.InvalidArgument, "no FUSE mount or NBD export specified")
+	}
+
+	// the name is taken while mounting, faults may be injected before the target is live
+	t := newTarget(req)
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		return nil, status.Error(codes.Unavailable, "daemon is closed")
+	}
+	if _, ok := d.targets[req.Name]; ok {
+		d.mutex.Unlock()
+		return nil, status.Errorf(codes.AlreadyExists, "target %q already exists", req.Name)
+	}
+	d.targets[req.Name] = t
+	d.mounting.Add(1)
+	d.mutex.Unlock()
+	defer d.mounting.Done()
+
+	stop, err := start(t)
+
+	d.mutex.Lock()
+	if err != nil {
+		delete(d.targets, req.Name)
+		d.mutex.Unlock()
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to mount %s: %v", req.Name, err)
+	}
+	if d.closed {
+		// Close didn't see the target as live, stop it here before Close returns
+		d.mutex.Unlock()
+		if err := stop(); err != nil {
+			log.Warn().Err(err).Str("target", req.Name).Msg("Failed to unmount the target mounted while closing")
+		}
+		d.mutex.Lock()
+		delete(d.targets, req.Name)
+		d.mutex.Unlock()
+		return nil, status.Error(codes.Unavailable, "daemon is closed")
+	}
+	defer d.mutex.Unlock()
+	t.stop = stop
+	t.start = time.Now()
+	log.Info().Str("target", req.Name).Msg("Target mounted")
+	return t.status(), nil
+}
+
+func (d *Daemon) Unmount(_ context.Context, req *pb.UnmountRequest) (*pb.Void, error) {
+	d.mutex.Lock()
+	t, ok := d.targets[req.Name]
+	if !ok {
+		d.mutex.Unlock()
+		return nil, status.Errorf(codes.NotFound, "target %q not found", req.Name)
+	}
+	if t.stop == nil {
+		d.mutex.Unlock()
+		return nil, status.Errorf(codes.FailedPrecondition, "target %q is being mounted", req.Name)
+	}
+	if t.stopping {
+		d.mutex.Unlock()
+		return nil, status.Errorf(codes.FailedPrecondition, "target %q is being unmounted", req.Name)
+	}
+	t.stopping = true
+	d.mutex.Unlock()
+
+	if err := d.stopTarget(t); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to unmount %s: %v", req.Name, err)
+	}
+	log.Info().Str("target", req.Name).Msg("Target unmounted")
+	return &pb.Void{}, nil
+}
+
+// stopTarget stops the target marked stopping, it's removed on success and still served otherwise
+func (d *Daemon) stopTarget(t *Target) error {
+	err := t.stop()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t.stopping = false
+	if err != nil {
+		return err
+	}
+	delete(d.targets, t.Name)
+	return nil
+}
+
+func (d *Daemon) ListTargets(_ context.Context, _ *pb.Void) (*pb.ListTargetsResponse, error) {
+	d.mutex.Lock()
+	rsp := &pb.ListTargetsResponse{}
+	for _, t := range d.targets {
+		rsp.Targets = append(rsp.Targets, t.status())
+	}
+	d.mutex.Unlock()
+
+	slices.SortFunc(rsp.Targets, func(a, b *pb.TargetStatus) int { return cmp.Compare(a.Name, b.Name) })
+	return rsp, nil
+}
+
+func (d *Daemon) GetTargetStatus(_ context.Context, req *pb.TargetRequest) (*pb.TargetStatus, error) {
+	t, err := d.Target(req.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return t.status(), nil
+}
+
+// Close unmounts all targets and fails the later mounts. The targets being mounted are unmounted by their Mount
+// calls, which Close waits for, the targets being unmounted are left to their Unmount calls. The targets failed
+// to unmount are kept
+func (d *Daemon) Close() error {
+	d.mutex.Lock()
+	d.closed = true
+	var targets []*Target
+	for _, t := range d.targets {
+		if t.stop != nil && !t.stopping {
+			t.stopping = true
+			targets = append(targets, t)
+		}
+	}
+	d.mutex.Unlock()
+
+	var errs []error
+	for _, t := range targets {
+		errs = append(errs, d.stopTarget(t))
+	}
+	d.mounting.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,43 @@
+//go:build linux || windows
+
+package fusestream
+
+import (
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+// MountFuseTarget mounts the base dir of the target, it returns once the mount is live. stop drains the in-flight
+// operations and unmounts it. SIGINT and SIGTERM are sent to signals, see NewFuseHost
+func MountFuseTarget(t *Target, spec *pb.FuseTarget, signals chan<- os.Signal) (func() error, error) {
+	stale, err := CleanStaleMountpoint(spec.Mountpoint)
+	if err != nil {
+		return nil, err
+	}
+	if stale {
+		log.Warn().Str("target", t.Name).Str("mountpoint", spec.Mountpoint).
+			Msg("Unmounted the stale mountpoint of a crashed mount")
+	}
+
+	fs := NewSlowFS(spec.BaseDir, t.Faults)
+	fs.Stats = t.Stats
+	fs.Inflight = t.Inflight
+
+	host := NewFuseHost(fs, fs.Inflight, signals)
+	host.SetUseIno(spec.UseIno)
+	if err := host.Mount(spec.Mountpoint, spec.MountOptions); err != nil {
+		return nil, err
+	}
+
+	drainTimeout := DefaultDrainTimeout
+	if spec.DrainTimeoutMs > 0 {
+		drainTimeout = time.Duration(spec.DrainTimeoutMs) * time.Millisecond
+	}
+	return func() error {
+		return host.Unmount(drainTimeout)
+	}, nil
+}
@@ -0,0 +1,105 @@
+package fusestream
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/pojntfx/go-nbd/pkg/server"
+	"github.com/rs/zerolog/log"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+// ServeNbdTarget serves the backend file of the target as an NBD export, stop closes the listener and the
+// connections
+func ServeNbdTarget(t *Target, spec *pb.NbdTarget) (func() error, error) {
+	flag := os.O_RDWR
+	if spec.ReadOnly {
+		flag = os.O_RDONLY
+	}
+	fh, err := os.OpenFile(spec.BackendFile, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen(cmp.Or(spec.Network, "tcp"), spec.Listen)
+	if err != nil {
+		_ = fh.Close()
+		return nil, err
+	}
+
+	backend := NewFileBackend(context.Background(), spec.Export, fh, t.Faults)
+	backend.Stats = t.Stats
+	backend.Inflight = t.Inflight
+	exports := []*server.Export{{Name: spec.Export, Backend: backend}}
+	options := &server.Options{ReadOnly: spec.ReadOnly, SupportsMultiConn: true}
+
+	var (
+		wg     sync.WaitGroup
+		mutex  sync.Mutex
+		closed bool                  // guarded by mutex
+		conns  = map[net.Conn]bool{} // guarded by mutex
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				log.Error().Err(err).Str("target", t.Name).Msg("Accept failed")
+				continue
+			}
+
+			mutex.Lock()
+			if closed {
+				mutex.Unlock()
+				_ = conn.Close()
+				return
+			}
+			conns[conn] = true
+			mutex.Unlock()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := server.Handle(conn, exports, options)
+				if err != nil {
+					log.Error().Err(err).Str("target", t.Name).Msg("Handle failed")
+				}
+
+				mutex.Lock()
+				delete(conns, conn)
+				mutex.Unlock()
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	// the export is stopped by the first call even if it fails, the retries of a failed Unmount return nil
+	var once sync.Once
+	return func() (err error) {
+		once.Do(func() {
+			err = listener.Close()
+
+			mutex.Lock()
+			closed = true
+			for conn := range conns {
+				_ = conn.Close()
+			}
+			mutex.Unlock()
+
+			wg.Wait()
+			backend.Close()
+			err = errors.Join(err, fh.Close())
+		})
+		return err
+	}, nil
+}
@@ -0,0 +1,231 @@
+package fusestream
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+func TestDaemon(t *testing.T) {
+	suite.Run(t, new(DaemonTestSuite))
+}
+
+type DaemonTestSuite struct {
+	suite.Suite
+	daemon  *Daemon
+	rpc     *Rpc
+	stopped []string
+	// mounted releases the mounts of the "slow" base dir
+	mounted chan struct{}
+}
+
+func (s *DaemonTestSuite) SetupTest() {
+	s.stopped = nil
+	s.mounted = make(chan struct{})
+	s.daemon = NewDaemon()
+	s.daemon.MountFuse = func(t *Target, spec *pb.FuseTarget) (func() error, error) {
+		if spec.BaseDir == "fail" {
+			return nil, errors.New("mount failed")
+		}
+		if spec.BaseDir == "slow" {
+			<-s.mounted
+		}
+		busy := spec.BaseDir == "busy"
+		return func() error {
+			if busy {
+				busy = false
+				return errors.New("device or resource busy")
+			}
+			s.stopped = append(s.stopped, t.Name)
+			return nil
+		}, nil
+	}
+	s.daemon.ServeNbd = ServeNbdTarget
+	s.rpc = &Rpc{Targets: s.daemon}
+}
+
+func (s *DaemonTestSuite) mountFuse(name, baseDir string) (*pb.TargetStatus, error) {
+	return s.daemon.Mount(context.Background(), &pb.MountRequest{
+		Name: name,
+		Spec: &pb.MountRequest_Fuse{Fuse: &pb.FuseTarget{BaseDir: baseDir, Mountpoint: "/mnt/" + name}},
+	})
+}
+
+func (s *DaemonTestSuite) TestFaultNamespaces() {
+	ctx := context.Background()
+	_, err := s.mountFuse("a", "/data/a")
+	s.Require().NoError(err)
+	_, err = s.mountFuse("b", "/data/b")
+	s.Require().NoError(err)
+
+	_, err = s.rpc.InjectFuseFault(ctx, &pb.InjectFuseFaultRequest{
+		Target: "a",
+		Fault:  &pb.FuseFault{PathRe: ".*", Op: pb.FuseOp_FUSE_READ},
+	})
+	s.Require().NoError(err)
+
+	a, err := s.rpc.ListFaults(ctx, &pb.TargetRequest{Target: "a"})
+	s.Require().NoError(err)
+	s.Len(a.FuseFaults, 1)
+	b, err := s.rpc.ListFaults(ctx, &pb.TargetRequest{Target: "b"})
+	s.Require().NoError(err)
+	s.Empty(b.FuseFaults)
+
+	_, err = s.rpc.PauseAll(ctx, &pb.TargetRequest{Target: "b"})
+	s.Require().NoError(err)
+	st, err := s.daemon.GetTargetStatus(ctx, &pb.TargetRequest{Target: "a"})
+	s.Require().NoError(err)
+	s.EqualValues(1, st.Faults)
+	s.False(st.Paused)
+	s.Equal("/data/a", st.GetFuse().BaseDir)
+	s.NotZero(st.StartTimeNs)
+
+	// the empty target is ambiguous with two targets
+	_, err = s.rpc.ListFaults(ctx, &pb.TargetRequest{})
+	s.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = s.rpc.ListFaults(ctx, &pb.TargetRequest{Target: "c"})
+	s.Equal(codes.NotFound, status.Code(err))
+
+	_, err = s.rpc.Unmount(ctx, &pb.TargetRequest{Target: "a"})
+	s.Require().NoError(err)
+	s.Equal([]string{"a"}, s.stopped)
+	_, err = s.rpc.ListFaults(ctx, &pb.TargetRequest{Target: "a"})
+	s.Equal(codes.NotFound, status.Code(err))
+
+	// the only target is the default
+	rsp, err := s.rpc.ListFaults(ctx, &pb.TargetRequest{})
+	s.Require().NoError(err)
+	s.True(rsp.Paused)
+
+	s.NoError(s.daemon.Close())
+	s.Equal([]string{"a", "b"}, s.stopped)
+	list, err := s.daemon.ListTargets(ctx, &pb.Void{})
+	s.Require().NoError(err)
+	s.Empty(list.Targets)
+}
+
+func (s *DaemonTestSuite) TestMountErrors() {
+	ctx := context.Background()
+	_, err := s.mountFuse("a", "/data/a")
+	s.Require().NoError(err)
+
+	_, err = s.mountFuse("a", "/data/b")
+	s.Equal(codes.AlreadyExists, status.Code(err))
+	_, err = s.mountFuse("../a", "/data/b")
+	s.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = s.daemon.Mount(ctx, &pb.MountRequest{Name: "b"})
+	s.Equal(codes.InvalidArgument, status.Code(err))
+
+	_, err = s.mountFuse("b", "fail")
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+	list, err := s.daemon.ListTargets(ctx, &pb.Void{})
+	s.Require().NoError(err)
+	s.Len(list.Targets, 1)
+
+	_, err = s.daemon.Unmount(ctx, &pb.UnmountRequest{Name: "b"})
+	s.Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *DaemonTestSuite) TestUnmountFailed() {
+	ctx := context.Background()
+	_, err := s.mountFuse("a", "busy")
+	s.Require().NoError(err)
+	_, err = s.mountFuse("b", "busy")
+	s.Require().NoError(err)
+
+	// the target is still served, and can be listed and retried
+	_, err = s.daemon.Unmount(ctx, &pb.UnmountRequest{Name: "a"})
+	s.Equal(codes.Internal, status.Code(err))
+	list, err := s.daemon.ListTargets(ctx, &pb.Void{})
+	s.Require().NoError(err)
+	s.Len(list.Targets, 2)
+
+	_, err = s.daemon.Unmount(ctx, &pb.UnmountRequest{Name: "a"})
+	s.NoError(err)
+	s.Equal([]string{"a"}, s.stopped)
+
+	s.Error(s.daemon.Close())
+	list, err = s.daemon.ListTargets(ctx, &pb.Void{})
+	s.Require().NoError(err)
+	s.Len(list.Targets, 1)
+	s.NoError(s.daemon.Close())
+	s.Equal([]string{"a", "b"}, s.stopped)
+}
+
+func (s *DaemonTestSuite) TestCloseWhileMounting() {
+	mountErr := make(chan error)
+	go func() {
+		_, err := s.mountFuse("a", "slow")
+		mountErr <- err
+	}()
+	s.Require().Eventually(func() bool {
+		_, err := s.daemon.Target("a")
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	closed := make(chan error)
+	go func() { closed <- s.daemon.Close() }()
+	time.Sleep(100 * time.Millisecond)
+	close(s.mounted)
+
+	// the mount completing after Close is unmounted before Close returns
+	s.Equal(codes.Unavailable, status.Code(<-mountErr))
+	s.NoError(<-closed)
+	s.Equal([]string{"a"}, s.stopped)
+	_, err := s.daemon.Target("a")
+	s.Equal(codes.NotFound, status.Code(err))
+
+	_, err = s.mountFuse("b", "/data/b")
+	s.Equal(codes.Unavailable, status.Code(err))
+}
+
+func (s *DaemonTestSuite) TestServeNbd() {
+	ctx := context.Background()
+	path := filepath.Join(s.T().TempDir(), "backend")
+	s.Require().NoError(os.WriteFile(path, make([]byte, 4096), 0644))
+
+	st, err := s.daemon.Mount(ctx, &pb.MountRequest{
+		Name: "disk",
+		Spec: &pb.MountRequest_Nbd{Nbd: &pb.NbdTarget{BackendFile: path, Export: "disk", Listen: "127.0.0.1:0"}},
+	})
+	s.Require().NoError(err)
+	s.Equal(path, st.GetNbd().BackendFile)
+
+	_, err = s.daemon.Unmount(ctx, &pb.UnmountRequest{Name: "disk"})
+	s.NoError(err)
+
+	_, err = s.daemon.Mount(ctx, &pb.MountRequest{
+		Name: "missing",
+		Spec: &pb.MountRequest_Nbd{Nbd: &pb.NbdTarget{BackendFile: path + ".missing", Export: "disk", Listen: "127.0.0.1:0"}},
+	})
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *DaemonTestSuite) TestSingleTargetRpc() {
+	ctx := context.Background()
+	rpc := &Rpc{Faults: NewFaultManager()}
+	_, err := rpc.ListFaults(ctx, &pb.TargetRequest{})
+	s.NoError(err)
+	_, err = rpc.ListFaults(ctx, &pb.TargetRequest{Target: "a"})
+	s.Equal(codes.NotFound, status.Code(err))
+
+	_, err = rpc.Unmount(ctx, &pb.TargetRequest{})
+	s.Equal(codes.Unimplemented, status.Code(err))
+	unmounted := false
+	rpc.Unmounter = func() error {
+		unmounted = true
+		return nil
+	}
+	_, err = rpc.Unmount(ctx, &pb.TargetRequest{})
+	s.NoError(err)
+	s.True(unmounted)
+}
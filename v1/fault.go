@@ -2,15 +2,23 @@ package fusestream
 
 import (
 	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// faultEventName is the span event recorded when a fault is injected
+const faultEventName = "fault.injected"
+
 type FaultExecute interface {
 	Delay()
 	MayReplaceErrorCode(rc int64) int64
 	MayReplaceError(err error) error
+	// RecordToSpan adds the fault decision to the span, should be called after the fault is applied
+	RecordToSpan(span trace.Span)
 }
 
 type ZeroFault struct{}
@@ -25,12 +33,23 @@ func (z ZeroFault) MayReplaceError(err error) error {
 
 func (z ZeroFault) Delay() {}
 
+func (z ZeroFault) RecordToSpan(trace.Span) {}
+
 var zeroFault FaultExecute = &ZeroFault{}
 
 type Fault struct {
+	// IDs of the faults triggered
+	IDs []int32
+
 	ReturnCode    *int64
 	Err           *error
 	DelayDuration *time.Duration
+
+	replacedRc  *int64
+	replacedErr error
+
+	// delaying counts the operations sleeping in Delay, may be nil
+	delaying *atomic.Int64
 }
 
 func (f *Fault) HasValue() bool {
@@ -39,12 +58,17 @@ func (f *Fault) HasValue() bool {
 
 func (f *Fault) Delay() {
 	if f.DelayDuration != nil {
+		if f.delaying != nil {
+			f.delaying.Add(1)
+			defer f.delaying.Add(-1)
+		}
 		time.Sleep(*f.DelayDuration)
 	}
 }
 
 func (f *Fault) MayReplaceErrorCode(rc int64) int64 {
 	if f.ReturnCode != nil {
+		f.replacedRc = &rc
 		return *f.ReturnCode
 	}
 	return rc
@@ -52,12 +76,47 @@ func (f *Fault) MayReplaceErrorCode(rc int64) int64 {
 
 func (f *Fault) MayReplaceError(err error) error {
 	if f.Err != nil {
+		f.replacedErr = err
 		return *f.Err
 	}
 	return err
 }
 
+func (f *Fault) RecordToSpan(span trace.Span) {
+	if len(f.IDs) == 0 {
+		return
+	}
+	if s, ok := span.(*opSpan); ok {
+		s.result.faultIDs = f.IDs
+	}
+
+	ids := make([]int64, len(f.IDs))
+	for i, id := range f.IDs {
+		ids[i] = int64(id)
+	}
+	span.SetAttributes(attribute.Int("fault_id", int(f.IDs[0])))
+
+	attrs := []attribute.KeyValue{attribute.Int64Slice("fault_ids", ids)}
+	if f.DelayDuration != nil {
+		attrs = append(attrs, attribute.Int64("delay_ns", f.DelayDuration.Nanoseconds()))
+	}
+	if f.ReturnCode != nil {
+		attrs = append(attrs, attribute.Int64("rc", *f.ReturnCode))
+		if f.replacedRc != nil {
+			attrs = append(attrs, attribute.Int64("replaced_rc", *f.replacedRc))
+		}
+	}
+	if f.Err != nil {
+		attrs = append(attrs, attribute.String("err", (*f.Err).Error()))
+		if f.replacedErr != nil {
+			attrs = append(attrs, attribute.String("replaced_err", f.replacedErr.Error()))
+		}
+	}
+	span.AddEvent(faultEventName, trace.WithAttributes(attrs...))
+}
+
 func (f *Fault) AppendTrace(e *zerolog.Event) *zerolog.Event {
+	e = e.Ints32("fault_ids", f.IDs)
 	if f.DelayDuration != nil {
 		e = e.Dur("latency", *f.DelayDuration)
 	}
@@ -70,31 +129,58 @@ func (f *Fault) AppendTrace(e *zerolog.Event) *zerolog.Event {
 	return e
 }
 
+// FromFuse merges the FUSE fault into f, delays add up while the existing return code is kept
 func (f *Fault) FromFuse(s *FuseFault) {
+	triggered := false
+
 	if s.Delay != nil && rand.Float32() <= s.DelayPossibility {
 		d := *s.Delay
+		if f.DelayDuration != nil {
+			d += *f.DelayDuration
+		}
 		f.DelayDuration = &d
+		triggered = true
 	}
 
-	if s.ReturnValue != nil && rand.Float32() <= s.ReturnValuePossibility {
+	if f.ReturnCode == nil && s.ReturnValue != nil && rand.Float32() <= s.ReturnValuePossibility {
 		ec := int64(*s.ReturnValue)
 		f.ReturnCode = &ec
+		triggered = true
+	}
+
+	if triggered {
+		f.IDs = append(f.IDs, s.ID)
+		s.hits.Add(1)
 	}
 }
 
+// FromNbd merges the NBD fault into f, delays add up while the existing return code and error are kept
 func (f *Fault) FromNbd(s *NbdFault) {
+	triggered := false
+
 	if s.Delay != nil && rand.Float32() <= s.DelayPossibility {
 		d := *s.Delay
+		if f.DelayDuration != nil {
+			d += *f.DelayDuration
+		}
 		f.DelayDuration = &d
+		triggered = true
 	}
 
-	if s.ReturnValue != nil && rand.Float32() <= s.ReturnValuePossibility {
+	if f.ReturnCode == nil && s.ReturnValue != nil && rand.Float32() <= s.ReturnValuePossibility {
 		a := *s.ReturnValue
 		f.ReturnCode = &a
+		triggered = true
 	}
 
-	if s.Err != nil && rand.Float32() <= s.ErrPossibility {
+	if f.Err == nil && s.Err != nil && rand.Float32() <= s.ErrPossibility {
 		err := *s.Err
 		f.Err = &err
+		triggered = true
+	}
+
+	if triggered {
+		f.IDs = append(f.IDs, s.ID)
+		s.hits.Add(1)
 	}
 }
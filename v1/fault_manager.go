@@ -1,7 +1,11 @@
 package fusestream
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -12,9 +16,20 @@ import (
 	"github.com/zperf/fusestream/pb"
 )
 
-type FuseFaultKey struct {
+var ErrFaultNotFound = errors.New("fault not found")
+
+// FuseCall is a FUSE operation to be matched against the injected faults
+type FuseCall struct {
 	Path string
 	Op   pb.FuseOp
+
+	// the calling process
+	Uid uint32
+	Gid uint32
+	Pid int
+
+	// Handle is the file being operated, nil if unknown
+	Handle *OpenHandle
 }
 
 type FuseFault struct {
@@ -27,6 +42,24 @@ type FuseFault struct {
 
 	Delay            *time.Duration
 	DelayPossibility float32
+
+	// Disabled faults are kept but never triggered
+	Disabled bool
+
+	// Process limits the fault to the calling processes, nil means any process
+	Process *ProcessFilter
+
+	// OpenFlags limits the fault to files opened with all of the flags
+	OpenFlags []pb.OpenFlag
+	// Fhs limits the fault to the file handles
+	Fhs []uint64
+
+	hits atomic.Int64
+}
+
+// Hits returns the number of times the fault was triggered
+func (f *FuseFault) Hits() int64 {
+	return f.hits.Load()
 }
 
 func (f *FuseFault) Clone() *FuseFault {
@@ -36,6 +69,7 @@ func (f *FuseFault) Clone() *FuseFault {
 		Op:                     f.Op,
 		ReturnValuePossibility: f.ReturnValuePossibility,
 		DelayPossibility:       f.DelayPossibility,
+		Disabled:               f.Disabled,
 	}
 
 	if f.ReturnValue != nil {
@@ -48,6 +82,14 @@ func (f *FuseFault) Clone() *FuseFault {
 		v.Delay = &d
 	}
 
+	if f.Process != nil {
+		v.Process = f.Process.Clone()
+	}
+
+	v.OpenFlags = slices.Clone(f.OpenFlags)
+	v.Fhs = slices.Clone(f.Fhs)
+	v.hits.Store(f.hits.Load())
+
 	return v
 }
 
@@ -65,16 +107,38 @@ type NbdFault struct {
 
 	Delay            *time.Duration
 	DelayPossibility float32
+
+	// Disabled faults are kept but never triggered
+	Disabled bool
+
+	// Faults of the same op are evaluated from the highest priority
+	Priority int32
+	// Only I/Os overlapping [OffsetStart, OffsetEnd) match, OffsetEnd = 0 means unbounded
+	OffsetStart int64
+	OffsetEnd   int64
+	MatchPolicy pb.NbdMatchPolicy
+
+	hits atomic.Int64
 }
 
-func (f *NbdFault) Clone() *NbdFault {
+// Hits returns the number of times the fault was triggered
+func (f *NbdFault) Hits() int64 {
+	return f.hits.Load()
+}
 
+func (f *NbdFault) Clone() *NbdFault {
 	v := &NbdFault{
 		ID:                     f.ID,
 		Op:                     f.Op,
+		preCond:                f.preCond,
 		ReturnValuePossibility: f.ReturnValuePossibility,
 		ErrPossibility:         f.ErrPossibility,
 		DelayPossibility:       f.DelayPossibility,
+		Disabled:               f.Disabled,
+		Priority:               f.Priority,
+		OffsetStart:            f.OffsetStart,
+		OffsetEnd:              f.OffsetEnd,
+		MatchPolicy:            f.MatchPolicy,
 	}
 
 	if f.ReturnValue != nil {
@@ -92,25 +156,55 @@ func (f *NbdFault) Clone() *NbdFault {
 		v.Err = &d
 	}
 
+	v.hits.Store(f.hits.Load())
 	return v
 }
 
+func (f *NbdFault) inRange(offset int64, length int) bool {
+	if f.OffsetStart == 0 && f.OffsetEnd == 0 {
+		return true
+	}
+	end := offset + int64(max(length, 1))
+	return end > f.OffsetStart && (f.OffsetEnd == 0 || offset < f.OffsetEnd)
+}
+
+func (f *NbdFault) evalPreCond(offset int64, length int) bool {
+	if f.preCond == nil {
+		return true
+	}
+
+	preCondObject, err := tengo.Eval(context.Background(), *f.preCond, map[string]interface{}{
+		"offset": offset,
+		"length": length,
+	})
+	preCond, ok := preCondObject.(bool)
+	if err != nil || !ok {
+		log.Warn().Err(err).Int32("id", f.ID).Int64("offset", offset).Int("len", length).
+			Interface("preCondObject", preCondObject).
+			Msg("Execute pre-condition script failed")
+		return false
+	}
+	return preCond
+}
+
 type FaultManager struct {
 	regexCache *RegexCache
 	nextID     int32
 
-	mutex        sync.RWMutex
-	fuseFaultMap map[FuseFaultKey]*FuseFault // guarded by mutex
-	nbdFaultMap  map[pb.NbdOp]*NbdFault      // guarded by mutex
+	mutex       sync.RWMutex
+	fuseFaults  []*FuseFault             // guarded by mutex, in injection order
+	nbdFaultMap map[pb.NbdOp][]*NbdFault // guarded by mutex, sorted by priority
 
-	haveFault atomic.Bool
+	haveFault atomic.Bool // true if any enabled fault exists
+	paused    atomic.Bool // global kill switch, overrides haveFault
+
+	delaying atomic.Int64 // number of operations sleeping in injected delays
 }
 
 func NewFaultManager() *FaultManager {
 	return &FaultManager{
-		regexCache:   NewRegexCache(),
-		fuseFaultMap: make(map[FuseFaultKey]*FuseFault),
-		nbdFaultMap:  make(map[pb.NbdOp]*NbdFault),
+		regexCache:  NewRegexCache(),
+		nbdFaultMap: make(map[pb.NbdOp][]*NbdFault),
 	}
 }
 
@@ -118,46 +212,82 @@ func (f *FaultManager) getNextID() int32 {
 	return atomic.AddInt32(&f.nextID, 1) - 1
 }
 
-func (f *FaultManager) GetFuseFault(path string, op pb.FuseOp) FaultExecute {
-	if !f.haveFault.Load() {
+// updateHaveFault must be called with mutex held
+func (f *FaultManager) updateHaveFault() {
+	for _, fault := range f.fuseFaults {
+		if !fault.Disabled {
+			f.haveFault.Store(true)
+			return
+		}
+	}
+	for _, faults := range f.nbdFaultMap {
+		for _, fault := range faults {
+			if !fault.Disabled {
+				f.haveFault.Store(true)
+				return
+			}
+		}
+	}
+	f.haveFault.Store(false)
+}
+
+func (f *FaultManager) active() bool {
+	return f.haveFault.Load() && !f.paused.Load()
+}
+
+// GetFuseFault evaluates all faults matching the call in injection order, e.g. faults of the same path and op scoped
+// to different processes. Delays of the matched faults add up, the return value comes from the first one triggering it
+func (f *FaultManager) GetFuseFault(call *FuseCall) FaultExecute {
+	if !f.active() {
 		return zeroFault
 	}
 
 	f.mutex.RLock()
 	defer f.mutex.RUnlock()
 
-	for key, fuseFault := range f.fuseFaultMap {
-		if key.Op != op {
+	path, op := call.Path, call.Op
+	fault := &Fault{delaying: &f.delaying}
+	for _, fuseFault := range f.fuseFaults {
+		if fuseFault.Op != op || fuseFault.Disabled {
 			continue
 		}
 
-		re, err := f.regexCache.Compile(key.Path)
+		re, err := f.regexCache.Compile(fuseFault.PathRe)
 		if err != nil {
-			log.Warn().Err(err).Str("regex", key.Path).Msg("Invalid regex")
+			log.Warn().Err(err).Str("regex", fuseFault.PathRe).Msg("Invalid regex")
 			continue
 		}
 
-		if re.Match([]byte(path)) {
-			var fault Fault
-			fault.FromFuse(fuseFault)
-			if fault.HasValue() {
-				e := log.Trace().Str("path", path).Str("op", op.String())
-				e = fault.AppendTrace(e)
-				e.Msg("Fault injected")
-				return &fault
-			}
+		if !re.Match([]byte(path)) {
+			continue
+		}
+		if fuseFault.Process != nil && !fuseFault.Process.Match(call, f.regexCache) {
+			continue
+		}
+		if !matchHandle(call.Handle, fuseFault.Fhs, fuseFault.OpenFlags) {
+			continue
 		}
+
+		fault.FromFuse(fuseFault)
+	}
+
+	if fault.HasValue() {
+		e := log.Trace().Str("path", path).Str("op", op.String())
+		e = fault.AppendTrace(e)
+		e.Msg("Fault injected")
+		return fault
 	}
 
 	return zeroFault
 }
 
+// FuseInject adds the fault, faults on the same path and op are kept side by side
 func (f *FaultManager) FuseInject(s *FuseFault) int32 {
 	f.mutex.Lock()
 	id := f.getNextID()
 	s.ID = id
-	f.fuseFaultMap[FuseFaultKey{s.PathRe, s.Op}] = s
-	f.haveFault.Store(true)
+	f.fuseFaults = append(f.fuseFaults, s)
+	f.updateHaveFault()
 	f.mutex.Unlock()
 	return id
 }
@@ -166,22 +296,28 @@ func (f *FaultManager) NbdInject(s *NbdFault) int32 {
 	f.mutex.Lock()
 	id := f.getNextID()
 	s.ID = id
-	f.nbdFaultMap[s.Op] = s
-	f.haveFault.Store(true)
+	faults := append(f.nbdFaultMap[s.Op], s)
+	slices.SortStableFunc(faults, func(a, b *NbdFault) int {
+		return cmp.Or(cmp.Compare(b.Priority, a.Priority), cmp.Compare(a.ID, b.ID))
+	})
+	f.nbdFaultMap[s.Op] = faults
+	f.updateHaveFault()
 	f.mutex.Unlock()
 	return id
 }
 
 func (f *FaultManager) ListFaults() ([]*FuseFault, []*NbdFault) {
 	f.mutex.RLock()
-	m := make([]*FuseFault, 0)
-	for _, fault := range f.fuseFaultMap {
+	m := make([]*FuseFault, 0, len(f.fuseFaults))
+	for _, fault := range f.fuseFaults {
 		m = append(m, fault.Clone())
 	}
 
 	b := make([]*NbdFault, 0)
-	for _, fault := range f.nbdFaultMap {
-		b = append(b, fault.Clone())
+	for _, faults := range f.nbdFaultMap {
+		for _, fault := range faults {
+			b = append(b, fault.Clone())
+		}
 	}
 
 	f.mutex.RUnlock()
@@ -192,15 +328,20 @@ func (f *FaultManager) DeleteAll() []int32 {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 
-	m := f.fuseFaultMap
-	f.fuseFaultMap = make(map[FuseFaultKey]*FuseFault)
-
 	deletedIDs := make([]int32, 0)
-	for _, fault := range m {
+	for _, fault := range f.fuseFaults {
 		deletedIDs = append(deletedIDs, fault.ID)
 	}
+	for _, faults := range f.nbdFaultMap {
+		for _, fault := range faults {
+			deletedIDs = append(deletedIDs, fault.ID)
+		}
+	}
 
-	f.haveFault.Store(false)
+	f.fuseFaults = nil
+	f.nbdFaultMap = make(map[pb.NbdOp][]*NbdFault)
+
+	f.updateHaveFault()
 	return deletedIDs
 }
 
@@ -209,28 +350,52 @@ func (f *FaultManager) DeleteByPathRegex(pathRe string) []int32 {
 	defer f.mutex.Unlock()
 
 	deletedIDs := make([]int32, 0)
-	toDelete := make(map[FuseFaultKey]struct{})
+	f.fuseFaults = slices.DeleteFunc(f.fuseFaults, func(fault *FuseFault) bool {
+		if fault.PathRe != pathRe {
+			return false
+		}
+		deletedIDs = append(deletedIDs, fault.ID)
+		return true
+	})
 
-	for key, fault := range f.fuseFaultMap {
-		if key.Path != pathRe {
-			continue
+	f.updateHaveFault()
+
+	return deletedIDs
+}
+
+func (f *FaultManager) DeleteByFuseOp(op pb.FuseOp) []int32 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	deletedIDs := make([]int32, 0)
+	f.fuseFaults = slices.DeleteFunc(f.fuseFaults, func(fault *FuseFault) bool {
+		if fault.Op != op {
+			return false
 		}
-		toDelete[key] = struct{}{}
 		deletedIDs = append(deletedIDs, fault.ID)
-	}
+		return true
+	})
 
-	for key := range toDelete {
-		delete(f.fuseFaultMap, key)
-	}
+	f.updateHaveFault()
+	return deletedIDs
+}
 
-	if len(f.fuseFaultMap) == 0 && len(f.nbdFaultMap) == 0 {
-		f.haveFault.Store(false)
+func (f *FaultManager) DeleteByNbdOp(op pb.NbdOp) []int32 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	deletedIDs := make([]int32, 0)
+	for _, fault := range f.nbdFaultMap[op] {
+		deletedIDs = append(deletedIDs, fault.ID)
 	}
+	delete(f.nbdFaultMap, op)
 
+	f.updateHaveFault()
 	return deletedIDs
 }
 
-func (f *FaultManager) DeleteByID(ids []int32) []int32 {
+// DeleteByID deletes FUSE and NBD faults by ID. Nothing is deleted if any of the IDs is unknown.
+func (f *FaultManager) DeleteByID(ids []int32) ([]int32, error) {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 
@@ -239,61 +404,130 @@ func (f *FaultManager) DeleteByID(ids []int32) []int32 {
 		idm[id] = struct{}{}
 	}
 
-	toDelete := make(map[FuseFaultKey]struct{})
+	fuseToDelete := make(map[*FuseFault]int32)
+	for _, fault := range f.fuseFaults {
+		if _, ok := idm[fault.ID]; ok {
+			fuseToDelete[fault] = fault.ID
+		}
+	}
+
+	nbdToDelete := make(map[*NbdFault]int32)
+	for _, faults := range f.nbdFaultMap {
+		for _, fault := range faults {
+			if _, ok := idm[fault.ID]; ok {
+				nbdToDelete[fault] = fault.ID
+			}
+		}
+	}
+
+	if len(fuseToDelete)+len(nbdToDelete) != len(idm) {
+		found := make(map[int32]struct{})
+		for _, id := range fuseToDelete {
+			found[id] = struct{}{}
+		}
+		for _, id := range nbdToDelete {
+			found[id] = struct{}{}
+		}
+		for _, id := range ids {
+			if _, ok := found[id]; !ok {
+				return nil, fmt.Errorf("%w, id: %d", ErrFaultNotFound, id)
+			}
+		}
+	}
 
-	for key, fault := range f.fuseFaultMap {
-		_, ok := idm[fault.ID]
+	deletedIDs := make([]int32, 0, len(idm))
+	f.fuseFaults = slices.DeleteFunc(f.fuseFaults, func(fault *FuseFault) bool {
+		id, ok := fuseToDelete[fault]
 		if ok {
-			toDelete[key] = struct{}{}
+			deletedIDs = append(deletedIDs, id)
+		}
+		return ok
+	})
+	for fault, id := range nbdToDelete {
+		faults := slices.DeleteFunc(f.nbdFaultMap[fault.Op], func(v *NbdFault) bool { return v == fault })
+		if len(faults) == 0 {
+			delete(f.nbdFaultMap, fault.Op)
+		} else {
+			f.nbdFaultMap[fault.Op] = faults
 		}
+		deletedIDs = append(deletedIDs, id)
 	}
 
-	for key := range toDelete {
-		delete(f.fuseFaultMap, key)
+	f.updateHaveFault()
+	return deletedIDs, nil
+}
+
+// SetEnabled enables or disables the faults with the given IDs, returns the IDs that were found
+func (f *FaultManager) SetEnabled(ids []int32, enabled bool) []int32 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	idm := make(map[int32]struct{})
+	for _, id := range ids {
+		idm[id] = struct{}{}
 	}
 
-	if len(f.fuseFaultMap) == 0 && len(f.nbdFaultMap) == 0 {
-		f.haveFault.Store(false)
+	updatedIDs := make([]int32, 0)
+	for _, fault := range f.fuseFaults {
+		if _, ok := idm[fault.ID]; ok {
+			fault.Disabled = !enabled
+			updatedIDs = append(updatedIDs, fault.ID)
+		}
+	}
+	for _, faults := range f.nbdFaultMap {
+		for _, fault := range faults {
+			if _, ok := idm[fault.ID]; ok {
+				fault.Disabled = !enabled
+				updatedIDs = append(updatedIDs, fault.ID)
+			}
+		}
 	}
 
-	return ids
+	f.updateHaveFault()
+	return updatedIDs
+}
+
+// PauseAll stops triggering all faults without deleting them
+func (f *FaultManager) PauseAll() {
+	f.paused.Store(true)
+}
+
+// ResumeAll undoes PauseAll, faults disabled individually stay disabled
+func (f *FaultManager) ResumeAll() {
+	f.paused.Store(false)
+}
+
+func (f *FaultManager) Paused() bool {
+	return f.paused.Load()
+}
+
+// Delaying returns the number of operations sleeping in injected delays
+func (f *FaultManager) Delaying() int64 {
+	return f.delaying.Load()
 }
 
+// GetNbdFault evaluates the faults of the op from the highest priority. Delays of all matched
+// faults add up, the return value and error come from the first matched fault that triggers them.
 func (f *FaultManager) GetNbdFault(op pb.NbdOp, offset int64, len int) FaultExecute {
-	if !f.haveFault.Load() {
+	if !f.active() {
 		return zeroFault
 	}
 
 	f.mutex.RLock()
 	defer f.mutex.RUnlock()
 
-	nbdFault, ok := f.nbdFaultMap[op]
-	if !ok {
-		return zeroFault
-	}
-
-	sc := nbdFault.preCond
-	if sc != nil {
-		preCondObject, err := tengo.Eval(context.Background(), *sc, map[string]interface{}{
-			"offset": offset,
-			"length": len,
-		})
-		var preCond bool
-		preCond, ok = preCondObject.(bool)
-		if err != nil || !ok {
-			log.Warn().Err(err).Int64("offset", offset).Int("len", len).
-				Interface("preCondObject", preCondObject).
-				Msg("Execute pre-condition script failed")
-			return zeroFault
+	fault := &Fault{delaying: &f.delaying}
+	for _, nbdFault := range f.nbdFaultMap[op] {
+		if nbdFault.Disabled || !nbdFault.inRange(offset, len) || !nbdFault.evalPreCond(offset, len) {
+			continue
 		}
 
-		if !preCond {
-			return zeroFault
+		fault.FromNbd(nbdFault)
+		if nbdFault.MatchPolicy == pb.NbdMatchPolicy_NBD_MATCH_FIRST {
+			break
 		}
 	}
 
-	fault := &Fault{}
-	fault.FromNbd(nbdFault)
 	if fault.HasValue() {
 		e := log.Trace().Str("op", op.String()).Int64("offset", offset).Int("len", len)
 		e = fault.AppendTrace(e)
@@ -51,6 +51,235 @@ func (s *FaultManagerTestSuite) TestFaultManager() {
 	s.Len(fuseFaults, 0)
 }
 
+func (s *FaultManagerTestSuite) TestPause() {
+	f := NewFaultManager()
+	rc := int32(-5)
+	id := f.FuseInject(&FuseFault{
+		PathRe:                 "test_file.*",
+		Op:                     pb.FuseOp_FUSE_WRITE,
+		ReturnValue:            &rc,
+		ReturnValuePossibility: 1,
+	})
+	s.Equal(int64(-5), f.GetFuseFault(&FuseCall{Path: "test_file1", Op: pb.FuseOp_FUSE_WRITE}).MayReplaceErrorCode(0))
+
+	f.PauseAll()
+	s.True(f.Paused())
+	s.Equal(int64(0), f.GetFuseFault(&FuseCall{Path: "test_file1", Op: pb.FuseOp_FUSE_WRITE}).MayReplaceErrorCode(0))
+
+	f.ResumeAll()
+	s.Equal(int64(-5), f.GetFuseFault(&FuseCall{Path: "test_file1", Op: pb.FuseOp_FUSE_WRITE}).MayReplaceErrorCode(0))
+
+	s.Equal([]int32{id}, f.SetEnabled([]int32{id, 100}, false))
+	s.Equal(int64(0), f.GetFuseFault(&FuseCall{Path: "test_file1", Op: pb.FuseOp_FUSE_WRITE}).MayReplaceErrorCode(0))
+	fuseFaults, _ := f.ListFaults()
+	s.Len(fuseFaults, 1)
+	s.True(fuseFaults[0].Disabled)
+
+	s.Equal([]int32{id}, f.SetEnabled([]int32{id}, true))
+	s.Equal(int64(-5), f.GetFuseFault(&FuseCall{Path: "test_file1", Op: pb.FuseOp_FUSE_WRITE}).MayReplaceErrorCode(0))
+}
+
+func (s *FaultManagerTestSuite) injectFaults(f *FaultManager) (fuseRead, fuseWrite, nbdRead, nbdWrite int32) {
+	d := 100 * time.Millisecond
+	fuseRead = f.FuseInject(&FuseFault{PathRe: "a.*", Op: pb.FuseOp_FUSE_READ, Delay: &d, DelayPossibility: 1})
+	fuseWrite = f.FuseInject(&FuseFault{PathRe: "b.*", Op: pb.FuseOp_FUSE_WRITE, Delay: &d, DelayPossibility: 1})
+	nbdRead = f.NbdInject(&NbdFault{Op: pb.NbdOp_NBD_READAT, Delay: &d, DelayPossibility: 1})
+	nbdWrite = f.NbdInject(&NbdFault{Op: pb.NbdOp_NBD_WRITEAT, Delay: &d, DelayPossibility: 1})
+	return
+}
+
+func (s *FaultManagerTestSuite) TestDeleteAll() {
+	f := NewFaultManager()
+	fuseRead, fuseWrite, nbdRead, nbdWrite := s.injectFaults(f)
+
+	deleted := f.DeleteAll()
+	s.ElementsMatch([]int32{fuseRead, fuseWrite, nbdRead, nbdWrite}, deleted)
+
+	fuseFaults, nbdFaults := f.ListFaults()
+	s.Len(fuseFaults, 0)
+	s.Len(nbdFaults, 0)
+	s.False(f.haveFault.Load())
+}
+
+func (s *FaultManagerTestSuite) TestDeleteByID() {
+	f := NewFaultManager()
+	fuseRead, fuseWrite, nbdRead, nbdWrite := s.injectFaults(f)
+
+	deleted, err := f.DeleteByID([]int32{fuseRead, nbdWrite})
+	s.NoError(err)
+	s.ElementsMatch([]int32{fuseRead, nbdWrite}, deleted)
+
+	fuseFaults, nbdFaults := f.ListFaults()
+	s.Len(fuseFaults, 1)
+	s.Equal(fuseWrite, fuseFaults[0].ID)
+	s.Len(nbdFaults, 1)
+	s.Equal(nbdRead, nbdFaults[0].ID)
+}
+
+func (s *FaultManagerTestSuite) TestDeleteByUnknownID() {
+	f := NewFaultManager()
+	fuseRead, _, nbdRead, _ := s.injectFaults(f)
+
+	deleted, err := f.DeleteByID([]int32{fuseRead, nbdRead, 100})
+	s.ErrorIs(err, ErrFaultNotFound)
+	s.Nil(deleted)
+
+	fuseFaults, nbdFaults := f.ListFaults()
+	s.Len(fuseFaults, 2)
+	s.Len(nbdFaults, 2)
+}
+
+func (s *FaultManagerTestSuite) TestDeleteByOp() {
+	f := NewFaultManager()
+	fuseRead, fuseWrite, nbdRead, nbdWrite := s.injectFaults(f)
+
+	s.Equal([]int32{fuseRead}, f.DeleteByFuseOp(pb.FuseOp_FUSE_READ))
+	s.Empty(f.DeleteByFuseOp(pb.FuseOp_FUSE_READ))
+	s.Equal([]int32{nbdWrite}, f.DeleteByNbdOp(pb.NbdOp_NBD_WRITEAT))
+	s.Empty(f.DeleteByNbdOp(pb.NbdOp_NBD_WRITEAT))
+
+	fuseFaults, nbdFaults := f.ListFaults()
+	s.Len(fuseFaults, 1)
+	s.Equal(fuseWrite, fuseFaults[0].ID)
+	s.Len(nbdFaults, 1)
+	s.Equal(nbdRead, nbdFaults[0].ID)
+}
+
+func (s *FaultManagerTestSuite) TestDeleteByPathRegex() {
+	f := NewFaultManager()
+	_, fuseWrite, _, _ := s.injectFaults(f)
+
+	s.Equal([]int32{fuseWrite}, f.DeleteByPathRegex("b.*"))
+	s.Empty(f.DeleteByPathRegex("c.*"))
+
+	fuseFaults, nbdFaults := f.ListFaults()
+	s.Len(fuseFaults, 1)
+	s.Len(nbdFaults, 2)
+}
+
+func (s *FaultManagerTestSuite) TestMultipleNbdFaults() {
+	f := NewFaultManager()
+	low, high := int64(-1), int64(-2)
+	lowPreCond := "offset < 4096"
+	highPreCond := "length > 512"
+	lowID := f.NbdInject(&NbdFault{
+		Op: pb.NbdOp_NBD_WRITEAT, preCond: &lowPreCond,
+		ReturnValue: &low, ReturnValuePossibility: 1,
+	})
+	highID := f.NbdInject(&NbdFault{
+		Op: pb.NbdOp_NBD_WRITEAT, preCond: &highPreCond, Priority: 1,
+		ReturnValue: &high, ReturnValuePossibility: 1,
+	})
+
+	_, nbdFaults := f.ListFaults()
+	s.Len(nbdFaults, 2)
+
+	// both match, the higher priority one wins
+	s.Equal(high, f.GetNbdFault(pb.NbdOp_NBD_WRITEAT, 0, 4096).MayReplaceErrorCode(0))
+	// only the low priority one matches
+	s.Equal(low, f.GetNbdFault(pb.NbdOp_NBD_WRITEAT, 0, 512).MayReplaceErrorCode(0))
+	// none matches
+	s.Equal(int64(0), f.GetNbdFault(pb.NbdOp_NBD_WRITEAT, 8192, 512).MayReplaceErrorCode(0))
+	s.Equal(int64(0), f.GetNbdFault(pb.NbdOp_NBD_READAT, 0, 4096).MayReplaceErrorCode(0))
+
+	deleted, err := f.DeleteByID([]int32{highID})
+	s.NoError(err)
+	s.Equal([]int32{highID}, deleted)
+	s.Equal(low, f.GetNbdFault(pb.NbdOp_NBD_WRITEAT, 0, 4096).MayReplaceErrorCode(0))
+
+	_, nbdFaults = f.ListFaults()
+	s.Len(nbdFaults, 1)
+	s.Equal(lowID, nbdFaults[0].ID)
+}
+
+func (s *FaultManagerTestSuite) TestNbdMatchPolicy() {
+	f := NewFaultManager()
+	d1, d2 := 10*time.Millisecond, 20*time.Millisecond
+	rc := int64(-5)
+	f.NbdInject(&NbdFault{
+		Op: pb.NbdOp_NBD_READAT, Priority: 2, MatchPolicy: pb.NbdMatchPolicy_NBD_MATCH_ALL,
+		Delay: &d1, DelayPossibility: 1,
+	})
+	f.NbdInject(&NbdFault{
+		Op: pb.NbdOp_NBD_READAT, Priority: 1, OffsetStart: 4096, OffsetEnd: 8192,
+		Delay: &d2, DelayPossibility: 1, ReturnValue: &rc, ReturnValuePossibility: 1,
+	})
+	f.NbdInject(&NbdFault{
+		Op: pb.NbdOp_NBD_READAT, Priority: 0,
+		Delay: &d2, DelayPossibility: 1,
+	})
+
+	// all-match falls through to the ranged fault, which stops the evaluation
+	fault, ok := f.GetNbdFault(pb.NbdOp_NBD_READAT, 4000, 512).(*Fault)
+	s.Require().True(ok)
+	s.Equal(d1+d2, *fault.DelayDuration)
+	s.Equal(rc, *fault.ReturnCode)
+
+	// out of range, falls through to the lowest priority fault
+	fault, ok = f.GetNbdFault(pb.NbdOp_NBD_READAT, 8192, 512).(*Fault)
+	s.Require().True(ok)
+	s.Equal(d1+d2, *fault.DelayDuration)
+	s.Nil(fault.ReturnCode)
+}
+
+func (s *FaultManagerTestSuite) TestFuseFaultsMerged() {
+	f := NewFaultManager()
+	d1, d2 := 100*time.Millisecond, 200*time.Millisecond
+	rc1, rc2 := int32(-5), int32(-28)
+	first := f.FuseInject(&FuseFault{PathRe: "wal/.*", Op: pb.FuseOp_FUSE_WRITE, Delay: &d1, DelayPossibility: 1,
+		ReturnValue: &rc1, ReturnValuePossibility: 1})
+	second := f.FuseInject(&FuseFault{PathRe: "wal/.*", Op: pb.FuseOp_FUSE_WRITE, Delay: &d2, DelayPossibility: 1,
+		ReturnValue: &rc2, ReturnValuePossibility: 1})
+
+	// delays add up, the return value of the first fault wins
+	fault, ok := f.GetFuseFault(&FuseCall{Path: "wal/1", Op: pb.FuseOp_FUSE_WRITE}).(*Fault)
+	s.Require().True(ok)
+	s.Equal([]int32{first, second}, fault.IDs)
+	s.Equal(d1+d2, *fault.DelayDuration)
+	s.Equal(int64(rc1), *fault.ReturnCode)
+
+	_, err := f.DeleteByID([]int32{first})
+	s.NoError(err)
+	fault, ok = f.GetFuseFault(&FuseCall{Path: "wal/1", Op: pb.FuseOp_FUSE_WRITE}).(*Fault)
+	s.Require().True(ok)
+	s.Equal([]int32{second}, fault.IDs)
+	s.Equal(int64(rc2), *fault.ReturnCode)
+}
+
+func (s *FaultManagerTestSuite) TestFileHandle() {
+	f := NewFaultManager()
+	d := 100 * time.Millisecond
+	f.FuseInject(&FuseFault{
+		PathRe:           "wal/.*",
+		Op:               pb.FuseOp_FUSE_FSYNC,
+		Delay:            &d,
+		DelayPossibility: 1,
+		OpenFlags:        []pb.OpenFlag{pb.OpenFlag_OPEN_FLAG_SYNC, pb.OpenFlag_OPEN_FLAG_APPEND},
+	})
+
+	sync := openFlagBits[pb.OpenFlag_OPEN_FLAG_SYNC] | openFlagBits[pb.OpenFlag_OPEN_FLAG_APPEND]
+	call := &FuseCall{Path: "wal/000001", Op: pb.FuseOp_FUSE_FSYNC}
+	s.Equal(zeroFault, f.GetFuseFault(call))
+
+	call.Handle = &OpenHandle{Fh: 1, Path: call.Path}
+	s.Equal(zeroFault, f.GetFuseFault(call))
+
+	call.Handle = &OpenHandle{Fh: 2, Path: call.Path, Flags: sync}
+	s.NotEqual(zeroFault, f.GetFuseFault(call))
+
+	f.FuseInject(&FuseFault{
+		PathRe:           "data/.*",
+		Op:               pb.FuseOp_FUSE_READ,
+		Delay:            &d,
+		DelayPossibility: 1,
+		Fhs:              []uint64{3},
+	})
+	call = &FuseCall{Path: "data/1", Op: pb.FuseOp_FUSE_READ, Handle: &OpenHandle{Fh: 2}}
+	s.Equal(zeroFault, f.GetFuseFault(call))
+	call.Handle.Fh = 3
+	s.NotEqual(zeroFault, f.GetFuseFault(call))
+}
+
 func TestMain(m *testing.M) {
 	InitLogging(zerolog.InfoLevel)
 	os.Exit(m.Run())
@@ -10,6 +10,7 @@ import (
 
 	"github.com/winfsp/cgofuse/fuse"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/zperf/fusestream/pb"
 )
@@ -17,6 +18,11 @@ import (
 type SlowFS struct {
 	RawFS
 	Faults *FaultManager
+	// Metrics is fed by the operations if not nil
+	Metrics  *Metrics
+	Stats    *Stats
+	Inflight *InflightTable
+	handles  *HandleTable
 }
 
 func NewSlowFS(baseDir string, faults *FaultManager) *SlowFS {
@@ -25,7 +31,10 @@ func NewSlowFS(baseDir string, faults *FaultManager) *SlowFS {
 			BaseDir:          baseDir,
 			DisableReadAhead: true,
 		},
-		Faults: faults,
+		Faults:   faults,
+		Stats:    NewStats(),
+		Inflight: NewInflightTable(),
+		handles:  NewHandleTable(),
 	}
 }
 
@@ -33,15 +42,80 @@ func (f *SlowFS) Init() {
 	f.RawFS.Init()
 }
 
+// startHandleSpan starts the parent span of all operations on a file handle, it ends on release
+func (f *SlowFS) startHandleSpan(path string, flags int) (context.Context, trace.Span) {
+	return tracer.Start(context.Background(), handleSpanName, trace.WithAttributes(
+		attribute.String("path", path),
+		attribute.Int("flags", flags),
+	))
+}
+
+// startSpan starts an operation span, the operation is in-flight until the span ends. The calling process is only
+// fetched from FUSE if in-flight operations are tracked, so the methods work outside of FUSE threads otherwise
+func (f *SlowFS) startSpan(ctx context.Context, name string, path string) trace.Span {
+	_, span := tracer.Start(ctx, name)
+	op := &InflightOp{Op: name, Path: path}
+	var caller *callerContext
+	if f.Inflight != nil {
+		uid, gid, pid := fuse.Getcontext()
+		caller = &callerContext{uid: uid, gid: gid, pid: pid}
+		op.Pid = pid
+	}
+
+	span = observeSpan(span, op, f.Metrics, f.Stats, f.Inflight)
+	if s, ok := span.(*opSpan); ok {
+		s.caller = caller
+	}
+	return span
+}
+
+// callerOf returns the calling process of the operation of span, it's fetched from FUSE once per operation
+func (f *SlowFS) callerOf(span trace.Span) (uid uint32, gid uint32, pid int) {
+	s, ok := span.(*opSpan)
+	if ok && s.caller != nil {
+		return s.caller.uid, s.caller.gid, s.caller.pid
+	}
+
+	uid, gid, pid = fuse.Getcontext()
+	if ok {
+		s.caller = &callerContext{uid: uid, gid: gid, pid: pid}
+	}
+	return uid, gid, pid
+}
+
+// OpenHandles returns the number of open file handles
+func (f *SlowFS) OpenHandles() int {
+	return f.handles.Len()
+}
+
+func (f *SlowFS) getFuseFault(span trace.Span, path string, op pb.FuseOp) FaultExecute {
+	return f.getFault(span, &FuseCall{Path: path, Op: op})
+}
+
+func (f *SlowFS) getFuseFaultByHandle(span trace.Span, path string, op pb.FuseOp, h *OpenHandle) FaultExecute {
+	return f.getFault(span, &FuseCall{Path: path, Op: op, Handle: h})
+}
+
+// getFault returns the fault of call, the fault is recorded on the in-flight operation of span
+func (f *SlowFS) getFault(span trace.Span, call *FuseCall) FaultExecute {
+	if !f.Faults.active() {
+		return zeroFault
+	}
+
+	call.Uid, call.Gid, call.Pid = f.callerOf(span)
+	return trackFault(span, f.Faults.GetFuseFault(call))
+}
+
 func (f *SlowFS) Statfs(path string, stat *fuse.Statfs_t) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Statfs")
+	span := f.startSpan(context.Background(), "fuse.Statfs", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_STATFS)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_STATFS)
 	fault.Delay()
 
 	errc = f.RawFS.Statfs(path, stat)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -51,14 +125,15 @@ func (f *SlowFS) Statfs(path string, stat *fuse.Statfs_t) (errc int) {
 }
 
 func (f *SlowFS) Mknod(path string, mode uint32, dev uint64) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Mknod")
+	span := f.startSpan(context.Background(), "fuse.Mknod", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_MKNOD)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_MKNOD)
 	fault.Delay()
 
 	errc = f.RawFS.Mknod(path, mode, dev)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -70,14 +145,15 @@ func (f *SlowFS) Mknod(path string, mode uint32, dev uint64) (errc int) {
 }
 
 func (f *SlowFS) Mkdir(path string, mode uint32) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Mkdir")
+	span := f.startSpan(context.Background(), "fuse.Mkdir", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_MKDIR)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_MKDIR)
 	fault.Delay()
 
 	errc = f.RawFS.Mkdir(path, mode)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -88,14 +164,15 @@ func (f *SlowFS) Mkdir(path string, mode uint32) (errc int) {
 }
 
 func (f *SlowFS) Unlink(path string) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Unlink")
+	span := f.startSpan(context.Background(), "fuse.Unlink", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_UNLINK)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_UNLINK)
 	fault.Delay()
 
 	errc = f.RawFS.Unlink(path)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -105,14 +182,15 @@ func (f *SlowFS) Unlink(path string) (errc int) {
 }
 
 func (f *SlowFS) Rmdir(path string) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Rmdir")
+	span := f.startSpan(context.Background(), "fuse.Rmdir", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_RMDIR)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_RMDIR)
 	fault.Delay()
 
 	errc = f.RawFS.Rmdir(path)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -122,14 +200,15 @@ func (f *SlowFS) Rmdir(path string) (errc int) {
 }
 
 func (f *SlowFS) Link(oldpath string, newpath string) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Link")
+	span := f.startSpan(context.Background(), "fuse.Link", oldpath)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(oldpath, pb.FuseOp_FUSE_LINK)
+	fault := f.getFuseFault(span, oldpath, pb.FuseOp_FUSE_LINK)
 	fault.Delay()
 
 	errc = f.RawFS.Link(oldpath, newpath)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("oldpath", oldpath),
@@ -140,14 +219,15 @@ func (f *SlowFS) Link(oldpath string, newpath string) (errc int) {
 }
 
 func (f *SlowFS) Symlink(target string, newpath string) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Symlink")
+	span := f.startSpan(context.Background(), "fuse.Symlink", target)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(target, pb.FuseOp_FUSE_SYMLINK)
+	fault := f.getFuseFault(span, target, pb.FuseOp_FUSE_SYMLINK)
 	fault.Delay()
 
 	errc = f.RawFS.Symlink(target, newpath)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("target", target),
@@ -158,14 +238,15 @@ func (f *SlowFS) Symlink(target string, newpath string) (errc int) {
 }
 
 func (f *SlowFS) Readlink(path string) (errc int, target string) {
-	_, span := tracer.Start(context.TODO(), "fuse.Readlink")
+	span := f.startSpan(context.Background(), "fuse.Readlink", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(target, pb.FuseOp_FUSE_READLINK)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_READLINK)
 	fault.Delay()
 
 	errc, target = f.RawFS.Readlink(path)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -176,14 +257,15 @@ func (f *SlowFS) Readlink(path string) (errc int, target string) {
 }
 
 func (f *SlowFS) Rename(oldpath string, newpath string) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Rename")
+	span := f.startSpan(context.Background(), "fuse.Rename", oldpath)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(oldpath, pb.FuseOp_FUSE_RENAME)
+	fault := f.getFuseFault(span, oldpath, pb.FuseOp_FUSE_RENAME)
 	fault.Delay()
 
 	errc = f.RawFS.Rename(oldpath, newpath)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("oldpath", oldpath),
@@ -194,14 +276,15 @@ func (f *SlowFS) Rename(oldpath string, newpath string) (errc int) {
 }
 
 func (f *SlowFS) Chmod(path string, mode uint32) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Chmod")
+	span := f.startSpan(context.Background(), "fuse.Chmod", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_CHMOD)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_CHMOD)
 	fault.Delay()
 
 	errc = f.RawFS.Chmod(path, mode)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -212,14 +295,15 @@ func (f *SlowFS) Chmod(path string, mode uint32) (errc int) {
 }
 
 func (f *SlowFS) Chown(path string, uid uint32, gid uint32) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Chown")
+	span := f.startSpan(context.Background(), "fuse.Chown", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_CHOWN)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_CHOWN)
 	fault.Delay()
 
 	errc = f.RawFS.Chown(path, uid, gid)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -231,14 +315,15 @@ func (f *SlowFS) Chown(path string, uid uint32, gid uint32) (errc int) {
 }
 
 func (f *SlowFS) Utimens(path string, tmsp1 []fuse.Timespec) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Utimens")
+	span := f.startSpan(context.Background(), "fuse.Utimens", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_UTIMENS)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_UTIMENS)
 	fault.Delay()
 
 	errc = f.RawFS.Utimens(path, tmsp1)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -252,14 +337,26 @@ func (f *SlowFS) Utimens(path string, tmsp1 []fuse.Timespec) (errc int) {
 }
 
 func (f *SlowFS) Create(path string, flags int, mode uint32) (errc int, fh uint64) {
-	_, span := tracer.Start(context.TODO(), "fuse.Create")
+	hctx, hspan := f.startHandleSpan(path, flags)
+	span := f.startSpan(hctx, "fuse.Create", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_CREATE)
+	fault := f.getFault(span, &FuseCall{
+		Path:   path,
+		Op:     pb.FuseOp_FUSE_CREATE,
+		Handle: &OpenHandle{Fh: ^uint64(0), Path: path, Flags: flags},
+	})
 	fault.Delay()
 
 	errc, fh = f.RawFS.Create(path, flags, mode)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
+	if errc == 0 {
+		hspan.SetAttributes(attribute.String("fh", fmt.Sprintf("%d", fh)))
+		f.handles.Add(&OpenHandle{Fh: fh, Path: path, Flags: flags, ctx: hctx, span: hspan})
+	} else {
+		hspan.End()
+	}
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -272,14 +369,26 @@ func (f *SlowFS) Create(path string, flags int, mode uint32) (errc int, fh uint6
 }
 
 func (f *SlowFS) Open(path string, flags int) (errc int, fh uint64) {
-	_, span := tracer.Start(context.TODO(), "fuse.Open")
+	hctx, hspan := f.startHandleSpan(path, flags)
+	span := f.startSpan(hctx, "fuse.Open", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_OPEN)
+	fault := f.getFault(span, &FuseCall{
+		Path:   path,
+		Op:     pb.FuseOp_FUSE_OPEN,
+		Handle: &OpenHandle{Fh: ^uint64(0), Path: path, Flags: flags},
+	})
 	fault.Delay()
 
 	errc, fh = f.RawFS.Open(path, flags)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
+	if errc == 0 {
+		hspan.SetAttributes(attribute.String("fh", fmt.Sprintf("%d", fh)))
+		f.handles.Add(&OpenHandle{Fh: fh, Path: path, Flags: flags, ctx: hctx, span: hspan})
+	} else {
+		hspan.End()
+	}
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -311,14 +420,16 @@ func (f *SlowFS) open(path string, flags int, mode uint32) (errc int, fh uint64)
 }
 
 func (f *SlowFS) Getattr(path string, stat *fuse.Stat_t, fh uint64) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Getattr")
+	h := f.handles.Get(fh)
+	span := f.startSpan(h.Context(), "fuse.Getattr", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_GETATTR)
+	fault := f.getFuseFaultByHandle(span, path, pb.FuseOp_FUSE_GETATTR, h)
 	fault.Delay()
 
 	errc = f.RawFS.Getattr(path, stat, fh)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -329,14 +440,16 @@ func (f *SlowFS) Getattr(path string, stat *fuse.Stat_t, fh uint64) (errc int) {
 }
 
 func (f *SlowFS) Truncate(path string, size int64, fh uint64) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Truncate")
+	h := f.handles.Get(fh)
+	span := f.startSpan(h.Context(), "fuse.Truncate", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_TRUNCATE)
+	fault := f.getFuseFaultByHandle(span, path, pb.FuseOp_FUSE_TRUNCATE, h)
 	fault.Delay()
 
 	errc = f.RawFS.Truncate(path, size, fh)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -348,52 +461,62 @@ func (f *SlowFS) Truncate(path string, size int64, fh uint64) (errc int) {
 }
 
 func (f *SlowFS) Read(path string, buff []byte, ofst int64, fh uint64) (rc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Read")
+	h := f.handles.Get(fh)
+	span := f.startSpan(h.Context(), "fuse.Read", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_READ)
+	fault := f.getFuseFaultByHandle(span, path, pb.FuseOp_FUSE_READ, h)
 	fault.Delay()
 
 	rc = f.RawFS.Read(path, buff, ofst, fh)
 	rc = int(fault.MayReplaceErrorCode(int64(rc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
 		attribute.Int64("offset", ofst),
 		attribute.String("fh", fmt.Sprintf("%d", fh)),
-		attribute.Int("length", rc),
+		attribute.Int("length", max(rc, 0)),
+		attribute.Int("errc", min(rc, 0)),
 	)
 	return
 }
 
 func (f *SlowFS) Write(path string, buff []byte, ofst int64, fh uint64) (rc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Write")
+	h := f.handles.Get(fh)
+	span := f.startSpan(h.Context(), "fuse.Write", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_WRITE)
+	fault := f.getFuseFaultByHandle(span, path, pb.FuseOp_FUSE_WRITE, h)
 	fault.Delay()
 
 	rc = f.RawFS.Write(path, buff, ofst, fh)
 	rc = int(fault.MayReplaceErrorCode(int64(rc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
 		attribute.Int64("offset", ofst),
 		attribute.String("fh", fmt.Sprintf("%d", fh)),
-		attribute.Int("length", rc),
+		attribute.Int("length", max(rc, 0)),
+		attribute.Int("errc", min(rc, 0)),
 	)
 	return
 }
 
 func (f *SlowFS) Release(path string, fh uint64) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Release")
+	// the handle is removed before the fd is closed, a concurrent open may reuse fh as soon as it is
+	h := f.handles.Remove(fh)
+	defer h.End()
+	span := f.startSpan(h.Context(), "fuse.Release", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_RELEASE)
+	fault := f.getFuseFaultByHandle(span, path, pb.FuseOp_FUSE_RELEASE, h)
 	fault.Delay()
 
 	errc = f.RawFS.Release(path, fh)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -404,14 +527,16 @@ func (f *SlowFS) Release(path string, fh uint64) (errc int) {
 }
 
 func (f *SlowFS) Fsync(path string, datasync bool, fh uint64) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Fsync")
+	h := f.handles.Get(fh)
+	span := f.startSpan(h.Context(), "fuse.Fsync", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_FSYNC)
+	fault := f.getFuseFaultByHandle(span, path, pb.FuseOp_FUSE_FSYNC, h)
 	fault.Delay()
 
 	errc = f.RawFS.Fsync(path, datasync, fh)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -423,14 +548,15 @@ func (f *SlowFS) Fsync(path string, datasync bool, fh uint64) (errc int) {
 }
 
 func (f *SlowFS) Opendir(path string) (errc int, fh uint64) {
-	_, span := tracer.Start(context.TODO(), "fuse.Opendir")
+	span := f.startSpan(context.Background(), "fuse.Opendir", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_OPENDIR)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_OPENDIR)
 	fault.Delay()
 
 	errc, fh = f.RawFS.Opendir(path)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -443,14 +569,15 @@ func (f *SlowFS) Opendir(path string) (errc int, fh uint64) {
 type fillFn = func(name string, stat *fuse.Stat_t, ofst int64) bool
 
 func (f *SlowFS) Readdir(path string, fill fillFn, ofst int64, fh uint64) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Readdir")
+	span := f.startSpan(context.Background(), "fuse.Readdir", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_READDIR)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_READDIR)
 	fault.Delay()
 
 	errc = f.RawFS.Readdir(path, fill, ofst, fh)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -462,14 +589,15 @@ func (f *SlowFS) Readdir(path string, fill fillFn, ofst int64, fh uint64) (errc
 }
 
 func (f *SlowFS) Releasedir(path string, fh uint64) (errc int) {
-	_, span := tracer.Start(context.TODO(), "fuse.Releasedir")
+	span := f.startSpan(context.Background(), "fuse.Releasedir", path)
 	defer span.End()
 
-	fault := f.Faults.GetFuseFault(path, pb.FuseOp_FUSE_READDIR)
+	fault := f.getFuseFault(span, path, pb.FuseOp_FUSE_RELEASEDIR)
 	fault.Delay()
 
 	errc = f.RawFS.Releasedir(path, fh)
 	errc = int(fault.MayReplaceErrorCode(int64(errc)))
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(
 		attribute.String("path", path),
@@ -0,0 +1,150 @@
+//go:build linux || windows
+
+package fusestream
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/rs/zerolog/log"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// DefaultDrainTimeout bounds the wait for in-flight operations before unmounting
+const DefaultDrainTimeout = 10 * time.Second
+
+// FuseHost mounts a filesystem in the background and unmounts it gracefully
+type FuseHost struct {
+	host       *fuse.FileSystemHost
+	inflight   *InflightTable
+	signals    chan<- os.Signal
+	mountpoint string
+	ready      chan struct{}
+	done       chan struct{}
+}
+
+// hostFS closes ready once the filesystem is mounted
+type hostFS struct {
+	fuse.FileSystemInterface
+	host *FuseHost
+}
+
+func (f *hostFS) Init() {
+	if f.host.signals != nil {
+		// cgofuse unmounts right away on SIGINT and SIGTERM, signals already go to f.host.signals to drain the
+		// in-flight operations first. Mount checked the channel exists
+		if sigc, err := cgofuseSigc(f.host.host); err == nil && *sigc != nil {
+			signal.Stop(*sigc)
+		}
+	}
+	f.FileSystemInterface.Init()
+	close(f.host.ready)
+}
+
+// cgofuseSigc returns the unexported signal channel cgofuse subscribes right before Init and unmounts on. cgofuse has
+// no option to skip it, stopping the channel keeps the other subscribers of the signals unlike signal.Reset, e.g. the
+// root context of main. The field is pinned by a test, so a cgofuse upgrade renaming it fails instead of silently
+// unmounting without a drain
+func cgofuseSigc(host *fuse.FileSystemHost) (*chan os.Signal, error) {
+	field := reflect.ValueOf(host).Elem().FieldByName("sigc")
+	if !field.IsValid() || field.Type() != reflect.TypeFor[chan os.Signal]() {
+		return nil, errors.New("unsupported cgofuse version, the signal channel of FileSystemHost isn't found")
+	}
+	return (*chan os.Signal)(unsafe.Pointer(field.UnsafeAddr())), nil
+}
+
+// NewFuseHost creates the host of fs, the in-flight operations are drained before unmounting if inflight is not nil.
+// If signals is not nil, SIGINT and SIGTERM are sent to it from Mount on instead of unmounting right away, the
+// receiver is expected to Unmount
+func NewFuseHost(fs fuse.FileSystemInterface, inflight *InflightTable, signals chan<- os.Signal) *FuseHost {
+	h := &FuseHost{
+		inflight: inflight,
+		signals:  signals,
+		ready:    make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	h.host = fuse.NewFileSystemHost(&hostFS{FileSystemInterface: fs, host: h})
+	return h
+}
+
+func (h *FuseHost) SetUseIno(value bool) {
+	h.host.SetUseIno(value)
+}
+
+// Mount mounts the filesystem, it returns once the mount is live
+func (h *FuseHost) Mount(mountpoint string, opts []string) error {
+	h.mountpoint = mountpoint
+	if h.signals != nil {
+		if _, err := cgofuseSigc(h.host); err != nil {
+			return err
+		}
+
+		// subscribed before cgofuse, a signal is never left to the default action or to cgofuse alone
+		signal.Notify(h.signals, syscall.SIGINT, syscall.SIGTERM)
+	}
+	go func() {
+		defer close(h.done)
+		h.host.Mount(mountpoint, opts)
+	}()
+
+	select {
+	case <-h.ready:
+		return nil
+	case <-h.done:
+		return fmt.Errorf("failed to mount %s", mountpoint)
+	}
+}
+
+// Done is closed once the filesystem is unmounted, by Unmount or externally
+func (h *FuseHost) Done() <-chan struct{} {
+	return h.done
+}
+
+// Unmount waits up to timeout for the in-flight operations to finish, then unmounts the filesystem. It returns once
+// the FUSE loop exited, concurrent calls are fine
+func (h *FuseHost) Unmount(timeout time.Duration) error {
+	h.drain(timeout)
+
+	select {
+	case <-h.done:
+		return nil
+	default:
+	}
+
+	if !h.host.Unmount() {
+		select {
+		case <-h.done:
+			return nil
+		default:
+			return fmt.Errorf("failed to unmount %s", h.mountpoint)
+		}
+	}
+	<-h.done
+	return nil
+}
+
+func (h *FuseHost) drain(timeout time.Duration) {
+	if h.inflight == nil {
+		return
+	}
+
+	deadline := time.Now().Add(timeout)
+	for n := h.inflight.Len(); n > 0; n = h.inflight.Len() {
+		if time.Now().After(deadline) {
+			log.Warn().Int("inflight", n).Str("mountpoint", h.mountpoint).
+				Msg("Timed out draining in-flight operations, unmounting anyway")
+			return
+		}
+		select {
+		case <-h.done:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
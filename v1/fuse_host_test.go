@@ -0,0 +1,59 @@
+//go:build linux || windows
+
+package fusestream
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+func TestFuseHostDrain(t *testing.T) {
+	inflight := NewInflightTable()
+	h := NewFuseHost(&fuse.FileSystemBase{}, inflight, nil)
+
+	// start tracks an operation finishing after delay
+	const delay = 300 * time.Millisecond
+	start := func() chan struct{} {
+		op := &InflightOp{Op: "fuse.Getattr", Path: "/a"}
+		inflight.add(op)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			time.Sleep(delay)
+			inflight.remove(op)
+		}()
+		return done
+	}
+
+	// the drain times out while the operation runs
+	done := start()
+	begin := time.Now()
+	h.drain(50 * time.Millisecond)
+	require.Less(t, time.Since(begin), delay)
+	require.Equal(t, 1, inflight.Len())
+	<-done
+
+	// the drain returns once the operation finished
+	done = start()
+	h.drain(10 * time.Second)
+	require.Zero(t, inflight.Len())
+	<-done
+
+	// Unmount drains and returns right away once the filesystem is unmounted
+	done = start()
+	close(h.done)
+	require.NoError(t, h.Unmount(10*time.Second))
+	<-done
+}
+
+// TestCgofuseSigc pins the unexported field FuseHost stops to drain on signals, see cgofuseSigc
+func TestCgofuseSigc(t *testing.T) {
+	h := NewFuseHost(&fuse.FileSystemBase{}, nil, make(chan os.Signal, 1))
+	sigc, err := cgofuseSigc(h.host)
+	require.NoError(t, err)
+	require.Nil(t, *sigc)
+}
@@ -0,0 +1,27 @@
+//go:build linux || windows
+
+package fusestream
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// TestSlowFSWithoutInflight calls SlowFS outside of a FUSE thread, the calling process mustn't be fetched from FUSE
+// without in-flight tracking and faults
+func TestSlowFSWithoutInflight(t *testing.T) {
+	baseDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, "a"), []byte("data"), 0644))
+
+	fs := NewSlowFS(baseDir, NewFaultManager())
+	fs.Inflight = nil
+
+	var stat fuse.Stat_t
+	require.Zero(t, fs.Getattr("/a", &stat, ^uint64(0)))
+	require.Equal(t, int64(4), stat.Size)
+	require.Equal(t, int64(1), fs.Stats.Count())
+}
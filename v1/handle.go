@@ -0,0 +1,112 @@
+package fusestream
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+// OpenHandle is a file opened through SlowFS
+type OpenHandle struct {
+	Fh    uint64
+	Path  string
+	Flags int
+
+	// ctx carries the handle span, which lives from open to release
+	ctx  context.Context
+	span trace.Span
+}
+
+// Context returns the parent context for the operations on the handle
+func (h *OpenHandle) Context() context.Context {
+	if h == nil || h.ctx == nil {
+		return context.Background()
+	}
+	return h.ctx
+}
+
+// End ends the handle span
+func (h *OpenHandle) End() {
+	if h != nil && h.span != nil {
+		h.span.End()
+	}
+}
+
+type HandleTable struct {
+	mutex   sync.RWMutex
+	handles map[uint64]*OpenHandle // guarded by mutex
+}
+
+func NewHandleTable() *HandleTable {
+	return &HandleTable{
+		handles: make(map[uint64]*OpenHandle),
+	}
+}
+
+func (t *HandleTable) Add(h *OpenHandle) {
+	t.mutex.Lock()
+	t.handles[h.Fh] = h
+	t.mutex.Unlock()
+}
+
+func (t *HandleTable) Get(fh uint64) *OpenHandle {
+	t.mutex.RLock()
+	h := t.handles[fh]
+	t.mutex.RUnlock()
+	return h
+}
+
+func (t *HandleTable) Remove(fh uint64) *OpenHandle {
+	t.mutex.Lock()
+	h := t.handles[fh]
+	delete(t.handles, fh)
+	t.mutex.Unlock()
+	return h
+}
+
+func (t *HandleTable) Len() int {
+	t.mutex.RLock()
+	n := len(t.handles)
+	t.mutex.RUnlock()
+	return n
+}
+
+// openFlagMask converts the flags to the native open flags
+func openFlagMask(flags []pb.OpenFlag) (int, error) {
+	mask := 0
+	for _, flag := range flags {
+		bits, ok := openFlagBits[flag]
+		if !ok {
+			return 0, fmt.Errorf("unsupported open flag: %v", flag)
+		}
+		mask |= bits
+	}
+	return mask, nil
+}
+
+// matchHandle reports whether the handle is one of fhs and was opened with all of the flags.
+// Empty fhs or flags match any handle.
+func matchHandle(h *OpenHandle, fhs []uint64, flags []pb.OpenFlag) bool {
+	if len(fhs) == 0 && len(flags) == 0 {
+		return true
+	}
+
+	if h == nil {
+		return false
+	}
+
+	if len(fhs) > 0 && !slices.Contains(fhs, h.Fh) {
+		return false
+	}
+
+	mask, err := openFlagMask(flags)
+	if err != nil {
+		return false
+	}
+	return h.Flags&mask == mask
+}
@@ -0,0 +1,124 @@
+package fusestream
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InflightOp is an operation not finished yet
+type InflightOp struct {
+	ID   uint64
+	Op   string
+	Path string
+	// Offset and Length of NBD I/Os
+	Offset int64
+	Length int64
+	// Pid of the calling process, 0 if unknown
+	Pid   int
+	Start time.Time
+
+	// FaultIDs and Delay are of the fault being applied
+	FaultIDs []int32
+	Delay    time.Duration
+	// Delaying is true while the operation sleeps in the injected delay
+	Delaying bool
+}
+
+// InflightTable tracks the in-flight operations of a mount or an export
+type InflightTable struct {
+	mutex  sync.Mutex
+	nextID uint64                 // guarded by mutex
+	ops    map[uint64]*InflightOp // guarded by mutex
+}
+
+func NewInflightTable() *InflightTable {
+	return &InflightTable{
+		ops: make(map[uint64]*InflightOp),
+	}
+}
+
+// add starts tracking op, its ID and Start are set
+func (t *InflightTable) add(op *InflightOp) {
+	t.mutex.Lock()
+	op.ID = t.nextID
+	t.nextID++
+	op.Start = time.Now()
+	t.ops[op.ID] = op
+	t.mutex.Unlock()
+}
+
+func (t *InflightTable) remove(op *InflightOp) {
+	t.mutex.Lock()
+	delete(t.ops, op.ID)
+	t.mutex.Unlock()
+}
+
+// setFault records the fault applied to op
+func (t *InflightTable) setFault(op *InflightOp, fault *Fault) {
+	t.mutex.Lock()
+	op.FaultIDs = fault.IDs
+	if fault.DelayDuration != nil {
+		op.Delay = *fault.DelayDuration
+	}
+	t.mutex.Unlock()
+}
+
+func (t *InflightTable) setDelaying(op *InflightOp, delaying bool) {
+	t.mutex.Lock()
+	op.Delaying = delaying
+	t.mutex.Unlock()
+}
+
+func (t *InflightTable) Len() int {
+	t.mutex.Lock()
+	n := len(t.ops)
+	t.mutex.Unlock()
+	return n
+}
+
+// List returns copies of the in-flight operations, the longest running first
+func (t *InflightTable) List() []InflightOp {
+	t.mutex.Lock()
+	ops := make([]InflightOp, 0, len(t.ops))
+	for _, op := range t.ops {
+		ops = append(ops, *op)
+	}
+	t.mutex.Unlock()
+
+	slices.SortFunc(ops, func(a, b InflightOp) int {
+		return cmp.Or(a.Start.Compare(b.Start), cmp.Compare(a.ID, b.ID))
+	})
+	return ops
+}
+
+// inflightFault marks the operation as delaying while the fault sleeps
+type inflightFault struct {
+	FaultExecute
+	table *InflightTable
+	op    *InflightOp
+}
+
+func (f *inflightFault) Delay() {
+	f.table.setDelaying(f.op, true)
+	defer f.table.setDelaying(f.op, false)
+	f.FaultExecute.Delay()
+}
+
+// trackFault records fault on the in-flight operation of span, fault is returned as is if the span is not tracked
+func trackFault(span trace.Span, fault FaultExecute) FaultExecute {
+	s, ok := span.(*opSpan)
+	if !ok || s.inflight == nil {
+		return fault
+	}
+	v, ok := fault.(*Fault)
+	if !ok {
+		return fault
+	}
+
+	s.inflight.setFault(s.inflightOp, v)
+	return &inflightFault{FaultExecute: fault, table: s.inflight, op: s.inflightOp}
+}
@@ -0,0 +1,106 @@
+package fusestream
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "fusestream"
+
+// metricsReadOps and metricsWriteOps are the operations counted in the read and written bytes
+var (
+	metricsReadOps  = map[string]bool{"fuse.Read": true, "nbd.ReadAt": true}
+	metricsWriteOps = map[string]bool{"fuse.Write": true, "nbd.WriteAt": true}
+)
+
+// Metrics collects the live metrics of the operations, it's fed by the same spans exported as traces
+type Metrics struct {
+	registry *prometheus.Registry
+
+	ops        *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	readBytes  prometheus.Counter
+	writeBytes prometheus.Counter
+	errors     *prometheus.CounterVec
+	faults     *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		ops: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "ops_total",
+			Help:      "Number of operations",
+		}, []string{"op"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "op_duration_seconds",
+			Help:      "Latency of operations, including the injected delay",
+			Buckets:   prometheus.ExponentialBuckets(10e-6, 4, 10),
+		}, []string{"op"}),
+		readBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "read_bytes_total",
+			Help:      "Bytes read",
+		}),
+		writeBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "written_bytes_total",
+			Help:      "Bytes written",
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "errors_total",
+			Help:      "Number of failed operations by errno",
+		}, []string{"op", "errno"}),
+		faults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "faults_injected_total",
+			Help:      "Number of operations a fault is injected into",
+		}, []string{"fault_id"}),
+	}
+
+	m.registry.MustRegister(
+		m.ops, m.duration, m.readBytes, m.writeBytes, m.errors, m.faults,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// TrackOpenHandles exports the number of open handles reported by count
+func (m *Metrics) TrackOpenHandles(count func() int) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "open_handles",
+		Help:      "Number of open file handles",
+	}, func() float64 { return float64(count()) }))
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) observe(r *opResult) {
+	m.ops.WithLabelValues(r.op).Inc()
+	m.duration.WithLabelValues(r.op).Observe(r.elapsed.Seconds())
+	if r.length > 0 {
+		if metricsReadOps[r.op] {
+			m.readBytes.Add(float64(r.length))
+		} else if metricsWriteOps[r.op] {
+			m.writeBytes.Add(float64(r.length))
+		}
+	}
+	if r.errc < 0 {
+		m.errors.WithLabelValues(r.op, strconv.FormatInt(-r.errc, 10)).Inc()
+	}
+	for _, id := range r.faultIDs {
+		m.faults.WithLabelValues(strconv.FormatInt(int64(id), 10)).Inc()
+	}
+}
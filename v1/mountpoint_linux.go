@@ -0,0 +1,37 @@
+package fusestream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// CleanStaleMountpoint lazily unmounts the mountpoint if it's left by a crashed FUSE mount, i.e. accessing it fails
+// with ENOTCONN. It returns whether the mountpoint was stale
+func CleanStaleMountpoint(mountpoint string) (bool, error) {
+	_, err := os.Stat(mountpoint)
+	if !errors.Is(err, syscall.ENOTCONN) {
+		return false, nil
+	}
+
+	if err := syscall.Unmount(mountpoint, syscall.MNT_DETACH); err == nil {
+		return true, nil
+	}
+
+	// unprivileged users unmount by the setuid fusermount
+	for _, name := range []string{"fusermount3", "fusermount"} {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		out, err := exec.Command(path, "-u", "-z", mountpoint).CombinedOutput()
+		if err != nil {
+			return true, fmt.Errorf("%s failed: %w, %s", name, err, bytes.TrimSpace(out))
+		}
+		return true, nil
+	}
+	return true, fmt.Errorf("failed to unmount stale mountpoint %s, fusermount not found", mountpoint)
+}
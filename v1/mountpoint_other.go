@@ -0,0 +1,8 @@
+//go:build !linux
+
+package fusestream
+
+// CleanStaleMountpoint is a no-op, WinFsp removes the mount of a crashed process
+func CleanStaleMountpoint(string) (bool, error) {
+	return false, nil
+}
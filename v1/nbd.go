@@ -2,63 +2,127 @@ package fusestream
 
 import (
 	"context"
+	"errors"
 	"os"
+	"syscall"
 
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/zperf/fusestream/pb"
 )
 
 type FileBackend struct {
+	export string
 	file   *os.File
 	faults *FaultManager
+	// Metrics is fed by the operations if not nil
+	Metrics  *Metrics
+	Stats    *Stats
+	Inflight *InflightTable
+
+	// ctx carries the backend span, which lives as long as the server and is linked from every operation
+	ctx  context.Context
+	span trace.Span
 }
 
-func NewFileBackend(file *os.File, faults *FaultManager) *FileBackend {
-	return &FileBackend{file: file, faults: faults}
+func NewFileBackend(ctx context.Context, export string, file *os.File, faults *FaultManager) *FileBackend {
+	ctx, span := tracer.Start(ctx, backendSpanName, trace.WithAttributes(
+		attribute.String("export", export),
+		attribute.String("backend_file", file.Name()),
+	))
+	return &FileBackend{
+		export:   export,
+		file:     file,
+		faults:   faults,
+		Stats:    NewStats(),
+		Inflight: NewInflightTable(),
+		ctx:      ctx,
+		span:     span,
+	}
+}
+
+// startSpan starts an operation span, the export is recorded on every span so records can be grouped by it.
+// The span is a root linked to the backend span, so every operation is a trace of its own and sampled on its own.
+// The operation is in-flight until the span ends
+func (f *FileBackend) startSpan(name string, offset int64, length int) trace.Span {
+	_, span := tracer.Start(f.ctx, name,
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(f.ctx)),
+		trace.WithAttributes(attribute.String("export", f.export)))
+	op := &InflightOp{Op: name, Path: f.export, Offset: offset, Length: int64(length)}
+	return observeSpan(span, op, f.Metrics, f.Stats, f.Inflight)
+}
+
+// getFault returns the fault of the I/O, the fault is recorded on the in-flight operation of span
+func (f *FileBackend) getFault(span trace.Span, op pb.NbdOp, offset int64, length int) FaultExecute {
+	return trackFault(span, f.faults.GetNbdFault(op, offset, length))
+}
+
+// setSpanResult records err and errc, errc is the negative errno of err or rc if rc is negative
+func setSpanResult(span trace.Span, rc int64, err error) {
+	errc := min(rc, 0)
+	if err != nil {
+		var errno syscall.Errno
+		if errors.As(err, &errno) {
+			errc = -int64(errno)
+		} else {
+			errc = -int64(syscall.EIO)
+		}
+		span.SetAttributes(attribute.String("err", err.Error()))
+	} else {
+		span.SetAttributes(attribute.String("err", ""))
+	}
+	span.SetAttributes(attribute.Int64("errc", errc))
+}
+
+// Close ends the backend span, the file is owned by the caller
+func (f *FileBackend) Close() {
+	f.span.End()
 }
 
 func (f *FileBackend) ReadAt(p []byte, off int64) (n int, err error) {
-	_, span := tracer.Start(context.TODO(), "nbd.ReadAt")
+	span := f.startSpan("nbd.ReadAt", off, len(p))
 	defer span.End()
 
 	n, err = f.file.ReadAt(p, off)
 
-	fault := f.faults.GetNbdFault(pb.NbdOp_NBD_READAT, off, len(p))
+	fault := f.getFault(span, pb.NbdOp_NBD_READAT, off, len(p))
 	fault.Delay()
 	n = int(fault.MayReplaceErrorCode(int64(n)))
 	err = fault.MayReplaceError(err)
+	fault.RecordToSpan(span)
 
-	span.SetAttributes(attribute.Int64("offset", off), attribute.Int("n", n))
-	if err != nil {
-		span.SetAttributes(attribute.String("err", err.Error()))
-	} else {
-		span.SetAttributes(attribute.String("err", ""))
-	}
+	span.SetAttributes(
+		attribute.Int64("offset", off),
+		attribute.Int("length", max(n, 0)),
+	)
+	setSpanResult(span, int64(n), err)
 	return
 }
 
 func (f *FileBackend) WriteAt(p []byte, off int64) (n int, err error) {
-	_, span := tracer.Start(context.TODO(), "nbd.WriteAt")
+	span := f.startSpan("nbd.WriteAt", off, len(p))
 	defer span.End()
 
 	n, err = f.file.WriteAt(p, off)
 
-	fault := f.faults.GetNbdFault(pb.NbdOp_NBD_WRITEAT, off, len(p))
+	fault := f.getFault(span, pb.NbdOp_NBD_WRITEAT, off, len(p))
 	fault.Delay()
 	n = int(fault.MayReplaceErrorCode(int64(n)))
 	err = fault.MayReplaceError(err)
-	span.SetAttributes(attribute.Int64("offset", off), attribute.Int("n", n))
-	if err != nil {
-		span.SetAttributes(attribute.String("err", err.Error()))
-	} else {
-		span.SetAttributes(attribute.String("err", ""))
-	}
+	fault.RecordToSpan(span)
+
+	span.SetAttributes(
+		attribute.Int64("offset", off),
+		attribute.Int("length", max(n, 0)),
+	)
+	setSpanResult(span, int64(n), err)
 	return
 }
 
 func (f *FileBackend) Size() (size int64, err error) {
-	_, span := tracer.Start(context.TODO(), "nbd.Size")
+	span := f.startSpan("nbd.Size", 0, 0)
 	defer span.End()
 
 	stat, err := f.file.Stat()
@@ -68,34 +132,28 @@ func (f *FileBackend) Size() (size int64, err error) {
 		size = stat.Size()
 	}
 
-	fault := f.faults.GetNbdFault(pb.NbdOp_NBD_SIZE, 0, 0)
+	fault := f.getFault(span, pb.NbdOp_NBD_SIZE, 0, 0)
 	fault.Delay()
 	size = fault.MayReplaceErrorCode(size)
 	err = fault.MayReplaceError(err)
+	fault.RecordToSpan(span)
 
 	span.SetAttributes(attribute.Int64("size", size))
-	if err != nil {
-		span.SetAttributes(attribute.String("err", err.Error()))
-	} else {
-		span.SetAttributes(attribute.String("err", ""))
-	}
+	setSpanResult(span, size, err)
 	return
 }
 
 func (f *FileBackend) Sync() (err error) {
-	_, span := tracer.Start(context.TODO(), "nbd.Sync")
+	span := f.startSpan("nbd.Sync", 0, 0)
 	defer span.End()
 
 	err = f.file.Sync()
 
-	fault := f.faults.GetNbdFault(pb.NbdOp_NBD_SYNC, 0, 0)
+	fault := f.getFault(span, pb.NbdOp_NBD_SYNC, 0, 0)
 	fault.Delay()
 	err = fault.MayReplaceError(err)
+	fault.RecordToSpan(span)
 
-	if err != nil {
-		span.SetAttributes(attribute.String("err", err.Error()))
-	} else {
-		span.SetAttributes(attribute.String("err", ""))
-	}
+	setSpanResult(span, 0, err)
 	return err
 }
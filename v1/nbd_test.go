@@ -0,0 +1,265 @@
+package fusestream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+func TestFileBackend(t *testing.T) {
+	suite.Run(t, new(FileBackendTestSuite))
+}
+
+type FileBackendTestSuite struct {
+	suite.Suite
+	recorder *tracetest.SpanRecorder
+	file     *os.File
+	// tracer is the package tracer replaced by the test
+	tracer trace.Tracer
+}
+
+func (s *FileBackendTestSuite) SetupTest() {
+	s.recorder = tracetest.NewSpanRecorder()
+	s.tracer = tracer
+	tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(s.recorder)).Tracer(tracerName)
+
+	var err error
+	s.file, err = os.Create(filepath.Join(s.T().TempDir(), "backend"))
+	s.Require().NoError(err)
+	s.Require().NoError(s.file.Truncate(4096))
+}
+
+func (s *FileBackendTestSuite) TearDownTest() {
+	tracer = s.tracer
+	s.NoError(s.file.Close())
+}
+
+func (s *FileBackendTestSuite) TestFaultSpan() {
+	faults := NewFaultManager()
+	rc := int64(-1)
+	id := faults.NbdInject(&NbdFault{
+		Op:                     pb.NbdOp_NBD_READAT,
+		ReturnValue:            &rc,
+		ReturnValuePossibility: 1,
+	})
+
+	backend := NewFileBackend(context.Background(), "export", s.file, faults)
+	n, err := backend.ReadAt(make([]byte, 512), 0)
+	s.NoError(err)
+	s.Equal(-1, n)
+	backend.Close()
+
+	spans := s.recorder.Ended()
+	s.Require().Len(spans, 2)
+	readSpan, backendSpan := spans[0], spans[1]
+	s.Equal("nbd.ReadAt", readSpan.Name())
+	s.Equal("nbd.Backend", backendSpan.Name())
+	// operations are roots linked to the backend span, so they're sampled one by one
+	s.False(readSpan.Parent().IsValid())
+	s.NotEqual(backendSpan.SpanContext().TraceID(), readSpan.SpanContext().TraceID())
+	s.Require().Len(readSpan.Links(), 1)
+	s.Equal(backendSpan.SpanContext().SpanID(), readSpan.Links()[0].SpanContext.SpanID())
+
+	s.Contains(readSpan.Attributes(), attribute.Int("fault_id", int(id)))
+	s.Require().Len(readSpan.Events(), 1)
+	event := readSpan.Events()[0]
+	s.Equal("fault.injected", event.Name)
+	s.Contains(event.Attributes, attribute.Int64("rc", -1))
+	s.Contains(event.Attributes, attribute.Int64("replaced_rc", 512))
+}
+
+func (s *FileBackendTestSuite) TestNoFault() {
+	backend := NewFileBackend(context.Background(), "export", s.file, NewFaultManager())
+	n, err := backend.WriteAt(make([]byte, 512), 0)
+	s.NoError(err)
+	s.Equal(512, n)
+	backend.Close()
+
+	spans := s.recorder.Ended()
+	s.Require().Len(spans, 2)
+	s.Empty(spans[0].Events())
+	for _, attr := range spans[0].Attributes() {
+		s.NotEqual(attribute.Key("fault_id"), attr.Key)
+	}
+
+	r := NewIORecord(spans[0])
+	s.Equal("nbd.WriteAt", r.Name)
+	s.Equal("export", r.Path)
+	s.Equal(int32(512), r.Length)
+	s.Equal(int32(0), r.Errc)
+}
+
+func (s *FileBackendTestSuite) TestErrorSpan() {
+	backend := NewFileBackend(context.Background(), "export", s.file, NewFaultManager())
+	s.Require().NoError(s.file.Close())
+	_, err := backend.ReadAt(make([]byte, 512), 0)
+	s.Error(err)
+	backend.Close()
+
+	s.file, err = os.Open(s.file.Name())
+	s.Require().NoError(err)
+
+	r := NewIORecord(s.recorder.Ended()[0])
+	s.Equal("nbd.ReadAt", r.Name)
+	s.Equal(int32(0), r.Length)
+	s.Negative(r.Errc)
+}
+
+func (s *FileBackendTestSuite) TestSampledPerOp() {
+	tracer = sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.5))),
+		sdktrace.WithSpanProcessor(s.recorder)).Tracer(tracerName)
+
+	backend := NewFileBackend(context.Background(), "export", s.file, NewFaultManager())
+	for range 200 {
+		_, err := backend.ReadAt(make([]byte, 512), 0)
+		s.Require().NoError(err)
+	}
+	backend.Close()
+
+	// the ratio applies to every I/O instead of to the backend once
+	reads := 0
+	for _, span := range s.recorder.Ended() {
+		if span.Name() == "nbd.ReadAt" {
+			reads++
+		}
+	}
+	s.Greater(reads, 0)
+	s.Less(reads, 200)
+}
+
+func (s *FileBackendTestSuite) TestMetrics() {
+	faults := NewFaultManager()
+	rc := -int64(syscall.EIO)
+	id := faults.NbdInject(&NbdFault{
+		Op:                     pb.NbdOp_NBD_READAT,
+		ReturnValue:            &rc,
+		ReturnValuePossibility: 1,
+	})
+
+	metrics := NewMetrics()
+	backend := NewFileBackend(context.Background(), "export", s.file, faults)
+	backend.Metrics = metrics
+	_, err := backend.WriteAt(make([]byte, 512), 0)
+	s.Require().NoError(err)
+	_, err = backend.WriteAt(make([]byte, 1024), 512)
+	s.Require().NoError(err)
+	_, _ = backend.ReadAt(make([]byte, 512), 0)
+	backend.Close()
+
+	handles := 3
+	metrics.TrackOpenHandles(func() int { return handles })
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	s.Require().NoError(err)
+	text := string(body)
+
+	s.Contains(text, `fusestream_ops_total{op="nbd.WriteAt"} 2`)
+	s.Contains(text, `fusestream_ops_total{op="nbd.ReadAt"} 1`)
+	s.Contains(text, `fusestream_op_duration_seconds_count{op="nbd.WriteAt"} 2`)
+	s.Contains(text, "fusestream_written_bytes_total 1536")
+	s.Contains(text, "fusestream_read_bytes_total 0")
+	s.Contains(text, `fusestream_errors_total{errno="5",op="nbd.ReadAt"} 1`)
+	s.Contains(text, fmt.Sprintf(`fusestream_faults_injected_total{fault_id="%d"} 1`, id))
+	s.Contains(text, "fusestream_open_handles 3")
+}
+
+func (s *FileBackendTestSuite) TestStats() {
+	faults := NewFaultManager()
+	delay := time.Millisecond
+	id := faults.NbdInject(&NbdFault{
+		Op:               pb.NbdOp_NBD_WRITEAT,
+		Delay:            &delay,
+		DelayPossibility: 1,
+	})
+
+	backend := NewFileBackend(context.Background(), "export", s.file, faults)
+	for i := range 3 {
+		_, err := backend.WriteAt(make([]byte, 512), int64(i)*512)
+		s.Require().NoError(err)
+	}
+	backend.Close()
+
+	rpc := &Rpc{Faults: faults, Stats: backend.Stats}
+	rsp, err := rpc.GetStats(context.Background(), &pb.TargetRequest{})
+	s.Require().NoError(err)
+	s.Require().Len(rsp.LatencyBoundsNs, len(StatsLatencyBounds))
+	s.Require().Equal(int64(0), rsp.Delaying)
+	s.Require().Len(rsp.Ops, 1)
+	op := rsp.Ops[0]
+	s.Equal("nbd.WriteAt", op.Op)
+	s.Equal(int64(3), op.Count)
+	s.Equal(int64(1536), op.Bytes)
+	s.GreaterOrEqual(op.ElapsedNs, 3*delay.Nanoseconds())
+	s.Len(op.LatencyBuckets, len(StatsLatencyBounds)+1)
+	s.Zero(op.LatencyBuckets[0])
+
+	list, err := rpc.ListFaults(context.Background(), &pb.TargetRequest{})
+	s.Require().NoError(err)
+	s.Require().Len(list.NbdFaults, 1)
+	s.Equal(id, list.NbdFaults[0].Id)
+	s.Equal(int64(3), list.NbdFaults[0].Hits)
+
+	_, err = (&Rpc{Faults: faults}).GetStats(context.Background(), &pb.TargetRequest{})
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *FileBackendTestSuite) TestInflight() {
+	faults := NewFaultManager()
+	delay := 500 * time.Millisecond
+	id := faults.NbdInject(&NbdFault{
+		Op:               pb.NbdOp_NBD_WRITEAT,
+		Delay:            &delay,
+		DelayPossibility: 1,
+	})
+
+	backend := NewFileBackend(context.Background(), "export", s.file, faults)
+	defer backend.Close()
+	rpc := &Rpc{Faults: faults, Inflight: backend.Inflight}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = backend.WriteAt(make([]byte, 512), 1024)
+	}()
+
+	var op *pb.InflightOp
+	s.Require().Eventually(func() bool {
+		rsp, err := rpc.ListInflight(context.Background(), &pb.TargetRequest{})
+		if err != nil || len(rsp.Ops) != 1 || !rsp.Ops[0].Delaying {
+			return false
+		}
+		op = rsp.Ops[0]
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	s.Equal("nbd.WriteAt", op.Op)
+	s.Equal("export", op.Path)
+	s.Equal(int64(1024), op.Offset)
+	s.Equal(int64(512), op.Length)
+	s.Equal([]int32{id}, op.FaultIds)
+	s.Equal(delay.Nanoseconds(), op.DelayNs)
+
+	<-done
+	s.Zero(backend.Inflight.Len())
+	_, err := (&Rpc{Faults: faults}).ListInflight(context.Background(), &pb.TargetRequest{})
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
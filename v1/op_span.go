@@ -0,0 +1,82 @@
+package fusestream
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// opResult is a finished operation picked from the attributes recorded on its span
+type opResult struct {
+	op       string
+	path     string
+	elapsed  time.Duration
+	length   int64
+	errc     int64 // zero or a negative errno
+	faultIDs []int32
+}
+
+// observeSpan wraps the span of operation op, op is tracked in inflight until the span ends, then metrics and stats
+// are fed. Nil ones are skipped
+func observeSpan(span trace.Span, op *InflightOp, metrics *Metrics, stats *Stats, inflight *InflightTable) trace.Span {
+	if metrics == nil && stats == nil && inflight == nil {
+		return span
+	}
+
+	s := &opSpan{Span: span, metrics: metrics, stats: stats, result: opResult{op: op.Op}, start: time.Now()}
+	if inflight != nil {
+		inflight.add(op)
+		s.inflight = inflight
+		s.inflightOp = op
+	}
+	return s
+}
+
+type opSpan struct {
+	trace.Span
+	metrics *Metrics
+	stats   *Stats
+	result  opResult
+	start   time.Time
+
+	inflight   *InflightTable
+	inflightOp *InflightOp
+
+	// caller is the calling process of a FUSE operation, nil until it's needed
+	caller *callerContext
+}
+
+type callerContext struct {
+	uid uint32
+	gid uint32
+	pid int
+}
+
+func (s *opSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.Span.SetAttributes(kv...)
+	for _, attr := range kv {
+		switch attr.Key {
+		case "path", "oldpath":
+			s.result.path = attr.Value.AsString()
+		case "length":
+			s.result.length = attr.Value.AsInt64()
+		case "errc":
+			s.result.errc = attr.Value.AsInt64()
+		}
+	}
+}
+
+func (s *opSpan) End(options ...trace.SpanEndOption) {
+	s.Span.End(options...)
+	s.result.elapsed = time.Since(s.start)
+	if s.inflight != nil {
+		s.inflight.remove(s.inflightOp)
+	}
+	if s.metrics != nil {
+		s.metrics.observe(&s.result)
+	}
+	if s.stats != nil {
+		s.stats.observe(&s.result)
+	}
+}
@@ -0,0 +1,15 @@
+package fusestream
+
+import (
+	"syscall"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+// openFlagBits has no O_TRUNC, libfuse2 does not enable atomic_o_trunc so the kernel never passes it to Open
+var openFlagBits = map[pb.OpenFlag]int{
+	pb.OpenFlag_OPEN_FLAG_DIRECT: syscall.O_DIRECT,
+	pb.OpenFlag_OPEN_FLAG_SYNC:   syscall.O_SYNC,
+	pb.OpenFlag_OPEN_FLAG_DSYNC:  syscall.O_DSYNC,
+	pb.OpenFlag_OPEN_FLAG_APPEND: syscall.O_APPEND,
+}
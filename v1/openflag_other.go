@@ -0,0 +1,15 @@
+//go:build !linux
+
+package fusestream
+
+import (
+	"syscall"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+// openFlagBits has no O_TRUNC, see openflag_linux.go
+var openFlagBits = map[pb.OpenFlag]int{
+	pb.OpenFlag_OPEN_FLAG_SYNC:   syscall.O_SYNC,
+	pb.OpenFlag_OPEN_FLAG_APPEND: syscall.O_APPEND,
+}
@@ -2,44 +2,153 @@ package fusestream
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
-	"github.com/negrel/assert"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
-	"go.opentelemetry.io/otel/trace"
 )
 
 const tracerName = "github.com/fanyang89/slowio"
 
-var tracer trace.Tracer
+// tracer follows the global provider, spans are dropped until SetupOTelSDK is called
+var tracer = otel.Tracer(tracerName)
 
-func SetupOTelSDK(exporter sdktrace.SpanExporter) {
-	res, err := resource.New(context.Background(),
-		resource.WithAttributes(
-			semconv.ServiceNamespaceKey.String("zbs"),
-			semconv.ServiceNameKey.String("fusestream"),
-		),
-	)
-	assert.NoError(err)
+// OTelOptions configures span exporting, spans are dropped if no exporter is set
+type OTelOptions struct {
+	// ExportPath is the parquet file path
+	ExportPath string
+	// ExportRotate enables the rotation of the parquet file, see NewRotatingSpanExporter
+	ExportRotate SpanExporterOptions
+	// OTLPEndpoint is the OTLP collector URL, e.g. http://localhost:4317
+	OTLPEndpoint string
+	// OTLPProtocol is either grpc or http
+	OTLPProtocol string
+	SampleRatio  float64
+
+	ServiceNamespace string
+	ServiceName      string
+	// Instance identifies the mount or the NBD export, exported as service.instance.id
+	Instance           string
+	ResourceAttributes map[string]string
+}
+
+// SetupOTelSDK installs the global tracer provider, the returned function flushes pending spans
+// and shuts down all exporters
+func SetupOTelSDK(ctx context.Context, opts OTelOptions) (func(context.Context) error, error) {
+	var exporters []sdktrace.SpanExporter
+	shutdownAll := func(ctx context.Context) error {
+		var errs []error
+		for _, e := range exporters {
+			errs = append(errs, e.Shutdown(ctx))
+		}
+		return errors.Join(errs...)
+	}
+
+	if opts.ExportPath != "" {
+		exporter, err := NewRotatingSpanExporter(opts.ExportPath, opts.ExportRotate)
+		if err != nil {
+			return nil, fmt.Errorf("create parquet exporter failed: %w", err)
+		}
+		exporters = append(exporters, exporter)
+	}
+
+	if opts.OTLPEndpoint != "" {
+		exporter, err := NewOTLPExporter(ctx, opts.OTLPProtocol, opts.OTLPEndpoint)
+		if err != nil {
+			_ = shutdownAll(ctx)
+			return nil, fmt.Errorf("create OTLP exporter failed: %w", err)
+		}
+		exporters = append(exporters, exporter)
+	}
+
+	if len(exporters) == 0 {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.ServiceNamespaceKey.String(opts.ServiceNamespace),
+		semconv.ServiceNameKey.String(opts.ServiceName),
+	}
+	if opts.Instance != "" {
+		attrs = append(attrs, semconv.ServiceInstanceIDKey.String(opts.Instance))
+	}
+	for k, v := range opts.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	res, err := resource.New(ctx, resource.WithFromEnv(), resource.WithAttributes(attrs...))
+	if err != nil {
+		_ = shutdownAll(ctx)
+		return nil, err
+	}
+
+	var exporter sdktrace.SpanExporter = exporters[0]
+	if len(exporters) > 1 {
+		exporter = NewTeeSpanExporter(exporters...)
+	}
 
 	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithResource(res), sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(time.Second)))
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(time.Second)))
 	otel.SetTracerProvider(tracerProvider)
 	tracer = tracerProvider.Tracer(tracerName)
-	return
+	return tracerProvider.Shutdown, nil
 }
 
+// NewOTLPExporter creates an OTLP span exporter, plain-text is used for http:// endpoints
+func NewOTLPExporter(ctx context.Context, protocol string, endpoint string) (sdktrace.SpanExporter, error) {
+	switch protocol {
+	case "", "grpc":
+		return otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+	case "http":
+		return otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol: %s", protocol)
+	}
+}
+
+// IORecordSchemaVersion is stored in the parquet metadata under IORecordSchemaVersionKey,
+// bump it when the IORecord layout changes
+const IORecordSchemaVersion = 2
+
+const IORecordSchemaVersionKey = "fusestream.schema_version"
+
+// IORecord is a row of the exported parquet file, one per span
 type IORecord struct {
-	Name        string
-	StartTimeNs int64
-	ElapsedNs   int64
-	Offset      int64
-	Length      int32
-	Path        string
+	// Name is the span name, e.g. fuse.Read
+	Name        string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	StartTimeNs int64  `parquet:"name=start_time_ns, type=INT64"`
+	ElapsedNs   int64  `parquet:"name=elapsed_ns, type=INT64"`
+	// Offset is the offset of read and write, or the size of truncate and nbd.Size
+	Offset int64 `parquet:"name=offset, type=INT64"`
+	// Length is the bytes transferred by read and write
+	Length int32 `parquet:"name=length, type=INT32"`
+	// Path is the path of the operation, the old path of rename and link, the target of symlink,
+	// or the export of NBD operations
+	Path string `parquet:"name=path, type=BYTE_ARRAY, convertedtype=UTF8"`
+	// NewPath is the new path of rename, link and symlink, or the target of readlink
+	NewPath string `parquet:"name=new_path, type=BYTE_ARRAY, convertedtype=UTF8"`
+	// Errc is the negative errno of the operation, 0 on success
+	Errc int32 `parquet:"name=errc, type=INT32"`
+	// Fh is the file handle, -1 if the operation is called by path
+	Fh    *int64 `parquet:"name=fh, type=INT64, repetitiontype=OPTIONAL"`
+	Flags int32  `parquet:"name=flags, type=INT32"`
+	Mode  int32  `parquet:"name=mode, type=INT32"`
+	// FaultID is the first fault injected to the operation
+	FaultID      *int32 `parquet:"name=fault_id, type=INT32, repetitiontype=OPTIONAL"`
+	FaultDelayNs int64  `parquet:"name=fault_delay_ns, type=INT64"`
+	// FaultRc is the injected return code
+	FaultRc *int64 `parquet:"name=fault_rc, type=INT64, repetitiontype=OPTIONAL"`
+	// Instance is the service.instance.id resource attribute, the mountpoint or the NBD export
+	Instance string `parquet:"name=instance, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
 }
 
 func NewIORecord(span sdktrace.ReadOnlySpan) IORecord {
@@ -49,25 +158,74 @@ func NewIORecord(span sdktrace.ReadOnlySpan) IORecord {
 		ElapsedNs:   span.EndTime().Sub(span.StartTime()).Nanoseconds(),
 	}
 	r.FromAttributes(span.Attributes())
+
+	for _, event := range span.Events() {
+		if event.Name == faultEventName {
+			r.FromFaultEvent(event.Attributes)
+		}
+	}
+
+	if res := span.Resource(); res != nil {
+		if v, ok := res.Set().Value(semconv.ServiceInstanceIDKey); ok {
+			r.Instance = v.AsString()
+		}
+	}
 	return r
 }
 
 func (r *IORecord) FromAttributes(attrs []attribute.KeyValue) {
-	n := 0
+	var target, export string
 	for _, attr := range attrs {
 		switch attr.Key {
-		case "offset":
+		case "offset", "size":
 			r.Offset = attr.Value.AsInt64()
-			n |= 0b1
 		case "length":
 			r.Length = int32(attr.Value.AsInt64())
-			n |= 0b10
-		case "path":
+		case "path", "oldpath":
 			r.Path = attr.Value.AsString()
-			n |= 0b100
+		case "newpath":
+			r.NewPath = attr.Value.AsString()
+		case "target":
+			target = attr.Value.AsString()
+		case "export":
+			export = attr.Value.AsString()
+		case "errc":
+			r.Errc = int32(attr.Value.AsInt64())
+		case "fh":
+			fh, err := strconv.ParseUint(attr.Value.Emit(), 10, 64)
+			if err == nil {
+				v := int64(fh)
+				r.Fh = &v
+			}
+		case "flags":
+			r.Flags = int32(attr.Value.AsInt64())
+		case "mode":
+			r.Mode = int32(attr.Value.AsInt64())
+		case "fault_id":
+			id := int32(attr.Value.AsInt64())
+			r.FaultID = &id
 		}
-		if n == 0b111 {
-			break
+	}
+
+	// symlink has no path, readlink returns the target, NBD operations are grouped by export
+	if r.Path == "" && target != "" {
+		r.Path = target
+	} else if r.NewPath == "" {
+		r.NewPath = target
+	}
+	if r.Path == "" {
+		r.Path = export
+	}
+}
+
+func (r *IORecord) FromFaultEvent(attrs []attribute.KeyValue) {
+	for _, attr := range attrs {
+		switch attr.Key {
+		case "delay_ns":
+			r.FaultDelayNs = attr.Value.AsInt64()
+		case "rc":
+			rc := attr.Value.AsInt64()
+			r.FaultRc = &rc
 		}
 	}
 }
@@ -0,0 +1,99 @@
+package fusestream
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// maxProcessTreeDepth bounds the parent walk when matching descendants
+const maxProcessTreeDepth = 64
+
+// ProcessFilter restricts a fault to the calling processes, unset fields match any process
+type ProcessFilter struct {
+	Pid             *int32
+	IncludeChildren bool
+	Uid             *uint32
+	Gid             *uint32
+	CommRe          string
+}
+
+func (p *ProcessFilter) Clone() *ProcessFilter {
+	v := &ProcessFilter{
+		IncludeChildren: p.IncludeChildren,
+		CommRe:          p.CommRe,
+	}
+
+	if p.Pid != nil {
+		pid := *p.Pid
+		v.Pid = &pid
+	}
+
+	if p.Uid != nil {
+		uid := *p.Uid
+		v.Uid = &uid
+	}
+
+	if p.Gid != nil {
+		gid := *p.Gid
+		v.Gid = &gid
+	}
+
+	return v
+}
+
+func (p *ProcessFilter) Match(call *FuseCall, regexCache *RegexCache) bool {
+	if p.Uid != nil && *p.Uid != call.Uid {
+		return false
+	}
+
+	if p.Gid != nil && *p.Gid != call.Gid {
+		return false
+	}
+
+	if p.Pid != nil && !p.matchPid(call.Pid) {
+		return false
+	}
+
+	if p.CommRe != "" {
+		re, err := regexCache.Compile(p.CommRe)
+		if err != nil {
+			log.Warn().Err(err).Str("regex", p.CommRe).Msg("Invalid regex")
+			return false
+		}
+
+		comm, err := processComm(call.Pid)
+		if err != nil {
+			log.Trace().Err(err).Int("pid", call.Pid).Msg("Get process comm failed")
+			return false
+		}
+
+		if !re.MatchString(comm) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *ProcessFilter) matchPid(pid int) bool {
+	if pid == int(*p.Pid) {
+		return true
+	}
+
+	if !p.IncludeChildren {
+		return false
+	}
+
+	for i := 0; i < maxProcessTreeDepth && pid > 1; i++ {
+		ppid, err := parentPid(pid)
+		if err != nil {
+			log.Trace().Err(err).Int("pid", pid).Msg("Get parent pid failed")
+			return false
+		}
+		if ppid == int(*p.Pid) {
+			return true
+		}
+		pid = ppid
+	}
+
+	return false
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package fusestream
+
+import (
+	"errors"
+	"runtime"
+)
+
+var errProcessInfoNotSupported = errors.New("process info is not supported on " + runtime.GOOS)
+
+func processComm(pid int) (string, error) {
+	_ = pid
+	return "", errProcessInfoNotSupported
+}
+
+func parentPid(pid int) (int, error) {
+	_ = pid
+	return 0, errProcessInfoNotSupported
+}
@@ -0,0 +1,98 @@
+package fusestream
+
+import (
+	"os"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+func TestProcessFilter(t *testing.T) {
+	suite.Run(t, new(ProcessFilterTestSuite))
+}
+
+type ProcessFilterTestSuite struct {
+	suite.Suite
+}
+
+func (s *ProcessFilterTestSuite) TestUidAndGid() {
+	uid, gid := uint32(1000), uint32(100)
+	p := &ProcessFilter{Uid: &uid, Gid: &gid}
+	cache := NewRegexCache()
+
+	s.True(p.Match(&FuseCall{Uid: 1000, Gid: 100}, cache))
+	s.False(p.Match(&FuseCall{Uid: 1000, Gid: 0}, cache))
+	s.False(p.Match(&FuseCall{Uid: 0, Gid: 100}, cache))
+}
+
+func (s *ProcessFilterTestSuite) TestProcessTree() {
+	if runtime.GOOS != "linux" {
+		s.T().Skip("process info is only supported on linux")
+	}
+
+	ppid := int32(os.Getppid())
+	p := &ProcessFilter{Pid: &ppid}
+	cache := NewRegexCache()
+	call := &FuseCall{Pid: os.Getpid()}
+
+	s.True(p.Match(&FuseCall{Pid: int(ppid)}, cache))
+	s.False(p.Match(call, cache))
+
+	p.IncludeChildren = true
+	s.True(p.Match(call, cache))
+}
+
+func (s *ProcessFilterTestSuite) TestComm() {
+	if runtime.GOOS != "linux" {
+		s.T().Skip("process info is only supported on linux")
+	}
+
+	comm, err := processComm(os.Getpid())
+	s.Require().NoError(err)
+
+	cache := NewRegexCache()
+	call := &FuseCall{Pid: os.Getpid()}
+	s.True((&ProcessFilter{CommRe: "^" + regexp.QuoteMeta(comm) + "$"}).Match(call, cache))
+	s.False((&ProcessFilter{CommRe: "^postgres$"}).Match(call, cache))
+}
+
+func (s *ProcessFilterTestSuite) TestFuseFault() {
+	f := NewFaultManager()
+	rc := int32(-5)
+	uid := uint32(1000)
+	f.FuseInject(&FuseFault{
+		PathRe:                 "wal/.*",
+		Op:                     pb.FuseOp_FUSE_WRITE,
+		ReturnValue:            &rc,
+		ReturnValuePossibility: 1,
+		Process:                &ProcessFilter{Uid: &uid},
+	})
+
+	call := &FuseCall{Path: "wal/000001", Op: pb.FuseOp_FUSE_WRITE, Uid: 1000}
+	s.Equal(int64(-5), f.GetFuseFault(call).MayReplaceErrorCode(0))
+
+	call.Uid = 0
+	s.Equal(int64(0), f.GetFuseFault(call).MayReplaceErrorCode(0))
+
+	// a fault of the same path and op scoped to another process is kept side by side
+	rc2 := int32(-28)
+	uid2 := uint32(2000)
+	f.FuseInject(&FuseFault{
+		PathRe:                 "wal/.*",
+		Op:                     pb.FuseOp_FUSE_WRITE,
+		ReturnValue:            &rc2,
+		ReturnValuePossibility: 1,
+		Process:                &ProcessFilter{Uid: &uid2},
+	})
+	fuseFaults, _ := f.ListFaults()
+	s.Len(fuseFaults, 2)
+
+	call.Uid = 1000
+	s.Equal(int64(-5), f.GetFuseFault(call).MayReplaceErrorCode(0))
+	call.Uid = 2000
+	s.Equal(int64(-28), f.GetFuseFault(call).MayReplaceErrorCode(0))
+}
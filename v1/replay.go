@@ -0,0 +1,367 @@
+package fusestream
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayOptions configures Replay
+type ReplayOptions struct {
+	// Target is the directory or mountpoint the operations are issued against
+	Target string
+	// Speed scales the original timing, e.g. 2 replays twice as fast. 0 replays as fast as possible
+	Speed float64
+	// Concurrency is the number of workers, operations on the same path are issued by the same worker in order.
+	// Namespace operations wait for all issued operations and block the later ones, see replayBarrierOps
+	Concurrency int
+	// SkipFailed skips the operations failed in the trace
+	SkipFailed bool
+}
+
+// ReplayOpStat is the replay result of an operation type, Skipped records of it are not replayed
+type ReplayOpStat struct {
+	Op        string `json:"op"`
+	Count     int64  `json:"count"`
+	Errors    int64  `json:"errors"`
+	ElapsedNs int64  `json:"elapsed_ns"`
+	Skipped   int64  `json:"skipped"`
+}
+
+type ReplayReport struct {
+	ElapsedNs int64 `json:"elapsed_ns"`
+	// Skipped is the number of records not replayed, unsupported, failed in the trace or escaping the target
+	Skipped int64          `json:"skipped"`
+	Ops     []ReplayOpStat `json:"ops"`
+}
+
+// replayOps are the operations Replay supports
+var replayOps = map[string]bool{
+	"fuse.Open":     true,
+	"fuse.Create":   true,
+	"fuse.Mknod":    true,
+	"fuse.Read":     true,
+	"fuse.Write":    true,
+	"fuse.Fsync":    true,
+	"fuse.Mkdir":    true,
+	"fuse.Unlink":   true,
+	"fuse.Rmdir":    true,
+	"fuse.Rename":   true,
+	"fuse.Link":     true,
+	"fuse.Symlink":  true,
+	"fuse.Chmod":    true,
+	"fuse.Truncate": true,
+	"fuse.Getattr":  true,
+	"fuse.Readdir":  true,
+}
+
+// replayBarrierOps change the namespace, the operations on other paths may depend on them, e.g. a create in a new
+// directory or an access to the new path of a rename
+var replayBarrierOps = map[string]bool{
+	"fuse.Create":  true,
+	"fuse.Mknod":   true,
+	"fuse.Mkdir":   true,
+	"fuse.Unlink":  true,
+	"fuse.Rmdir":   true,
+	"fuse.Rename":  true,
+	"fuse.Link":    true,
+	"fuse.Symlink": true,
+}
+
+type replayer struct {
+	opts ReplayOptions
+	// pending counts the dispatched operations not applied yet
+	pending sync.WaitGroup
+
+	mutex sync.Mutex
+	files map[string]*os.File
+	// appending are the paths last opened with O_APPEND, their writes go to the end of the file
+	appending map[string]bool
+	stats     map[string]*ReplayOpStat
+}
+
+// Replay re-issues the recorded FUSE operations against opts.Target, records are in start time order
+func Replay(ctx context.Context, records []IORecord, opts ReplayOptions) (*ReplayReport, error) {
+	p := &replayer{
+		opts:      opts,
+		files:     make(map[string]*os.File),
+		appending: make(map[string]bool),
+		stats:     make(map[string]*ReplayOpStat),
+	}
+	defer p.closeAll()
+
+	concurrency := max(opts.Concurrency, 1)
+	queues := make([]chan *IORecord, concurrency)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan *IORecord, 1024)
+		wg.Add(1)
+		go func(queue chan *IORecord) {
+			defer wg.Done()
+			p.work(queue)
+		}(queues[i])
+	}
+
+	start := time.Now()
+	err := p.dispatch(ctx, records, queues, start)
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReplayReport{ElapsedNs: time.Since(start).Nanoseconds()}
+	for _, stat := range p.stats {
+		report.Skipped += stat.Skipped
+		report.Ops = append(report.Ops, *stat)
+	}
+	slices.SortFunc(report.Ops, func(a, b ReplayOpStat) int { return cmp.Compare(a.Op, b.Op) })
+	return report, nil
+}
+
+func (p *replayer) dispatch(ctx context.Context, records []IORecord, queues []chan *IORecord, start time.Time) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	first := records[0].StartTimeNs
+	for i := range records {
+		r := &records[i]
+		if !replayOps[r.Name] || (p.opts.SkipFailed && r.Errc < 0) || !replayInTarget(r) {
+			p.mutex.Lock()
+			p.stat(r.Name).Skipped++
+			p.mutex.Unlock()
+			continue
+		}
+
+		if p.opts.Speed > 0 {
+			due := start.Add(time.Duration(float64(r.StartTimeNs-first) / p.opts.Speed))
+			if d := time.Until(due); d > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(d):
+				}
+			}
+		}
+
+		barrier := replayBarrierOps[r.Name]
+		if barrier {
+			p.pending.Wait()
+		}
+
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(r.Path))
+		p.pending.Add(1)
+		select {
+		case <-ctx.Done():
+			p.pending.Done()
+			return ctx.Err()
+		case queues[h.Sum32()%uint32(len(queues))] <- r:
+		}
+
+		if barrier {
+			p.pending.Wait()
+		}
+	}
+	return nil
+}
+
+// replayInTarget returns false if the paths of the record escape the target, e.g. /../etc/passwd
+func replayInTarget(r *IORecord) bool {
+	inTarget := func(path string) bool {
+		path = strings.TrimLeft(filepath.ToSlash(path), "/")
+		return path == "" || filepath.IsLocal(path)
+	}
+	// the path of symlink is the target of the link, it's not accessed
+	return (r.Name == "fuse.Symlink" || inTarget(r.Path)) && inTarget(r.NewPath)
+}
+
+func (p *replayer) work(queue chan *IORecord) {
+	buf := make([]byte, 0)
+	for r := range queue {
+		if cap(buf) < int(r.Length) {
+			buf = make([]byte, r.Length)
+		}
+
+		start := time.Now()
+		err := p.apply(r, buf[:max(r.Length, 0)])
+		elapsed := time.Since(start)
+
+		p.mutex.Lock()
+		stat := p.stat(r.Name)
+		stat.Count++
+		stat.ElapsedNs += elapsed.Nanoseconds()
+		if err != nil {
+			stat.Errors++
+		}
+		p.mutex.Unlock()
+		p.pending.Done()
+	}
+}
+
+// stat returns the stat of the op, it must be called with mutex held
+func (p *replayer) stat(op string) *ReplayOpStat {
+	stat, ok := p.stats[op]
+	if !ok {
+		stat = &ReplayOpStat{Op: op}
+		p.stats[op] = stat
+	}
+	return stat
+}
+
+func (p *replayer) apply(r *IORecord, buf []byte) error {
+	path := filepath.Join(p.opts.Target, r.Path)
+	newPath := filepath.Join(p.opts.Target, r.NewPath)
+
+	switch r.Name {
+	case "fuse.Open":
+		return p.open(path, int(r.Flags), 0, 0)
+	case "fuse.Create":
+		return p.open(path, int(r.Flags), os.O_CREATE, replayPerm(r.Mode, 0644))
+	case "fuse.Mknod":
+		_, err := p.file(path, os.O_CREATE, replayPerm(r.Mode, 0644))
+		return err
+	case "fuse.Read":
+		f, err := p.file(path, 0, 0)
+		if err != nil {
+			return err
+		}
+		_, err = f.ReadAt(buf, r.Offset)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	case "fuse.Write":
+		f, err := p.file(path, 0, 0)
+		if err != nil {
+			return err
+		}
+		offset := r.Offset
+		if p.isAppending(path) {
+			st, err := f.Stat()
+			if err != nil {
+				return err
+			}
+			offset = st.Size()
+		}
+		_, err = f.WriteAt(buf, offset)
+		return err
+	case "fuse.Fsync":
+		f, err := p.file(path, 0, 0)
+		if err != nil {
+			return err
+		}
+		return f.Sync()
+	case "fuse.Mkdir":
+		return os.Mkdir(path, replayPerm(r.Mode, 0755))
+	case "fuse.Unlink", "fuse.Rmdir":
+		p.close(path)
+		return os.Remove(path)
+	case "fuse.Rename":
+		p.close(path)
+		return os.Rename(path, newPath)
+	case "fuse.Link":
+		return os.Link(path, newPath)
+	case "fuse.Symlink":
+		// the path of symlink is the target, kept as recorded
+		return os.Symlink(r.Path, newPath)
+	case "fuse.Chmod":
+		return os.Chmod(path, replayPerm(r.Mode, 0644))
+	case "fuse.Truncate":
+		return os.Truncate(path, r.Offset)
+	case "fuse.Getattr":
+		_, err := os.Lstat(path)
+		return err
+	case "fuse.Readdir":
+		_, err := os.ReadDir(path)
+		return err
+	}
+	return nil
+}
+
+// open replays an open with the recorded flags, O_TRUNC truncates the file and O_APPEND makes the later writes to path
+// append. The file is cached, so a single file is kept per path whatever the flags
+func (p *replayer) open(path string, recorded int, flag int, perm fs.FileMode) error {
+	f, err := p.file(path, flag, perm)
+	if err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	p.appending[path] = recorded&os.O_APPEND != 0
+	p.mutex.Unlock()
+
+	if recorded&os.O_TRUNC != 0 {
+		return f.Truncate(0)
+	}
+	return nil
+}
+
+func (p *replayer) isAppending(path string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.appending[path]
+}
+
+// file returns the cached file of path, it's opened read-write or read-only if it's not writable
+func (p *replayer) file(path string, flag int, perm fs.FileMode) (*os.File, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	f, ok := p.files[path]
+	if ok {
+		return f, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|flag, perm)
+	if errors.Is(err, fs.ErrPermission) {
+		f, err = os.OpenFile(path, os.O_RDONLY|flag, perm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.files[path] = f
+	return f, nil
+}
+
+func (p *replayer) close(path string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	f, ok := p.files[path]
+	if ok {
+		_ = f.Close()
+		delete(p.files, path)
+	}
+	delete(p.appending, path)
+}
+
+func (p *replayer) closeAll() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for path, f := range p.files {
+		_ = f.Close()
+		delete(p.files, path)
+	}
+}
+
+func replayPerm(mode int32, defaultPerm fs.FileMode) fs.FileMode {
+	perm := fs.FileMode(mode) & fs.ModePerm
+	if perm == 0 {
+		return defaultPerm
+	}
+	return perm
+}
@@ -0,0 +1,131 @@
+package fusestream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplay(t *testing.T) {
+	target := t.TempDir()
+	ms := time.Millisecond.Nanoseconds()
+	records := []IORecord{
+		{Name: "fuse.Mkdir", StartTimeNs: 0, Path: "/dir", Mode: 0755},
+		{Name: "fuse.Create", StartTimeNs: 1 * ms, Path: "/dir/a", Mode: 0644},
+		{Name: "fuse.Write", StartTimeNs: 2 * ms, Path: "/dir/a", Offset: 4096, Length: 4096},
+		{Name: "fuse.Read", StartTimeNs: 3 * ms, Path: "/dir/a", Offset: 0, Length: 4096},
+		{Name: "fuse.Fsync", StartTimeNs: 4 * ms, Path: "/dir/a"},
+		{Name: "fuse.Create", StartTimeNs: 5 * ms, Path: "/b"},
+		{Name: "fuse.Rename", StartTimeNs: 6 * ms, Path: "/b", NewPath: "/c"},
+		{Name: "fuse.Unlink", StartTimeNs: 7 * ms, Path: "/missing", Errc: -2},
+		{Name: "fuse.Statfs", StartTimeNs: 8 * ms, Path: "/"},
+		{Name: "fuse.Getattr", StartTimeNs: 50 * ms, Path: "/missing"},
+	}
+
+	report, err := Replay(context.Background(), records, ReplayOptions{
+		Target:      target,
+		Speed:       1,
+		Concurrency: 1,
+		SkipFailed:  true,
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, report.ElapsedNs, 50*ms)
+	require.Equal(t, int64(2), report.Skipped)
+
+	st, err := os.Stat(filepath.Join(target, "dir/a"))
+	require.NoError(t, err)
+	require.Equal(t, int64(8192), st.Size())
+	require.FileExists(t, filepath.Join(target, "c"))
+	require.NoFileExists(t, filepath.Join(target, "b"))
+
+	ops := make(map[string]ReplayOpStat)
+	for _, op := range report.Ops {
+		ops[op.Op] = op
+	}
+	require.Len(t, ops, 9)
+	require.Equal(t, int64(2), ops["fuse.Create"].Count)
+	require.Zero(t, ops["fuse.Write"].Errors)
+	require.Equal(t, int64(1), ops["fuse.Getattr"].Errors)
+	// the skipped records are counted by op
+	require.Equal(t, ReplayOpStat{Op: "fuse.Statfs", Skipped: 1}, ops["fuse.Statfs"])
+	require.Equal(t, ReplayOpStat{Op: "fuse.Unlink", Skipped: 1}, ops["fuse.Unlink"])
+}
+
+func TestReplayFlags(t *testing.T) {
+	target := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(target, "log"), make([]byte, 100), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(target, "data"), make([]byte, 100), 0644))
+	records := []IORecord{
+		// the writes of the appending file go to its end whatever the recorded offset
+		{Name: "fuse.Open", Path: "/log", Flags: int32(os.O_WRONLY | os.O_APPEND)},
+		{Name: "fuse.Write", Path: "/log", Offset: 0, Length: 10},
+		{Name: "fuse.Write", Path: "/log", Offset: 0, Length: 10},
+		// the file is truncated on open, then to the recorded size
+		{Name: "fuse.Open", Path: "/data", Flags: int32(os.O_RDWR | os.O_TRUNC)},
+		{Name: "fuse.Write", Path: "/data", Offset: 0, Length: 10},
+		{Name: "fuse.Truncate", Path: "/data", Offset: 4096},
+		{Name: "fuse.Chown", Path: "/data"},
+	}
+
+	report, err := Replay(context.Background(), records, ReplayOptions{Target: target})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, report.Skipped)
+
+	st, err := os.Stat(filepath.Join(target, "log"))
+	require.NoError(t, err)
+	require.Equal(t, int64(120), st.Size())
+	st, err = os.Stat(filepath.Join(target, "data"))
+	require.NoError(t, err)
+	require.Equal(t, int64(4096), st.Size())
+}
+
+func TestReplayConcurrent(t *testing.T) {
+	target := t.TempDir()
+	records := make([]IORecord, 0)
+	for i := range 20 {
+		dir := fmt.Sprintf("/d%d", i)
+		records = append(records,
+			IORecord{Name: "fuse.Mkdir", Path: dir},
+			IORecord{Name: "fuse.Mkdir", Path: dir + "/sub"},
+			IORecord{Name: "fuse.Create", Path: dir + "/sub/f"},
+			IORecord{Name: "fuse.Write", Path: dir + "/sub/f", Length: 512},
+			IORecord{Name: "fuse.Rename", Path: dir + "/sub", NewPath: dir + "/moved"},
+			IORecord{Name: "fuse.Write", Path: dir + "/moved/f", Offset: 512, Length: 512},
+			IORecord{Name: "fuse.Unlink", Path: dir + "/moved/f"},
+			IORecord{Name: "fuse.Rmdir", Path: dir + "/moved"},
+		)
+	}
+	records = append(records,
+		IORecord{Name: "fuse.Create", Path: "/../escaped"},
+		IORecord{Name: "fuse.Rename", Path: "/d0", NewPath: "/d0/../../escaped"},
+	)
+
+	report, err := Replay(context.Background(), records, ReplayOptions{Target: target, Concurrency: 8})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, report.Skipped)
+	for _, op := range report.Ops {
+		require.Zero(t, op.Errors, op.Op)
+	}
+	require.NoFileExists(t, filepath.Join(filepath.Dir(target), "escaped"))
+
+	entries, err := os.ReadDir(target)
+	require.NoError(t, err)
+	require.Len(t, entries, 20)
+}
+
+func TestReplayCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	records := []IORecord{
+		{Name: "fuse.Getattr", StartTimeNs: 0, Path: "/"},
+		{Name: "fuse.Getattr", StartTimeNs: time.Hour.Nanoseconds(), Path: "/"},
+	}
+	_, err := Replay(ctx, records, ReplayOptions{Target: t.TempDir(), Speed: 1})
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -3,6 +3,8 @@ package fusestream
 import (
 	"context"
 	"errors"
+	"os"
+	"regexp"
 	"time"
 
 	"github.com/d5/tengo/v2"
@@ -15,11 +17,55 @@ import (
 type Rpc struct {
 	pb.UnimplementedFuseStreamServer
 	Faults *FaultManager
+	// Stats of the served mount or export, GetStats fails if nil
+	Stats *Stats
+	// Inflight operations of the served mount or export, ListInflight fails if nil
+	Inflight *InflightTable
+	// Unmounter unmounts the mount or stops serving the export, Unmount fails if nil
+	Unmounter func() error
+	// Info is returned by GetServerInfo, only the version is returned if nil
+	Info *ServerInfo
+	// Targets resolves the target named by requests if set, the fields above are ignored then. Otherwise the
+	// server serves a single target and requests must not name one
+	Targets TargetResolver
+}
+
+// TargetResolver finds a target by name, the empty name may resolve to a default target
+type TargetResolver interface {
+	Target(name string) (*Target, error)
+	Unmount(ctx context.Context, req *pb.UnmountRequest) (*pb.Void, error)
+}
+
+// target resolves the target of a request
+func (r *Rpc) target(name string) (*Target, error) {
+	if r.Targets != nil {
+		return r.Targets.Target(name)
+	}
+	if name != "" {
+		return nil, status.Errorf(codes.NotFound, "target %q not found, the server serves a single target", name)
+	}
+	return &Target{Faults: r.Faults, Stats: r.Stats, Inflight: r.Inflight}, nil
 }
 
 func (r *Rpc) InjectNbdFault(ctx context.Context, req *pb.InjectNbdFaultRequest) (*pb.InjectNbdFaultResponse, error) {
+	t, err := r.target(req.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Fault.OffsetStart < 0 || req.Fault.OffsetEnd < 0 ||
+		(req.Fault.OffsetEnd != 0 && req.Fault.OffsetEnd <= req.Fault.OffsetStart) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid offset range [%d, %d)",
+			req.Fault.OffsetStart, req.Fault.OffsetEnd)
+	}
+
 	fault := &NbdFault{
-		Op: req.Fault.Op,
+		Op:          req.Fault.Op,
+		Disabled:    req.Fault.Enabled != nil && !*req.Fault.Enabled,
+		Priority:    req.Fault.Priority,
+		OffsetStart: req.Fault.OffsetStart,
+		OffsetEnd:   req.Fault.OffsetEnd,
+		MatchPolicy: req.Fault.MatchPolicy,
 	}
 
 	switch m := req.Fault.PreCond.(type) {
@@ -54,14 +100,43 @@ func (r *Rpc) InjectNbdFault(ctx context.Context, req *pb.InjectNbdFaultRequest)
 		fault.ReturnValue = &rc
 	}
 
-	id := r.Faults.NbdInject(fault)
-	return &pb.InjectNbdFaultResponse{Id: id}, nil
+	// converted before injecting, the fault may be changed by the other RPCs once it's injected
+	def := nbdFaultToProto(fault)
+	def.Id = t.Faults.NbdInject(fault)
+	return &pb.InjectNbdFaultResponse{Id: def.Id, Fault: def}, nil
 }
 
 func (r *Rpc) InjectFuseFault(_ context.Context, req *pb.InjectFuseFaultRequest) (*pb.InjectFuseFaultResponse, error) {
+	t, err := r.target(req.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := openFlagMask(req.Fault.OpenFlags); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	fault := &FuseFault{
-		PathRe: req.Fault.PathRe,
-		Op:     req.Fault.Op,
+		PathRe:    req.Fault.PathRe,
+		Op:        req.Fault.Op,
+		Disabled:  req.Fault.Enabled != nil && !*req.Fault.Enabled,
+		OpenFlags: req.Fault.OpenFlags,
+		Fhs:       req.Fault.Fhs,
+	}
+
+	if p := req.Fault.Process; p != nil {
+		if p.CommRe != "" {
+			if _, err := regexp.Compile(p.CommRe); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid comm regex, err: %v", err)
+			}
+		}
+		fault.Process = &ProcessFilter{
+			Pid:             p.Pid,
+			IncludeChildren: p.IncludeChildren,
+			Uid:             p.Uid,
+			Gid:             p.Gid,
+			CommRe:          p.CommRe,
+		}
 	}
 
 	switch m := req.Fault.ReturnValue.(type) {
@@ -78,91 +153,249 @@ func (r *Rpc) InjectFuseFault(_ context.Context, req *pb.InjectFuseFaultRequest)
 		fault.Delay = &d
 	}
 
-	id := r.Faults.FuseInject(fault)
-	return &pb.InjectFuseFaultResponse{Id: id}, nil
+	// converted before injecting, the fault may be changed by the other RPCs once it's injected
+	def := fuseFaultToProto(fault)
+	def.Id = t.Faults.FuseInject(fault)
+	return &pb.InjectFuseFaultResponse{Id: def.Id, Fault: def}, nil
 }
 
 func (r *Rpc) DeleteFault(_ context.Context, req *pb.DeleteFaultRequest) (*pb.DeleteFaultResponse, error) {
+	t, err := r.target(req.Target)
+	if err != nil {
+		return nil, err
+	}
+
 	rsp := &pb.DeleteFaultResponse{}
 	if req.All {
-		rsp.DeletedIds = r.Faults.DeleteAll()
+		rsp.DeletedIds = t.Faults.DeleteAll()
 	} else if ids := req.GetId(); ids != nil {
-		rsp.DeletedIds = r.Faults.DeleteByID(ids)
+		deletedIDs, err := t.Faults.DeleteByID(ids)
+		if err != nil {
+			if errors.Is(err, ErrFaultNotFound) {
+				return nil, status.Error(codes.NotFound, err.Error())
+			}
+			return nil, err
+		}
+		rsp.DeletedIds = deletedIDs
 	} else if pathRe := req.GetPathRe(); pathRe != "" {
-		rsp.DeletedIds = r.Faults.DeleteByPathRegex(pathRe)
+		rsp.DeletedIds = t.Faults.DeleteByPathRegex(pathRe)
+	} else if op := req.GetFuseOp(); op != pb.FuseOp_FUSE_UNKNOWN {
+		rsp.DeletedIds = t.Faults.DeleteByFuseOp(op)
+	} else if op := req.GetNbdOp(); op != pb.NbdOp_NBD_UNKNOWN {
+		rsp.DeletedIds = t.Faults.DeleteByNbdOp(op)
+	} else {
+		return nil, status.Error(codes.InvalidArgument, "no fault to delete specified")
 	}
 	return rsp, nil
 }
 
-func (r *Rpc) ListFaults(_ context.Context, _ *pb.Void) (*pb.ListFaultsResponse, error) {
-	f, b := r.Faults.ListFaults()
+func (r *Rpc) SetFaultEnabled(_ context.Context, req *pb.SetFaultEnabledRequest) (*pb.SetFaultEnabledResponse, error) {
+	if len(req.GetId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no fault id specified")
+	}
+	t, err := r.target(req.Target)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SetFaultEnabledResponse{UpdatedIds: t.Faults.SetEnabled(req.GetId(), req.GetEnabled())}, nil
+}
 
-	FuseFaults := make([]*pb.FuseFault, 0)
-	NbdFaults := make([]*pb.NbdFault, 0)
+func (r *Rpc) PauseAll(_ context.Context, req *pb.TargetRequest) (*pb.Void, error) {
+	t, err := r.target(req.Target)
+	if err != nil {
+		return nil, err
+	}
+	t.Faults.PauseAll()
+	return &pb.Void{}, nil
+}
 
+func (r *Rpc) ResumeAll(_ context.Context, req *pb.TargetRequest) (*pb.Void, error) {
+	t, err := r.target(req.Target)
+	if err != nil {
+		return nil, err
+	}
+	t.Faults.ResumeAll()
+	return &pb.Void{}, nil
+}
+
+func (r *Rpc) ListFaults(_ context.Context, req *pb.TargetRequest) (*pb.ListFaultsResponse, error) {
+	t, err := r.target(req.Target)
+	if err != nil {
+		return nil, err
+	}
+	f, b := t.Faults.ListFaults()
+
+	FuseFaults := make([]*pb.FuseFault, 0, len(f))
 	for _, fault := range f {
-		fuseFault := &pb.FuseFault{
-			Id:     fault.ID,
-			PathRe: fault.PathRe,
-			Op:     fault.Op,
+		FuseFaults = append(FuseFaults, fuseFaultToProto(fault))
+	}
+
+	NbdFaults := make([]*pb.NbdFault, 0, len(b))
+	for _, fault := range b {
+		NbdFaults = append(NbdFaults, nbdFaultToProto(fault))
+	}
+
+	return &pb.ListFaultsResponse{
+		FuseFaults: FuseFaults,
+		NbdFaults:  NbdFaults,
+		Paused:     t.Faults.Paused(),
+	}, nil
+}
+
+// fuseFaultToProto converts the fault to its proto definition, the hits are read at the time of the call
+func fuseFaultToProto(fault *FuseFault) *pb.FuseFault {
+	enabled := !fault.Disabled
+	fuseFault := &pb.FuseFault{
+		Id:        fault.ID,
+		PathRe:    fault.PathRe,
+		Op:        fault.Op,
+		Enabled:   &enabled,
+		OpenFlags: fault.OpenFlags,
+		Fhs:       fault.Fhs,
+		Hits:      fault.Hits(),
+	}
+
+	if fault.Delay != nil {
+		fuseFault.Delay = &pb.FuseFault_DelayFault{
+			DelayFault: &pb.DelayFault{
+				Possibility: fault.DelayPossibility,
+				DelayMs:     fault.Delay.Milliseconds(),
+			},
 		}
+	}
 
-		if fault.Delay != nil {
-			fuseFault.Delay = &pb.FuseFault_DelayFault{
-				DelayFault: &pb.DelayFault{
-					Possibility: fault.DelayPossibility,
-					DelayMs:     fault.Delay.Milliseconds(),
-				},
-			}
+	if p := fault.Process; p != nil {
+		fuseFault.Process = &pb.ProcessFilter{
+			Pid:             p.Pid,
+			IncludeChildren: p.IncludeChildren,
+			Uid:             p.Uid,
+			Gid:             p.Gid,
+			CommRe:          p.CommRe,
 		}
+	}
 
-		if fault.ReturnValue != nil {
-			fuseFault.ReturnValue = &pb.FuseFault_ReturnValueFault{
-				ReturnValueFault: &pb.ReturnValueFault{
-					Possibility: fault.ReturnValuePossibility,
-					ReturnValue: int64(*fault.ReturnValue),
-				},
-			}
+	if fault.ReturnValue != nil {
+		fuseFault.ReturnValue = &pb.FuseFault_ReturnValueFault{
+			ReturnValueFault: &pb.ReturnValueFault{
+				Possibility: fault.ReturnValuePossibility,
+				ReturnValue: int64(*fault.ReturnValue),
+			},
 		}
+	}
+	return fuseFault
+}
 
-		FuseFaults = append(FuseFaults, fuseFault)
+// nbdFaultToProto is fuseFaultToProto of the NBD faults
+func nbdFaultToProto(fault *NbdFault) *pb.NbdFault {
+	enabled := !fault.Disabled
+	nbdFault := &pb.NbdFault{
+		Id:          fault.ID,
+		Op:          fault.Op,
+		Enabled:     &enabled,
+		Priority:    fault.Priority,
+		OffsetStart: fault.OffsetStart,
+		OffsetEnd:   fault.OffsetEnd,
+		MatchPolicy: fault.MatchPolicy,
+		Hits:        fault.Hits(),
 	}
 
-	for _, fault := range b {
-		nbdFault := &pb.NbdFault{
-			Id: fault.ID,
-			Op: fault.Op,
+	if fault.preCond != nil {
+		nbdFault.PreCond = &pb.NbdFault_Expression{Expression: *fault.preCond}
+	}
+
+	if fault.Delay != nil {
+		nbdFault.Delay = &pb.NbdFault_DelayFault{
+			DelayFault: &pb.DelayFault{
+				Possibility: fault.DelayPossibility,
+				DelayMs:     fault.Delay.Milliseconds(),
+			},
 		}
+	}
 
-		if fault.Delay != nil {
-			nbdFault.Delay = &pb.NbdFault_DelayFault{
-				DelayFault: &pb.DelayFault{
-					Possibility: fault.DelayPossibility,
-					DelayMs:     fault.Delay.Milliseconds(),
-				},
-			}
+	if fault.ReturnValue != nil {
+		nbdFault.ReturnValue = &pb.NbdFault_ReturnValueFault{
+			ReturnValueFault: &pb.ReturnValueFault{
+				Possibility: fault.ReturnValuePossibility,
+				ReturnValue: *fault.ReturnValue,
+			},
 		}
+	}
 
-		if fault.ReturnValue != nil {
-			nbdFault.ReturnValue = &pb.NbdFault_ReturnValueFault{
-				ReturnValueFault: &pb.ReturnValueFault{
-					Possibility: fault.ReturnValuePossibility,
-					ReturnValue: *fault.ReturnValue,
-				},
-			}
+	if fault.Err != nil {
+		nbdFault.Err = &pb.NbdFault_ErrorFault{
+			ErrorFault: &pb.ErrorFault{
+				Possibility: fault.ErrPossibility,
+				Err:         (*fault.Err).Error(),
+			},
 		}
+	}
+	return nbdFault
+}
 
-		if fault.Err != nil {
-			nbdFault.Err = &pb.NbdFault_ErrorFault{
-				ErrorFault: &pb.ErrorFault{
-					Possibility: fault.ErrPossibility,
-					Err:         (*fault.Err).Error(),
-				},
-			}
+func (r *Rpc) GetStats(_ context.Context, req *pb.TargetRequest) (*pb.GetStatsResponse, error) {
+	t, err := r.target(req.Target)
+	if err != nil {
+		return nil, err
+	}
+	if t.Stats == nil {
+		return nil, status.Error(codes.FailedPrecondition, "stats are not collected")
+	}
+	rsp := t.Stats.Snapshot()
+	rsp.Delaying = t.Faults.Delaying()
+	return rsp, nil
+}
+
+func (r *Rpc) ListInflight(_ context.Context, req *pb.TargetRequest) (*pb.ListInflightResponse, error) {
+	t, err := r.target(req.Target)
+	if err != nil {
+		return nil, err
+	}
+	if t.Inflight == nil {
+		return nil, status.Error(codes.FailedPrecondition, "in-flight operations are not tracked")
+	}
+
+	rsp := &pb.ListInflightResponse{TimeNs: time.Now().UnixNano()}
+	for _, op := range t.Inflight.List() {
+		rsp.Ops = append(rsp.Ops, &pb.InflightOp{
+			Id:          op.ID,
+			Op:          op.Op,
+			Path:        op.Path,
+			Offset:      op.Offset,
+			Length:      op.Length,
+			Pid:         int32(op.Pid),
+			StartTimeNs: op.Start.UnixNano(),
+			FaultIds:    op.FaultIDs,
+			DelayNs:     op.Delay.Nanoseconds(),
+			Delaying:    op.Delaying,
+		})
+	}
+	return rsp, nil
+}
+
+func (r *Rpc) Unmount(ctx context.Context, req *pb.TargetRequest) (*pb.Void, error) {
+	if r.Targets != nil {
+		t, err := r.Targets.Target(req.Target)
+		if err != nil {
+			return nil, err
 		}
+		return r.Targets.Unmount(ctx, &pb.UnmountRequest{Name: t.Name})
+	}
 
-		NbdFaults = append(NbdFaults, nbdFault)
+	if _, err := r.target(req.Target); err != nil {
+		return nil, err
 	}
+	if r.Unmounter == nil {
+		return nil, status.Error(codes.Unimplemented, "unmount is not supported")
+	}
+	if err := r.Unmounter(); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.Void{}, nil
+}
 
-	return &pb.ListFaultsResponse{FuseFaults: FuseFaults, NbdFaults: NbdFaults}, nil
+func (r *Rpc) GetServerInfo(_ context.Context, _ *pb.Void) (*pb.ServerInfo, error) {
+	if r.Info == nil {
+		return &pb.ServerInfo{Version: Version(), Pid: int32(os.Getpid())}, nil
+	}
+	return r.Info.proto(), nil
 }
@@ -0,0 +1,59 @@
+package fusestream
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const unixScheme = "unix://"
+
+// UnixSocketPath returns the socket path of a unix:///path/to.sock address
+func UnixSocketPath(address string) (string, bool) {
+	return strings.CutPrefix(address, unixScheme)
+}
+
+// ListenRPC listens on a TCP host:port or a unix:///path/to.sock address. The socket file is created with mode,
+// a stale socket left by a crashed server is replaced, and the file is removed when the listener is closed
+func ListenRPC(address string, mode os.FileMode) (net.Listener, error) {
+	path, ok := UnixSocketPath(address)
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("invalid unix socket address: %s", address)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	return listenUnix(path, mode)
+}
+
+// removeStaleSocket removes the socket file at path if no server is accepting on it
+func removeStaleSocket(path string) error {
+	stat, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stat.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s is in use by another server", path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package fusestream
+
+import (
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// umaskMutex serializes the umask changes of listenUnix, the umask is process-wide
+var umaskMutex sync.Mutex
+
+// listenUnix creates the socket file with mode right away, it's never accessible by others before a chmod. fuse mount
+// and daemon run clear the umask, the socket would be 0777 otherwise
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	umaskMutex.Lock()
+	defer umaskMutex.Unlock()
+
+	old := syscall.Umask(int(^mode & os.ModePerm))
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+
+package fusestream
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenUnixMode(t *testing.T) {
+	// fuse mount and daemon run clear the umask
+	old := syscall.Umask(0)
+	defer syscall.Umask(old)
+
+	path := filepath.Join(t.TempDir(), "test.sock")
+	listener, err := listenUnix(path, 0600)
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+	// the umask is restored
+	require.Equal(t, 0, syscall.Umask(0))
+}
@@ -0,0 +1,19 @@
+package fusestream
+
+import (
+	"net"
+	"os"
+)
+
+// listenUnix sets the mode after listening, Windows has no umask and ignores most of the mode bits
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
@@ -3,13 +3,22 @@ package fusestream
 import (
 	"context"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 
 	"github.com/zperf/fusestream/pb"
+	"github.com/zperf/fusestream/rpcauth"
 )
 
 func TestRpc(t *testing.T) {
@@ -23,7 +32,7 @@ type RpcTestSuite struct {
 func (s *RpcTestSuite) TestItWorks() {
 	faults := NewFaultManager()
 	server := grpc.NewServer(grpc.Creds(insecure.NewCredentials()))
-	pb.RegisterSlowIOServer(server, &Rpc{Faults: faults})
+	pb.RegisterFuseStreamServer(server, &Rpc{Faults: faults})
 
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	s.Require().NoError(err)
@@ -35,9 +44,184 @@ func (s *RpcTestSuite) TestItWorks() {
 
 	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	s.Require().NoError(err)
-	client := pb.NewSlowIOClient(conn)
+	client := pb.NewFuseStreamClient(conn)
 
-	_, err = client.ListFaults(context.TODO(), &pb.Void{})
+	_, err = client.ListFaults(context.TODO(), &pb.TargetRequest{})
+	s.NoError(err)
+
+	_, err = client.DeleteFault(context.TODO(), &pb.DeleteFaultRequest{Id: []int32{100}})
+	s.Equal(codes.NotFound, status.Code(err))
+
+	s.NoError(conn.Close())
+}
+
+func (s *RpcTestSuite) TestInjectReturnsFault() {
+	ctx := context.Background()
+	rpc := &Rpc{Faults: NewFaultManager()}
+
+	enabled := false
+	fuse, err := rpc.InjectFuseFault(ctx, &pb.InjectFuseFaultRequest{Fault: &pb.FuseFault{
+		PathRe:    "wal/.*",
+		Op:        pb.FuseOp_FUSE_FSYNC,
+		Enabled:   &enabled,
+		OpenFlags: []pb.OpenFlag{pb.OpenFlag_OPEN_FLAG_SYNC},
+		Delay:     &pb.FuseFault_DelayFault{DelayFault: &pb.DelayFault{Possibility: 1, DelayMs: 200}},
+	}})
+	s.Require().NoError(err)
+	s.Equal(fuse.Id, fuse.Fault.Id)
+	s.Equal("wal/.*", fuse.Fault.PathRe)
+	s.False(fuse.Fault.GetEnabled())
+	s.Equal([]pb.OpenFlag{pb.OpenFlag_OPEN_FLAG_SYNC}, fuse.Fault.OpenFlags)
+	s.EqualValues(200, fuse.Fault.GetDelayFault().DelayMs)
+
+	nbd, err := rpc.InjectNbdFault(ctx, &pb.InjectNbdFaultRequest{Fault: &pb.NbdFault{
+		Op:       pb.NbdOp_NBD_WRITEAT,
+		Priority: 2,
+		Err:      &pb.NbdFault_ErrorFault{ErrorFault: &pb.ErrorFault{Possibility: 0.5, Err: "EIO"}},
+	}})
+	s.Require().NoError(err)
+	s.Equal(nbd.Id, nbd.Fault.Id)
+	s.NotEqual(fuse.Id, nbd.Id)
+	s.True(nbd.Fault.GetEnabled())
+	s.EqualValues(2, nbd.Fault.Priority)
+	s.Equal("EIO", nbd.Fault.GetErrorFault().Err)
+}
+
+func (s *RpcTestSuite) TestInjectTruncFlag() {
+	// the kernel never passes O_TRUNC to Open, such a fault could never match
+	_, err := (&Rpc{Faults: NewFaultManager()}).InjectFuseFault(context.Background(), &pb.InjectFuseFaultRequest{Fault: &pb.FuseFault{
+		Op:        pb.FuseOp_FUSE_WRITE,
+		OpenFlags: []pb.OpenFlag{pb.OpenFlag_OPEN_FLAG_TRUNC},
+		Delay:     &pb.FuseFault_DelayFault{DelayFault: &pb.DelayFault{Possibility: 1, DelayMs: 200}},
+	}})
+	s.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *RpcTestSuite) TestTLSAndToken() {
+	dir := s.T().TempDir()
+	s.Require().NoError(GenerateCerts(dir, []string{"127.0.0.1"}, time.Hour))
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	serverConfig, err := rpcauth.ServerTLSConfig(path(ServerCertFile), path(ServerKeyFile), path(CACertFile))
+	s.Require().NoError(err)
+	opts := append([]grpc.ServerOption{grpc.Creds(credentials.NewTLS(serverConfig))}, rpcauth.TokenServerOptions("secret")...)
+	server := grpc.NewServer(opts...)
+	pb.RegisterFuseStreamServer(server, &Rpc{Faults: NewFaultManager()})
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	listFaults := func(certFile, keyFile, token string) error {
+		config, err := rpcauth.ClientTLSConfig(path(CACertFile), certFile, keyFile, "")
+		s.Require().NoError(err)
+		opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(config))}
+		if token != "" {
+			opts = append(opts, grpc.WithPerRPCCredentials(rpcauth.TokenCredentials{Token: token, Secure: true}))
+		}
+		conn, err := grpc.NewClient(listener.Addr().String(), opts...)
+		s.Require().NoError(err)
+		defer func() { _ = conn.Close() }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = pb.NewFuseStreamClient(conn).ListFaults(ctx, &pb.TargetRequest{})
+		return err
+	}
+
+	s.NoError(listFaults(path(ClientCertFile), path(ClientKeyFile), "secret"))
+	s.Equal(codes.Unauthenticated, status.Code(listFaults(path(ClientCertFile), path(ClientKeyFile), "wrong")))
+	s.Equal(codes.Unauthenticated, status.Code(listFaults(path(ClientCertFile), path(ClientKeyFile), "")))
+	// mTLS rejects clients without a certificate
+	s.Error(listFaults("", "", "secret"))
+
+	// the health checks are open to probes without the token
+	config, err := rpcauth.ClientTLSConfig(path(CACertFile), path(ClientCertFile), path(ClientKeyFile), "")
+	s.Require().NoError(err)
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	s.Require().NoError(err)
+	defer func() { _ = conn.Close() }()
+	rsp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	s.Require().NoError(err)
+	s.Equal(healthpb.HealthCheckResponse_SERVING, rsp.Status)
+}
+
+func (s *RpcTestSuite) TestUnixSocket() {
+	path := filepath.Join(s.T().TempDir(), "run", "test.sock")
+	address := "unix://" + path
+
+	listener, err := ListenRPC(address, 0600)
+	s.Require().NoError(err)
+	stat, err := os.Stat(path)
+	s.Require().NoError(err)
+	s.Equal(os.FileMode(0600), stat.Mode().Perm())
+
+	_, err = ListenRPC(address, 0600)
+	s.ErrorContains(err, "in use")
+
+	server := grpc.NewServer(grpc.Creds(insecure.NewCredentials()))
+	pb.RegisterFuseStreamServer(server, &Rpc{Faults: NewFaultManager()})
+	go func() { _ = server.Serve(listener) }()
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	s.Require().NoError(err)
+	_, err = pb.NewFuseStreamClient(conn).ListFaults(context.Background(), &pb.TargetRequest{})
 	s.NoError(err)
 	s.NoError(conn.Close())
+
+	// stopping the server removes the socket
+	server.Stop()
+	_, err = os.Stat(path)
+	s.ErrorIs(err, os.ErrNotExist)
+
+	// a stale socket is replaced
+	stale, err := net.Listen("unix", path)
+	s.Require().NoError(err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	s.Require().NoError(stale.Close())
+	listener, err = ListenRPC(address, 0600)
+	s.Require().NoError(err)
+	s.NoError(listener.Close())
+}
+
+func (s *RpcTestSuite) TestHealthAndServerInfo() {
+	info := NewServerInfo(pb.ServerMode_SERVER_MODE_FUSE)
+	info.BaseDir = "/data"
+	info.Mountpoint = "/mnt"
+	server := grpc.NewServer(grpc.Creds(insecure.NewCredentials()))
+	pb.RegisterFuseStreamServer(server, &Rpc{Faults: NewFaultManager(), Info: info})
+	info.RegisterHealth(server)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	s.Require().NoError(err)
+	defer func() { _ = conn.Close() }()
+	ctx := context.Background()
+	health := healthpb.NewHealthClient(conn)
+
+	rsp, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: pb.FuseStream_ServiceDesc.ServiceName})
+	s.Require().NoError(err)
+	s.Equal(healthpb.HealthCheckResponse_NOT_SERVING, rsp.Status)
+	got, err := pb.NewFuseStreamClient(conn).GetServerInfo(ctx, &pb.Void{})
+	s.Require().NoError(err)
+	s.False(got.Live)
+
+	info.SetLive(true)
+	rsp, err = health.Check(ctx, &healthpb.HealthCheckRequest{})
+	s.Require().NoError(err)
+	s.Equal(healthpb.HealthCheckResponse_SERVING, rsp.Status)
+
+	got, err = pb.NewFuseStreamClient(conn).GetServerInfo(ctx, &pb.Void{})
+	s.Require().NoError(err)
+	s.True(got.Live)
+	s.Equal(pb.ServerMode_SERVER_MODE_FUSE, got.Mode)
+	s.Equal("/mnt", got.Mountpoint)
+	s.Equal(int32(os.Getpid()), got.Pid)
+	s.NotEmpty(got.Version)
 }
@@ -0,0 +1,27 @@
+package fusestream
+
+import (
+	"net"
+	"os"
+)
+
+// SdNotify sends the state, e.g. READY=1, to the service manager. It's a no-op unless NOTIFY_SOCKET is set, see
+// sd_notify(3)
+func SdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:] // abstract namespace
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -0,0 +1,109 @@
+package fusestream
+
+import (
+	"os"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+// version is set by -ldflags "-X github.com/zperf/fusestream/v1.version=...", see Version
+var version string
+
+// Version returns the version set at link time, or the module version and VCS revision of the build info
+func Version() string {
+	if version != "" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	v := info.Main.Version
+	var revision string
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value[:min(len(s.Value), 12)]
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if revision != "" && (v == "" || v == "(devel)") {
+		v = "devel-" + revision
+		if modified {
+			v += "-dirty"
+		}
+	}
+	return v
+}
+
+// ServerInfo describes the server for GetServerInfo, and backs the standard gRPC health service. The server is
+// reported SERVING once the mount or the export is live
+type ServerInfo struct {
+	Mode        pb.ServerMode
+	BaseDir     string
+	Mountpoint  string
+	BackendFile string
+	Export      string
+	Exporter    *pb.ExporterInfo
+
+	start  time.Time
+	live   atomic.Bool
+	health *health.Server
+}
+
+func NewServerInfo(mode pb.ServerMode) *ServerInfo {
+	i := &ServerInfo{
+		Mode:   mode,
+		start:  time.Now(),
+		health: health.NewServer(),
+	}
+	i.SetLive(false)
+	return i
+}
+
+// RegisterHealth registers the gRPC health service reporting the liveness of the server
+func (i *ServerInfo) RegisterHealth(s grpc.ServiceRegistrar) {
+	healthpb.RegisterHealthServer(s, i.health)
+}
+
+// SetLive sets the health of the server and of the FuseStream service
+func (i *ServerInfo) SetLive(live bool) {
+	i.live.Store(live)
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if live {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	i.health.SetServingStatus("", status)
+	i.health.SetServingStatus(pb.FuseStream_ServiceDesc.ServiceName, status)
+}
+
+func (i *ServerInfo) Live() bool {
+	return i.live.Load()
+}
+
+func (i *ServerInfo) proto() *pb.ServerInfo {
+	now := time.Now()
+	return &pb.ServerInfo{
+		Version:     Version(),
+		Mode:        i.Mode,
+		BaseDir:     i.BaseDir,
+		Mountpoint:  i.Mountpoint,
+		BackendFile: i.BackendFile,
+		Export:      i.Export,
+		StartTimeNs: i.start.UnixNano(),
+		UptimeNs:    now.Sub(i.start).Nanoseconds(),
+		Live:        i.Live(),
+		Exporter:    i.Exporter,
+		Pid:         int32(os.Getpid()),
+	}
+}
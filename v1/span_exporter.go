@@ -2,7 +2,16 @@ package fusestream
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/rs/zerolog/log"
 	"github.com/xitongsys/parquet-go-source/local"
 	"github.com/xitongsys/parquet-go/parquet"
 	"github.com/xitongsys/parquet-go/source"
@@ -10,46 +19,240 @@ import (
 	"go.opentelemetry.io/otel/sdk/trace"
 )
 
+// SpanExporterOptions configures the rotation, all spans go to a single file if neither is set
+type SpanExporterOptions struct {
+	// RotateSize rotates the segment when its estimated size reaches RotateSize bytes
+	RotateSize int64
+	// RotateInterval rotates the segment when it has been open for RotateInterval, even if no spans arrive
+	RotateInterval time.Duration
+}
+
+// segmentTimeFormat sorts lexically in time order
+const segmentTimeFormat = "20060102T150405.000000Z"
+
+// inProgressSuffix is appended to the open segment, it's renamed to the segment path once finalized
+const inProgressSuffix = ".inprogress"
+
+const maxRowGroupSize = 128 * 1024 * 1024
+
+// The spans of file handles and NBD backends only group the operations under them, they're sent to OTLP but aren't
+// written as records, so they don't count as operations in the stats
+const (
+	handleSpanName  = "fuse.Handle"
+	backendSpanName = "nbd.Backend"
+)
+
 type SpanExporter struct {
-	fw source.ParquetFile
-	pw *writer.ParquetWriter
+	path string
+	opts SpanExporterOptions
+
+	mutex    sync.Mutex
+	fw       source.ParquetFile
+	pw       *writer.ParquetWriter
+	segment  string
+	openedAt time.Time
+	rows     int64
+
+	// done stops the interval rotation, stopped is closed once it returns. Both are nil without RotateInterval
+	done    chan struct{}
+	stopped chan struct{}
 }
 
 func NewSpanExporter(path string) (*SpanExporter, error) {
-	fw, err := local.NewLocalFileWriter(path)
+	return NewRotatingSpanExporter(path, SpanExporterOptions{})
+}
+
+// NewRotatingSpanExporter writes spans to timestamped segments next to path, see SegmentPath.
+// Each segment is a complete parquet file once rotated, a crash loses the open segment only
+func NewRotatingSpanExporter(path string, opts SpanExporterOptions) (*SpanExporter, error) {
+	e := &SpanExporter{path: path, opts: opts}
+	err := e.open()
 	if err != nil {
 		return nil, err
 	}
 
+	if opts.RotateInterval > 0 {
+		e.done = make(chan struct{})
+		e.stopped = make(chan struct{})
+		go e.rotateOnInterval()
+	}
+	return e, nil
+}
+
+// SegmentPath inserts the UTC timestamp before the extension, e.g. fs-20250102T150405.000000Z.parquet
+func SegmentPath(path string, t time.Time) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(path, ext), t.UTC().Format(segmentTimeFormat), ext)
+}
+
+func (e *SpanExporter) rotating() bool {
+	return e.opts.RotateSize > 0 || e.opts.RotateInterval > 0
+}
+
+func (e *SpanExporter) open() error {
+	e.openedAt = time.Now()
+	e.rows = 0
+
+	path := e.path
+	if e.rotating() {
+		e.segment = SegmentPath(e.path, e.openedAt)
+		path = e.segment + inProgressSuffix
+	}
+
+	fw, err := local.NewLocalFileWriter(path)
+	if err != nil {
+		return err
+	}
+
 	const parallelNum = 4
 	pw, err := writer.NewParquetWriter(fw, &IORecord{}, parallelNum)
 	if err != nil {
-		return nil, err
+		_ = fw.Close()
+		return err
 	}
 
-	pw.RowGroupSize = 128 * 1024 * 1024
+	pw.RowGroupSize = maxRowGroupSize
+	if e.opts.RotateSize > 0 {
+		pw.RowGroupSize = min(pw.RowGroupSize, e.opts.RotateSize)
+	}
 	pw.PageSize = 8 * 1024
 	pw.CompressionType = parquet.CompressionCodec_LZ4
-	return &SpanExporter{fw: fw, pw: pw}, nil
+
+	version := strconv.Itoa(IORecordSchemaVersion)
+	pw.Footer.KeyValueMetadata = append(pw.Footer.KeyValueMetadata, &parquet.KeyValue{
+		Key:   IORecordSchemaVersionKey,
+		Value: &version,
+	})
+
+	e.fw = fw
+	e.pw = pw
+	return nil
+}
+
+// close finalizes the open segment, an empty segment is removed
+func (e *SpanExporter) close() error {
+	err := e.pw.WriteStop()
+	if err != nil {
+		return err
+	}
+
+	err = e.fw.Close()
+	if err != nil {
+		return err
+	}
+
+	if !e.rotating() {
+		return nil
+	}
+
+	if e.rows == 0 {
+		return os.Remove(e.segment + inProgressSuffix)
+	}
+	return os.Rename(e.segment+inProgressSuffix, e.segment)
+}
+
+func (e *SpanExporter) shouldRotate() bool {
+	if e.opts.RotateInterval > 0 && time.Since(e.openedAt) >= e.opts.RotateInterval {
+		return true
+	}
+	// the writer buffers the current row group, count it in
+	return e.opts.RotateSize > 0 && e.pw.Offset+e.pw.Size+e.pw.ObjsSize >= e.opts.RotateSize
+}
+
+// rotate finalizes the segment and opens the next one if it's due, the mutex must be held
+func (e *SpanExporter) rotate() error {
+	if !e.rotating() || e.rows == 0 || !e.shouldRotate() {
+		return nil
+	}
+	err := e.close()
+	if err != nil {
+		return err
+	}
+	return e.open()
+}
+
+// rotateOnInterval finalizes the segment at the interval when no spans are exported, so an idle mount doesn't keep
+// its records in the in-progress segment
+func (e *SpanExporter) rotateOnInterval() {
+	defer close(e.stopped)
+
+	timer := time.NewTimer(e.opts.RotateInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-timer.C:
+		}
+
+		e.mutex.Lock()
+		err := e.rotate()
+		wait := e.opts.RotateInterval - time.Since(e.openedAt)
+		e.mutex.Unlock()
+		if err != nil {
+			log.Error().Err(err).Str("path", e.path).Msg("Failed to rotate the span segment")
+		}
+		if wait <= 0 {
+			// the segment is empty, it's rotated once it has records
+			wait = e.opts.RotateInterval
+		}
+		timer.Reset(wait)
+	}
 }
 
 func (e *SpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
 	_ = ctx
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
 	for _, span := range spans {
+		if span.Name() == handleSpanName || span.Name() == backendSpanName {
+			continue
+		}
 		record := NewIORecord(span)
 		err := e.pw.Write(&record)
 		if err != nil {
 			return err
 		}
+		e.rows++
 	}
-	return nil
+
+	return e.rotate()
 }
 
 func (e *SpanExporter) Shutdown(ctx context.Context) error {
 	_ = ctx
-	err := e.pw.WriteStop()
-	if err != nil {
-		return err
+	if e.done != nil {
+		close(e.done)
+		<-e.stopped
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.close()
+}
+
+// TeeSpanExporter sends every batch to all exporters
+type TeeSpanExporter struct {
+	exporters []trace.SpanExporter
+}
+
+func NewTeeSpanExporter(exporters ...trace.SpanExporter) *TeeSpanExporter {
+	return &TeeSpanExporter{exporters: exporters}
+}
+
+func (e *TeeSpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	var errs []error
+	for _, exporter := range e.exporters {
+		errs = append(errs, exporter.ExportSpans(ctx, spans))
+	}
+	return errors.Join(errs...)
+}
+
+func (e *TeeSpanExporter) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, exporter := range e.exporters {
+		errs = append(errs, exporter.Shutdown(ctx))
 	}
-	return e.fw.Close()
+	return errors.Join(errs...)
 }
@@ -0,0 +1,173 @@
+package fusestream
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+)
+
+func TestTeeSpanExporter(t *testing.T) {
+	a := tracetest.NewInMemoryExporter()
+	b := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(NewTeeSpanExporter(a, b)))
+
+	_, span := provider.Tracer(tracerName).Start(context.Background(), "fuse.Read")
+	span.End()
+
+	require.Len(t, a.GetSpans(), 1)
+	require.Len(t, b.GetSpans(), 1)
+	require.Equal(t, "fuse.Read", b.GetSpans()[0].Name)
+
+	require.NoError(t, provider.Shutdown(context.Background()))
+	require.Empty(t, a.GetSpans())
+	require.Empty(t, b.GetSpans())
+}
+
+func TestSpanExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.parquet")
+	exporter, err := NewSpanExporter(path)
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceInstanceIDKey.String("/mnt/fusestream"))))
+	tr := provider.Tracer(tracerName)
+
+	rc := int64(-5)
+	delay := time.Millisecond
+	fault := &Fault{IDs: []int32{3}, ReturnCode: &rc, DelayDuration: &delay}
+	_, span := tr.Start(context.Background(), "fuse.Write")
+	fault.MayReplaceErrorCode(4096)
+	fault.RecordToSpan(span)
+	span.SetAttributes(
+		attribute.String("path", "/data"),
+		attribute.Int64("offset", 8192),
+		attribute.String("fh", "7"),
+		attribute.Int("length", 0),
+		attribute.Int("errc", -5),
+	)
+	span.End()
+
+	// the handle span only groups the operations, it's no record
+	_, span = tr.Start(context.Background(), handleSpanName)
+	span.End()
+
+	_, span = tr.Start(context.Background(), "fuse.Rename")
+	span.SetAttributes(
+		attribute.String("oldpath", "/a"),
+		attribute.String("newpath", "/b"),
+		attribute.Int("errc", 0),
+	)
+	span.End()
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	fr, err := local.NewLocalFileReader(path)
+	require.NoError(t, err)
+	defer func() { _ = fr.Close() }()
+	pr, err := reader.NewParquetReader(fr, new(IORecord), 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	version := ""
+	for _, kv := range pr.Footer.KeyValueMetadata {
+		if kv.Key == IORecordSchemaVersionKey {
+			version = *kv.Value
+		}
+	}
+	require.Equal(t, strconv.Itoa(IORecordSchemaVersion), version)
+
+	records := make([]IORecord, pr.GetNumRows())
+	require.Len(t, records, 2)
+	require.NoError(t, pr.Read(&records))
+
+	w := records[0]
+	require.Equal(t, "fuse.Write", w.Name)
+	require.Equal(t, "/data", w.Path)
+	require.Equal(t, int64(8192), w.Offset)
+	require.Equal(t, int32(-5), w.Errc)
+	require.Equal(t, int64(7), *w.Fh)
+	require.Equal(t, int32(3), *w.FaultID)
+	require.Equal(t, int64(-5), *w.FaultRc)
+	require.Equal(t, delay.Nanoseconds(), w.FaultDelayNs)
+	require.Equal(t, "/mnt/fusestream", w.Instance)
+
+	r := records[1]
+	require.Equal(t, "/a", r.Path)
+	require.Equal(t, "/b", r.NewPath)
+	require.Nil(t, r.Fh)
+	require.Nil(t, r.FaultID)
+	require.Nil(t, r.FaultRc)
+}
+
+func TestRotatingSpanExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.parquet")
+	exporter, err := NewRotatingSpanExporter(path, SpanExporterOptions{RotateSize: 1})
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tr := provider.Tracer(tracerName)
+	for i := 0; i < 3; i++ {
+		_, span := tr.Start(context.Background(), "fuse.Read")
+		span.SetAttributes(attribute.Int64("offset", int64(i)))
+		span.End()
+
+		// each segment is readable once rotated
+		segments, err := filepath.Glob(filepath.Join(filepath.Dir(path), "spans-*.parquet"))
+		require.NoError(t, err)
+		require.Len(t, segments, i+1)
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	// the segment opened by the last rotation is empty and removed
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	segments, err := filepath.Glob(filepath.Join(filepath.Dir(path), "spans-*.parquet"))
+	require.NoError(t, err)
+	records, err := ReadIORecords(segments...)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	for i, record := range records {
+		require.Equal(t, int64(i), record.Offset)
+	}
+}
+
+func TestRotateIdleSegment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.parquet")
+	exporter, err := NewRotatingSpanExporter(path, SpanExporterOptions{RotateInterval: 100 * time.Millisecond})
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	_, span := provider.Tracer(tracerName).Start(context.Background(), "fuse.Read")
+	span.End()
+
+	// no more spans arrive, the segment is still finalized at the interval
+	var segments []string
+	require.Eventually(t, func() bool {
+		segments, err = filepath.Glob(filepath.Join(filepath.Dir(path), "spans-*.parquet"))
+		require.NoError(t, err)
+		return len(segments) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	records, err := ReadIORecords(segments...)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	require.NoError(t, provider.Shutdown(context.Background()))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
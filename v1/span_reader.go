@@ -0,0 +1,63 @@
+package fusestream
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+// readBatchSize bounds the rows decoded at once
+const readBatchSize = 64 * 1024
+
+// ReadIORecords reads the records of the parquet files written by SpanExporter, in the order of paths. The files are
+// decoded in batches of readBatchSize rows, but all records are returned at once
+func ReadIORecords(paths ...string) ([]IORecord, error) {
+	records := make([]IORecord, 0)
+	for _, path := range paths {
+		r, err := readIORecords(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s failed: %w", path, err)
+		}
+		records = append(records, r...)
+	}
+	return records, nil
+}
+
+func readIORecords(path string) ([]IORecord, error) {
+	fr, err := local.NewLocalFileReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = fr.Close() }()
+
+	const parallelNum = 4
+	pr, err := reader.NewParquetReader(fr, new(IORecord), parallelNum)
+	if err != nil {
+		return nil, err
+	}
+	defer pr.ReadStop()
+
+	for _, kv := range pr.Footer.KeyValueMetadata {
+		if kv.Key != IORecordSchemaVersionKey || kv.Value == nil {
+			continue
+		}
+		version, err := strconv.Atoi(*kv.Value)
+		if err != nil || version > IORecordSchemaVersion {
+			return nil, fmt.Errorf("unsupported schema version: %s", *kv.Value)
+		}
+	}
+
+	n := int(pr.GetNumRows())
+	records := make([]IORecord, 0, n)
+	for len(records) < n {
+		batch := make([]IORecord, min(readBatchSize, n-len(records)))
+		err = pr.Read(&batch)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, batch...)
+	}
+	return records, nil
+}
@@ -0,0 +1,139 @@
+package fusestream
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zperf/fusestream/pb"
+)
+
+// maxStatsPaths bounds the paths tracked by Stats, operations on further paths are counted under the empty path
+const maxStatsPaths = 4096
+
+// StatsLatencyBounds are the upper bounds of the latency buckets, from 10us doubling up to about 5s
+var StatsLatencyBounds = func() []time.Duration {
+	bounds := make([]time.Duration, 20)
+	for i := range bounds {
+		bounds[i] = 10 * time.Microsecond << i
+	}
+	return bounds
+}()
+
+type opCounters struct {
+	count     atomic.Int64
+	errors    atomic.Int64
+	bytes     atomic.Int64
+	elapsedNs atomic.Int64
+	buckets   []atomic.Int64
+}
+
+type pathCounters struct {
+	count     atomic.Int64
+	bytes     atomic.Int64
+	elapsedNs atomic.Int64
+}
+
+// Stats keeps cumulative counters of the operations for live views, e.g. fusestream top. It's updated by every
+// operation, so the counters are atomics and only the first operation of an op or a path stores a new entry
+type Stats struct {
+	ops       sync.Map // op name -> *opCounters
+	paths     sync.Map // path -> *pathCounters
+	pathCount atomic.Int64
+}
+
+func NewStats() *Stats {
+	return &Stats{}
+}
+
+func (s *Stats) observe(r *opResult) {
+	bucket, _ := slices.BinarySearch(StatsLatencyBounds, r.elapsed)
+
+	v, ok := s.ops.Load(r.op)
+	if !ok {
+		v, _ = s.ops.LoadOrStore(r.op, &opCounters{buckets: make([]atomic.Int64, len(StatsLatencyBounds)+1)})
+	}
+	op := v.(*opCounters)
+	op.count.Add(1)
+	op.bytes.Add(max(r.length, 0))
+	op.elapsedNs.Add(r.elapsed.Nanoseconds())
+	op.buckets[bucket].Add(1)
+	if r.errc < 0 {
+		op.errors.Add(1)
+	}
+
+	if r.path == "" {
+		return
+	}
+	v, ok = s.paths.Load(r.path)
+	if !ok {
+		// concurrent first operations of different paths may exceed the bound by a few entries
+		key := r.path
+		if s.pathCount.Load() >= maxStatsPaths {
+			key = ""
+		}
+		var loaded bool
+		v, loaded = s.paths.LoadOrStore(key, &pathCounters{})
+		if !loaded {
+			s.pathCount.Add(1)
+		}
+	}
+	path := v.(*pathCounters)
+	path.count.Add(1)
+	path.bytes.Add(max(r.length, 0))
+	path.elapsedNs.Add(r.elapsed.Nanoseconds())
+}
+
+// Count returns the number of finished operations
+func (s *Stats) Count() int64 {
+	var n int64
+	s.ops.Range(func(_, v any) bool {
+		n += v.(*opCounters).count.Load()
+		return true
+	})
+	return n
+}
+
+// Snapshot returns the counters as of now, ops and paths are sorted by name
+func (s *Stats) Snapshot() *pb.GetStatsResponse {
+	rsp := &pb.GetStatsResponse{
+		TimeNs:          time.Now().UnixNano(),
+		LatencyBoundsNs: make([]int64, len(StatsLatencyBounds)),
+	}
+	for i, bound := range StatsLatencyBounds {
+		rsp.LatencyBoundsNs[i] = bound.Nanoseconds()
+	}
+
+	s.ops.Range(func(k, v any) bool {
+		op := v.(*opCounters)
+		buckets := make([]int64, len(op.buckets))
+		for i := range op.buckets {
+			buckets[i] = op.buckets[i].Load()
+		}
+		rsp.Ops = append(rsp.Ops, &pb.OpStats{
+			Op:             k.(string),
+			Count:          op.count.Load(),
+			Errors:         op.errors.Load(),
+			Bytes:          op.bytes.Load(),
+			ElapsedNs:      op.elapsedNs.Load(),
+			LatencyBuckets: buckets,
+		})
+		return true
+	})
+	s.paths.Range(func(k, v any) bool {
+		path := v.(*pathCounters)
+		rsp.Paths = append(rsp.Paths, &pb.PathStats{
+			Path:      k.(string),
+			Count:     path.count.Load(),
+			Bytes:     path.bytes.Load(),
+			ElapsedNs: path.elapsedNs.Load(),
+		})
+		return true
+	})
+
+	slices.SortFunc(rsp.Ops, func(a, b *pb.OpStats) int { return cmp.Compare(a.Op, b.Op) })
+	slices.SortFunc(rsp.Paths, func(a, b *pb.PathStats) int { return cmp.Compare(a.Path, b.Path) })
+	return rsp
+}
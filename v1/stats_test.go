@@ -0,0 +1,48 @@
+package fusestream
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsConcurrent(t *testing.T) {
+	stats := NewStats()
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range maxStatsPaths {
+				stats.observe(&opResult{
+					op:      "fuse.Write",
+					path:    fmt.Sprintf("/%d/%d", g, i),
+					elapsed: time.Millisecond,
+					length:  10,
+					errc:    -int64(i % 2),
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	n := int64(8 * maxStatsPaths)
+	require.Equal(t, n, stats.Count())
+	rsp := stats.Snapshot()
+	require.Len(t, rsp.Ops, 1)
+	assert.Equal(t, n/2, rsp.Ops[0].Errors)
+	assert.Equal(t, 10*n, rsp.Ops[0].Bytes)
+
+	// the paths beyond the bound are counted under the empty path
+	assert.LessOrEqual(t, len(rsp.Paths), maxStatsPaths+8)
+	assert.Equal(t, "", rsp.Paths[0].Path)
+	var count int64
+	for _, path := range rsp.Paths {
+		count += path.Count
+	}
+	assert.Equal(t, n, count)
+}